@@ -0,0 +1,225 @@
+package arena
+
+import (
+	"bufio"
+	"fmt"
+	"game/boss"
+	"game/fight"
+	"game/player"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// Каждая пятая волна - элитный противник
+const eliteEvery = 5
+
+type enemyTemplate struct {
+	Title    string
+	HP       int
+	Strength int
+	Move     boss.SpecialMove
+}
+
+var templates = []enemyTemplate{
+	{
+		Title:    "Кошмарик",
+		HP:       70,
+		Strength: 14,
+		Move:     boss.SpecialMove{Name: "😱 Внезапный испуг", Damage: 8, Description: "Выпрыгивает из-под кровати"},
+	},
+	{
+		Title:    "Заводной солдатик",
+		HP:       90,
+		Strength: 12,
+		Move:     boss.SpecialMove{Name: "🔩 Штыковой выпад", Damage: 10, Description: "Пружина разжимается со звоном"},
+	},
+	{
+		Title:    "Бумажный дракон",
+		HP:       60,
+		Strength: 18,
+		Move:     boss.SpecialMove{Name: "🔥 Чернильное пламя", Damage: 12, Description: "Обжигает страницами старых сказок"},
+	},
+	{
+		Title:    "Клякса",
+		HP:       110,
+		Strength: 10,
+		Move:     boss.SpecialMove{Name: "🫧 Растекание", Damage: 6, Description: "Заливает всё вокруг темнотой"},
+	},
+	{
+		Title:    "Тень-с-подушкой",
+		HP:       80,
+		Strength: 15,
+		Move:     boss.SpecialMove{Name: "💤 Усыпляющий удар", Damage: 9, Description: "Мягко, но очень настойчиво"},
+	},
+}
+
+var prefixes = []string{"Злобный", "Туманный", "Ржавый", "Полуночный", "Кривой", "Голодный"}
+
+type Arena struct {
+	Player   *player.Player
+	Cycle    int
+	Wave     int
+	Score    int
+	Defeated int
+	Earned   int
+	rng      *rand.Rand
+}
+
+func NewArena(p *player.Player, cycle int) *Arena {
+	return &Arena{
+		Player: p,
+		Cycle:  cycle,
+		rng:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Run - волны противников до поражения или пока игрок не уйдёт на отдыхе
+func (a *Arena) Run() {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("\n=== 🏟 АРЕНА ВЫЖИВАНИЯ ===")
+	fmt.Println("Здоровье не восстанавливается между боями - только на привалах.")
+	a.Player.ResetForBattle()
+	a.Player.Mark("arena|start")
+
+	for {
+		a.Wave++
+		enemies := a.generateWave()
+
+		fmt.Printf("\n%s ВОЛНА %d %s\n", strings.Repeat("~", 10), a.Wave, strings.Repeat("~", 10))
+		fmt.Printf("Противников: %d\n", len(enemies))
+
+		for _, enemy := range enemies {
+			if !fight.NewFight(a.Player, enemy).Start() {
+				a.finish(false)
+				return
+			}
+			a.Defeated++
+			a.Score += 100 * a.Wave
+
+			reward := KillReward(a.Wave)
+			a.Earned += reward
+			a.Player.Earn(reward, fmt.Sprintf("arena|%d", a.Wave))
+		}
+
+		bonus := 50 * a.Wave
+		a.Score += bonus
+		fmt.Printf("\n🎉 Волна %d пройдена! Бонус к счёту: %d (счёт: %d)\n", a.Wave, bonus, a.Score)
+
+		if !a.restStop(reader) {
+			a.finish(true)
+			return
+		}
+	}
+}
+
+// restStop - привал между волнами: немного здоровья и выбор, идти ли дальше
+func (a *Arena) restStop(reader *bufio.Reader) bool {
+	heal := a.Player.GetMaxHP() * 30 / 100
+	fmt.Println("\n🔥 ПРИВАЛ")
+	a.Player.Heal(heal)
+
+	fmt.Println("1. Следующая волна")
+	fmt.Println("2. Покинуть арену с добычей")
+	fmt.Print("Выберите действие: ")
+	input, _ := reader.ReadString('\n')
+	return strings.TrimSpace(input) != "2"
+}
+
+func (a *Arena) finish(retreated bool) {
+	fmt.Println("\n=== ИТОГИ АРЕНЫ ===")
+	if retreated {
+		fmt.Println("🚪 Вы покинули арену живым.")
+	} else {
+		fmt.Println("💀 Арена взяла своё...")
+	}
+	fmt.Printf("🌊 Волн пройдено: %d\n", a.wavesCleared(retreated))
+	fmt.Printf("⚔️ Повержено противников: %d\n", a.Defeated)
+	fmt.Printf("✨ Заработано воображения: %d\n", a.Earned)
+	fmt.Printf("🏅 Счёт: %d\n", a.Score)
+
+	rank, err := RecordScore(Score{
+		Name:  a.Player.Name,
+		Score: a.Score,
+		Wave:  a.wavesCleared(retreated),
+		Cycle: a.Cycle,
+		Date:  time.Now(),
+	})
+	if err != nil {
+		fmt.Println("⚠️ Не удалось сохранить рекорд:", err)
+	} else if rank > 0 {
+		fmt.Printf("🏆 Новый рекорд! Место в таблице: %d\n", rank)
+	}
+
+	a.Player.HP = a.Player.GetMaxHP()
+}
+
+func (a *Arena) wavesCleared(retreated bool) int {
+	if retreated {
+		return a.Wave
+	}
+	return a.Wave - 1
+}
+
+// WaveSize - сколько противников в волне вместе с элитным; по нему сервер принимает заявки арены
+func WaveSize(wave int) int {
+	size := 1 + (wave-1)/3
+	if wave%eliteEvery == 0 {
+		size++
+	}
+	return size
+}
+
+func (a *Arena) generateWave() []*boss.Boss {
+	count := 1 + (a.Wave-1)/3
+	enemies := make([]*boss.Boss, 0, count)
+	for i := 0; i < count; i++ {
+		enemies = append(enemies, a.generateEnemy(false))
+	}
+	if a.Wave%eliteEvery == 0 {
+		enemies = append(enemies, a.generateEnemy(true))
+	}
+	return enemies
+}
+
+// generateEnemy - противник из шаблона, усиленный номером волны и циклом Новой игры+
+func (a *Arena) generateEnemy(elite bool) *boss.Boss {
+	return GenerateEnemy(a.rng, a.Wave, a.Cycle, elite)
+}
+
+// KillReward - воображение за каждого побеждённого противника волны; так же считает и сервер
+func KillReward(wave int) int {
+	return 10 + 5*wave
+}
+
+// GenerateEnemy - случайный противник заданного уровня; используется и за пределами арены
+func GenerateEnemy(rng *rand.Rand, level, cycle int, elite bool) *boss.Boss {
+	tpl := templates[rng.Intn(len(templates))]
+	name := prefixes[rng.Intn(len(prefixes))] + " " + tpl.Title
+
+	growth := 1 + 0.15*float64(level-1) + 0.35*float64(cycle)
+	hp := int(float64(tpl.HP) * growth)
+	strength := tpl.Strength + 2*(level-1) + 5*cycle
+	if elite {
+		name = "👑 " + name + " (элита)"
+		hp = hp * 3 / 2
+		strength += 5
+	}
+
+	enemy := boss.NewGuildBoss(name, hp, strength)
+	move := tpl.Move
+	move.Damage += strength
+	enemy.SpecialMoves = append(enemy.SpecialMoves, move)
+	if !elite {
+		// Обычные противники не владеют сокрушающим ударом гильдий
+		enemy.SpecialMoves = enemy.SpecialMoves[1:]
+	}
+
+	enemy.Aggression = 0.2 + 0.02*float32(level)
+	if enemy.Aggression > 0.6 {
+		enemy.Aggression = 0.6
+	}
+	return enemy
+}
@@ -0,0 +1,97 @@
+package arena
+
+import (
+	"encoding/json"
+	"fmt"
+	"game/save"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Сколько лучших результатов хранится в таблице рекордов
+const maxScores = 10
+
+type Score struct {
+	Name  string
+	Score int
+	Wave  int
+	Cycle int
+	Date  time.Time
+}
+
+func scoresPath() string {
+	return filepath.Join(save.Dir, "arena_scores.json")
+}
+
+func LoadScores() ([]Score, error) {
+	raw, err := os.ReadFile(scoresPath())
+	if os.IsNotExist(err) {
+		return []Score{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var scores []Score
+	if err := json.Unmarshal(raw, &scores); err != nil {
+		return nil, err
+	}
+	return scores, nil
+}
+
+// RecordScore - добавляет результат и возвращает место в таблице (0 - не попал)
+func RecordScore(score Score) (int, error) {
+	scores, err := LoadScores()
+	if err != nil {
+		return 0, err
+	}
+
+	scores = append(scores, score)
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+	if len(scores) > maxScores {
+		scores = scores[:maxScores]
+	}
+
+	rank := 0
+	for i, s := range scores {
+		if s.Name == score.Name && s.Date.Equal(score.Date) {
+			rank = i + 1
+			break
+		}
+	}
+
+	raw, err := json.MarshalIndent(scores, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(save.Dir, 0755); err != nil {
+		return 0, err
+	}
+	return rank, os.WriteFile(scoresPath(), raw, 0644)
+}
+
+func ShowHighScores() {
+	scores, err := LoadScores()
+	if err != nil {
+		fmt.Println("⚠️ Не удалось прочитать рекорды:", err)
+		return
+	}
+
+	fmt.Println("\n=== 🏆 РЕКОРДЫ АРЕНЫ ===")
+	if len(scores) == 0 {
+		fmt.Println("Рекордов пока нет")
+		return
+	}
+	for i, s := range scores {
+		cycle := ""
+		if s.Cycle > 0 {
+			cycle = fmt.Sprintf(" [НИ+%d]", s.Cycle)
+		}
+		fmt.Printf("%2d. %-16s %6d очков, волна %d%s (%s)\n",
+			i+1, s.Name, s.Score, s.Wave, cycle, s.Date.Format("02.01.2006"))
+	}
+}
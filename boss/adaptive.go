@@ -0,0 +1,59 @@
+package boss
+
+import (
+	"game/combat"
+	"math/rand"
+)
+
+// Adaptive - противник, который запоминает, куда соперник бьёт и что закрывает,
+// и со временем отвечает на его привычки: ставит блок туда, куда чаще бьют,
+// и бьёт туда, где реже защищаются. Немного случайности остаётся всегда
+type Adaptive struct {
+	attacks [3]int // сколько раз соперник бил в голову, корпус и ноги
+	blocks  [3]int // сколько раз закрывал их
+}
+
+func NewAdaptive() *Adaptive {
+	return &Adaptive{}
+}
+
+// Observe - запоминает ход соперника. Части тела вне диапазона (ход потрачен на предмет) пропускаются
+func (a *Adaptive) Observe(attack, block combat.BodyPart) {
+	if attack >= combat.Head && attack <= combat.Legs {
+		a.attacks[attack]++
+	}
+	if block >= combat.Head && block <= combat.Legs {
+		a.blocks[block]++
+	}
+}
+
+// ChooseAttack - бьёт чаще туда, где соперник реже ставит блок
+func (a *Adaptive) ChooseAttack() combat.BodyPart {
+	most := max(a.blocks[0], a.blocks[1], a.blocks[2])
+	var weights [3]int
+	for i, n := range a.blocks {
+		weights[i] = most - n + 1
+	}
+	return pickWeighted(weights)
+}
+
+// ChooseBlock - закрывает то, куда соперник чаще бьёт
+func (a *Adaptive) ChooseBlock() combat.BodyPart {
+	var weights [3]int
+	for i, n := range a.attacks {
+		weights[i] = 2*n + 1
+	}
+	return pickWeighted(weights)
+}
+
+func pickWeighted(weights [3]int) combat.BodyPart {
+	total := weights[0] + weights[1] + weights[2]
+	roll := rand.Intn(total)
+	for i, w := range weights {
+		if roll < w {
+			return combat.BodyPart(i)
+		}
+		roll -= w
+	}
+	return combat.Torso
+}
@@ -1,155 +1,212 @@
-package boss
-
-import (
-	"fmt"
-	"game/combat"
-	"math/rand"
-)
-
-type Boss struct {
-	Name         string
-	HP           int
-	MaxHP        int
-	Strength     int
-	Description  string
-	Phase        int
-	Stunned      bool
-	StunRounds   int
-	SpecialMoves []SpecialMove
-}
-
-type SpecialMove struct {
-	Name        string
-	Damage      int
-	Description string
-}
-
-func NewGuildBoss(name string, hp, strength int) *Boss {
-	return &Boss{
-		Name:        name,
-		HP:          hp,
-		MaxHP:       hp,
-		Strength:    strength,
-		Description: "",
-		Phase:       1,
-		Stunned:     false,
-		StunRounds:  0,
-		SpecialMoves: []SpecialMove{
-			{
-				Name:        "💥 Сокрушающий удар",
-				Damage:      strength + 15,
-				Description: "Мощная атака, пробивающая защиту",
-			},
-		},
-	}
-}
-
-func NewFinalBoss() *Boss {
-	return &Boss{
-		Name:        "👾 ДРЕВНИЙ ХАОС",
-		HP:          400,
-		MaxHP:       400,
-		Strength:    35,
-		Description: "Первобытная сила, стоящая за всеми конфликтами Воображариума",
-		Phase:       1,
-		Stunned:     false,
-		StunRounds:  0,
-		SpecialMoves: []SpecialMove{
-			{
-				Name:        "🌪 Вихрь реальности",
-				Damage:      45,
-				Description: "Искажает пространство вокруг",
-			},
-			{
-				Name:        "📖 Стирание истории",
-				Damage:      60,
-				Description: "Пытается стереть ваше прошлое",
-			},
-			{
-				Name:        "🌀 Пустота",
-				Damage:      80,
-				Description: "Поглощает всё воображение",
-			},
-		},
-	}
-}
-
-func (b *Boss) TakeDamage(damage int) {
-	b.HP -= damage
-	if b.HP < 0 {
-		b.HP = 0
-	}
-
-	// Проверяем смену фазы (только для финального босса)
-	if b.Name == "👾 ДРЕВНИЙ ХАОС" {
-		if b.HP <= b.MaxHP*2/3 && b.Phase == 1 {
-			b.Phase = 2
-			b.Strength += 10
-			fmt.Printf("\n⚠️ ФАЗА 2: %s становится сильнее! ⚠️\n", b.Name)
-		} else if b.HP <= b.MaxHP/3 && b.Phase == 2 {
-			b.Phase = 3
-			b.Strength += 15
-			fmt.Printf("\n⚠️ ФАЗА 3: %s в ярости! ⚠️\n", b.Name)
-		}
-	}
-
-	fmt.Printf("💥 Нанесено %d урона %s! Осталось: %d/%d\n", damage, b.Name, b.HP, b.MaxHP)
-}
-
-func (b *Boss) ChooseAttack() (combat.BodyPart, int) {
-	if b.Stunned {
-		b.StunRounds--
-		if b.StunRounds <= 0 {
-			b.Stunned = false
-			fmt.Printf("🌀 %s выходит из оглушения!\n", b.Name)
-		}
-		return combat.Stun, 0
-	}
-
-	// Шанс на особую атаку (30%)
-	if rand.Float32() < 0.3 && len(b.SpecialMoves) > 0 {
-		moveIndex := rand.Intn(len(b.SpecialMoves))
-		move := b.SpecialMoves[moveIndex]
-		fmt.Printf("\n⚠️ %s использует: %s!\n", b.Name, move.Name)
-		fmt.Printf("   %s\n", move.Description)
-		return combat.Torso, move.Damage
-	}
-
-	// Обычная атака
-	damage := b.Strength + rand.Intn(15)
-	part := combat.BodyPart(rand.Intn(3))
-	return part, damage
-}
-
-func (b *Boss) ChooseBlock() combat.BodyPart {
-	if b.Stunned {
-		return combat.Torso
-	}
-	return combat.BodyPart(rand.Intn(3))
-}
-
-func (b *Boss) ApplyStun(rounds int) {
-	b.Stunned = true
-	b.StunRounds = rounds
-	fmt.Printf("🌀 %s оглушен на %d хода!\n", b.Name, rounds)
-}
-
-func (b *Boss) IsStunned() bool {
-	return b.Stunned
-}
-
-func (b *Boss) IsAlive() bool {
-	return b.HP > 0
-}
-
-func (b *Boss) GetName() string {
-	return b.Name
-}
-
-func (b *Boss) GetHP() int {
-	return b.HP
-}
-
-func (b *Boss) GetStrength() int {
-	return b.Strength
-}
+package boss
+
+import (
+	"fmt"
+	"game/combat"
+	"math/rand"
+)
+
+type Boss struct {
+	Name         string
+	HP           int
+	MaxHP        int
+	Strength     int
+	BaseMaxHP    int
+	BaseStrength int
+	DamageScale  float64 // Множитель урона от сложности
+	Aggression   float32 // Шанс особой атаки
+	Description  string
+	Phase        int
+	Stunned      bool
+	StunRounds   int
+	SpecialMoves []SpecialMove
+}
+
+type SpecialMove struct {
+	Name        string
+	Damage      int
+	Description string
+}
+
+func NewGuildBoss(name string, hp, strength int) *Boss {
+	return &Boss{
+		Name:         name,
+		HP:           hp,
+		MaxHP:        hp,
+		Strength:     strength,
+		BaseMaxHP:    hp,
+		BaseStrength: strength,
+		DamageScale:  1,
+		Aggression:   0.3,
+		Description:  "",
+		Phase:        1,
+		Stunned:      false,
+		StunRounds:   0,
+		SpecialMoves: []SpecialMove{
+			{
+				Name:        "💥 Сокрушающий удар",
+				Damage:      strength + 15,
+				Description: "Мощная атака, пробивающая защиту",
+			},
+		},
+	}
+}
+
+func NewFinalBoss() *Boss {
+	return &Boss{
+		Name:         "👾 ДРЕВНИЙ ХАОС",
+		HP:           400,
+		MaxHP:        400,
+		Strength:     35,
+		BaseMaxHP:    400,
+		BaseStrength: 35,
+		DamageScale:  1,
+		Aggression:   0.3,
+		Description:  "Первобытная сила, стоящая за всеми конфликтами Воображариума",
+		Phase:        1,
+		Stunned:      false,
+		StunRounds:   0,
+		SpecialMoves: []SpecialMove{
+			{
+				Name:        "🌪 Вихрь реальности",
+				Damage:      45,
+				Description: "Искажает пространство вокруг",
+			},
+			{
+				Name:        "📖 Стирание истории",
+				Damage:      60,
+				Description: "Пытается стереть ваше прошлое",
+			},
+			{
+				Name:        "🌀 Пустота",
+				Damage:      80,
+				Description: "Поглощает всё воображение",
+			},
+		},
+	}
+}
+
+// newGamePlusMoves - приёмы, которые боссы осваивают в циклах Новой игры+
+var newGamePlusMoves = []SpecialMove{
+	{
+		Name:        "🌑 Эхо прошлого цикла",
+		Damage:      20,
+		Description: "Повторяет удар, которым вас уже побеждали",
+	},
+	{
+		Name:        "⏳ Петля времени",
+		Damage:      30,
+		Description: "Бьёт дважды в одно и то же мгновение",
+	},
+	{
+		Name:        "🪞 Зеркальный удар",
+		Damage:      40,
+		Description: "Возвращает вам вашу же силу",
+	},
+}
+
+// LearnNewGamePlusMoves - добавляет боссу по одному новому приёму за каждый цикл
+func (b *Boss) LearnNewGamePlusMoves(cycle int) {
+	for i := 0; i < cycle && i < len(newGamePlusMoves); i++ {
+		move := newGamePlusMoves[i]
+		move.Damage += b.BaseStrength + 10*cycle
+		b.SpecialMoves = append(b.SpecialMoves, move)
+	}
+}
+
+func (b *Boss) TakeDamage(damage int) {
+	b.HP -= damage
+	if b.HP < 0 {
+		b.HP = 0
+	}
+
+	// Проверяем смену фазы (только для финального босса)
+	if b.Name == "👾 ДРЕВНИЙ ХАОС" {
+		if b.HP <= b.MaxHP*2/3 && b.Phase == 1 {
+			b.Phase = 2
+			b.Strength += 10
+			fmt.Printf("\n⚠️ ФАЗА 2: %s становится сильнее! ⚠️\n", b.Name)
+		} else if b.HP <= b.MaxHP/3 && b.Phase == 2 {
+			b.Phase = 3
+			b.Strength += 15
+			fmt.Printf("\n⚠️ ФАЗА 3: %s в ярости! ⚠️\n", b.Name)
+		}
+	}
+
+	fmt.Printf("💥 Нанесено %d урона %s! Осталось: %d/%d\n", damage, b.Name, b.HP, b.MaxHP)
+}
+
+func (b *Boss) ChooseAttack() (combat.BodyPart, int) {
+	if b.Stunned {
+		b.StunRounds--
+		if b.StunRounds <= 0 {
+			b.Stunned = false
+			fmt.Printf("🌀 %s выходит из оглушения!\n", b.Name)
+		}
+		return combat.Stun, 0
+	}
+
+	// Шанс на особую атаку (по умолчанию 30%)
+	if rand.Float32() < b.Aggression && len(b.SpecialMoves) > 0 {
+		moveIndex := rand.Intn(len(b.SpecialMoves))
+		move := b.SpecialMoves[moveIndex]
+		fmt.Printf("\n⚠️ %s использует: %s!\n", b.Name, move.Name)
+		fmt.Printf("   %s\n", move.Description)
+		return combat.Torso, int(float64(move.Damage) * b.DamageScale)
+	}
+
+	// Обычная атака
+	damage := b.Strength + rand.Intn(15)
+	part := combat.BodyPart(rand.Intn(3))
+	return part, damage
+}
+
+func (b *Boss) ChooseBlock() combat.BodyPart {
+	if b.Stunned {
+		return combat.Torso
+	}
+	return combat.BodyPart(rand.Intn(3))
+}
+
+func (b *Boss) ApplyStun(rounds int) {
+	b.Stunned = true
+	b.StunRounds = rounds
+	fmt.Printf("🌀 %s оглушен на %d хода!\n", b.Name, rounds)
+}
+
+// Scale - пересчитывает характеристики от базовых с учётом сложности
+func (b *Boss) Scale(hpMult, strengthMult float64, aggression float32) {
+	b.MaxHP = int(float64(b.BaseMaxHP) * hpMult)
+	b.DamageScale = strengthMult
+	b.Aggression = aggression
+	b.Reset()
+}
+
+// Reset - возвращает босса в исходное состояние после проигранного боя
+func (b *Boss) Reset() {
+	b.HP = b.MaxHP
+	b.Strength = int(float64(b.BaseStrength) * b.DamageScale)
+	b.Phase = 1
+	b.Stunned = false
+	b.StunRounds = 0
+}
+
+func (b *Boss) IsStunned() bool {
+	return b.Stunned
+}
+
+func (b *Boss) IsAlive() bool {
+	return b.HP > 0
+}
+
+func (b *Boss) GetName() string {
+	return b.Name
+}
+
+func (b *Boss) GetHP() int {
+	return b.HP
+}
+
+func (b *Boss) GetStrength() int {
+	return b.Strength
+}
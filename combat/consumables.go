@@ -0,0 +1,101 @@
+package combat
+
+import (
+	"fmt"
+	"game/items"
+)
+
+// ItemsPerFight - сколько расходников можно использовать за один бой
+const ItemsPerFight = 3
+
+// Коды отказа в использовании расходника (их же сервер отдаёт клиенту PvP)
+const (
+	ItemErrRound    = "item_round"    // в этом раунде предмет уже использован
+	ItemErrLimit    = "item_limit"    // исчерпан лимит на бой
+	ItemErrCooldown = "item_cooldown" // предмет ещё перезаряжается
+	ItemErrUnusable = "item_unusable" // предмет нельзя использовать в этом бою
+)
+
+// ConsumableError - почему расходник сейчас нельзя использовать
+type ConsumableError struct {
+	Code   string
+	Rounds int // для перезарядки - сколько раундов осталось ждать
+}
+
+func (e *ConsumableError) Error() string {
+	switch e.Code {
+	case ItemErrRound:
+		return "в этом раунде предмет уже использован"
+	case ItemErrLimit:
+		return fmt.Sprintf("за бой можно использовать не больше %d предметов", ItemsPerFight)
+	case ItemErrCooldown:
+		return fmt.Sprintf("предмет перезаряжается, осталось раундов: %d", e.Rounds)
+	default:
+		return "этот предмет нельзя использовать в бою"
+	}
+}
+
+// ItemCooldown - сколько раундов после использования тот же предмет недоступен.
+// Печать мира срабатывает один раз за бой
+func ItemCooldown(effect items.ItemEffect) int {
+	switch {
+	case effect.SpecialEffect == "instant_peace":
+		return 1000
+	case effect.StunRounds > 0:
+		return 3 + effect.StunRounds
+	case effect.Heal > 0:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// ConsumableTracker - учёт расходников одного бойца в одном бою:
+// один предмет за раунд, перезарядка по названию предмета и общий лимит на бой
+type ConsumableTracker struct {
+	Used    int
+	last    int            // раунд последнего использования
+	readyAt map[string]int // название -> раунд, с которого предмет снова доступен
+}
+
+func NewConsumableTracker() *ConsumableTracker {
+	return &ConsumableTracker{readyAt: make(map[string]int)}
+}
+
+// Check - можно ли использовать предмет в раунде round
+func (t *ConsumableTracker) Check(item *items.Item, round int) error {
+	if !item.IsConsumable() {
+		return &ConsumableError{Code: ItemErrUnusable}
+	}
+	if t.Used > 0 && t.last == round {
+		return &ConsumableError{Code: ItemErrRound}
+	}
+	if t.Used >= ItemsPerFight {
+		return &ConsumableError{Code: ItemErrLimit}
+	}
+	if ready := t.readyAt[item.Name]; round < ready {
+		return &ConsumableError{Code: ItemErrCooldown, Rounds: ready - round}
+	}
+	return nil
+}
+
+// Use - отмечает использование предмета. Проверку нужно сделать заранее через Check
+func (t *ConsumableTracker) Use(item *items.Item, round int) {
+	t.Used++
+	t.last = round
+	t.readyAt[item.Name] = round + ItemCooldown(item.Effect)
+}
+
+// Left - сколько ещё предметов можно использовать в этом бою
+func (t *ConsumableTracker) Left() int {
+	return ItemsPerFight - t.Used
+}
+
+// HealTo - здоровье после лечения, не выше максимума
+func HealTo(hp, maxHP, heal int) int {
+	hp += heal
+	if hp > maxHP {
+		hp = maxHP
+	}
+	return hp
+}
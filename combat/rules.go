@@ -0,0 +1,70 @@
+package combat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	NormalizedHP  = 100 // Здоровье обоих бойцов, если правила выравнивают здоровье
+	MaxRoundLimit = 50
+)
+
+// MatchRules - особые правила товарищеского PvP-боя. В протоколе записываются
+// через запятую: normhp, noitems, rounds=N; пустая строка - обычные правила
+type MatchRules struct {
+	NormalizeHP bool
+	NoItems     bool
+	RoundLimit  int // после стольких раундов побеждает тот, у кого больше здоровья; 0 - без ограничения
+}
+
+func ParseRules(raw string) MatchRules {
+	var rules MatchRules
+	for _, part := range strings.Split(raw, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "normhp":
+			rules.NormalizeHP = true
+		case "noitems":
+			rules.NoItems = true
+		case "rounds":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				rules.RoundLimit = min(n, MaxRoundLimit)
+			}
+		}
+	}
+	return rules
+}
+
+func (r MatchRules) String() string {
+	parts := make([]string, 0, 3)
+	if r.NormalizeHP {
+		parts = append(parts, "normhp")
+	}
+	if r.NoItems {
+		parts = append(parts, "noitems")
+	}
+	if r.RoundLimit > 0 {
+		parts = append(parts, fmt.Sprintf("rounds=%d", r.RoundLimit))
+	}
+	return strings.Join(parts, ",")
+}
+
+// Describe - правила словами для экрана игрока
+func (r MatchRules) Describe() string {
+	parts := make([]string, 0, 3)
+	if r.NormalizeHP {
+		parts = append(parts, fmt.Sprintf("здоровье выровнено до %d", NormalizedHP))
+	}
+	if r.NoItems {
+		parts = append(parts, "без предметов")
+	}
+	if r.RoundLimit > 0 {
+		parts = append(parts, fmt.Sprintf("не больше %d раундов", r.RoundLimit))
+	}
+	if len(parts) == 0 {
+		return "обычные правила"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,307 @@
+package crafting
+
+import (
+	"bufio"
+	"fmt"
+	"game/items"
+	"game/player"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Open - мастерская: крафт, улучшение и разбор предметов из инвентаря.
+// Надетые предметы сначала нужно снять
+func Open(p *player.Player, reader *bufio.Reader) {
+	for {
+		fmt.Println("\n=== 🔨 МАСТЕРСКАЯ ===")
+		fmt.Printf("✨ Воображение: %d\n", p.Imagination)
+		showMaterials(p)
+		fmt.Println("1. Создать предмет")
+		fmt.Println("2. Улучшить предмет")
+		fmt.Println("3. Разобрать предмет на материалы")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		switch readLine(reader) {
+		case "1":
+			craftMenu(p, reader)
+		case "2":
+			upgradeMenu(p, reader)
+		case "3":
+			salvageMenu(p, reader)
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+func showMaterials(p *player.Player) {
+	if len(p.Materials) == 0 {
+		fmt.Println("🧱 Материалов нет - их дают разобранные предметы")
+		return
+	}
+	fmt.Printf("🧱 Материалы: %s\n", formatMaterials(p.Materials))
+}
+
+func craftMenu(p *player.Player, reader *bufio.Reader) {
+	fmt.Println("\n=== 📜 РЕЦЕПТЫ ===")
+	for i, recipe := range recipes {
+		mark := "❌"
+		if canCraft(p, recipe) {
+			mark = "✅"
+		}
+		fmt.Printf("%s %d. %s%s\033[0m\n", mark, i+1, recipe.Result.GetRarityColor(), recipe.Result.Name)
+		fmt.Printf("   └─ %s\n", recipe.Result.Description)
+		fmt.Printf("   Нужно: %s\n", describeCost(recipe.Ingredients, recipe.Materials, recipe.Imagination))
+	}
+	fmt.Println("\n0. Назад")
+	fmt.Print("Выберите рецепт: ")
+
+	choice, err := strconv.Atoi(readLine(reader))
+	if err != nil || choice < 1 || choice > len(recipes) {
+		return
+	}
+	Craft(p, recipes[choice-1])
+}
+
+// Craft - создаёт предмет по рецепту, забирая ингредиенты, материалы и воображение
+func Craft(p *player.Player, recipe Recipe) bool {
+	if !canCraft(p, recipe) {
+		fmt.Println("❌ Не хватает ингредиентов, материалов или воображения")
+		return false
+	}
+
+	taken := make([]*items.Item, 0, len(recipe.Ingredients))
+	for _, name := range recipe.Ingredients {
+		taken = append(taken, p.TakeItemByName(name))
+	}
+
+	result := recipe.Result
+	if !p.HasRoomFor(&result) {
+		for _, item := range taken {
+			p.PutItem(item)
+		}
+		fmt.Println("❌ Рюкзак полон - готовый предмет некуда положить")
+		return false
+	}
+	p.SpendMaterials(recipe.Materials)
+	p.Imagination -= recipe.Imagination
+
+	fmt.Println("🔨 Мастерская гудит...")
+	p.AddItem(&result)
+	return true
+}
+
+func canCraft(p *player.Player, recipe Recipe) bool {
+	if p.Imagination < recipe.Imagination || !p.HasMaterials(recipe.Materials) {
+		return false
+	}
+
+	// Одинаковые ингредиенты считаются поштучно, в том числе из стопок
+	need := make(map[string]int)
+	for _, name := range recipe.Ingredients {
+		need[name]++
+	}
+	// Улучшенные предметы в рецепты не идут, чтобы не сгорели случайно
+	for name, count := range need {
+		if p.CountItem(name) < count {
+			return false
+		}
+	}
+	return true
+}
+
+func upgradeMenu(p *player.Player, reader *bufio.Reader) {
+	index := pickItem(p, reader, "Выберите предмет для улучшения: ", func(item *items.Item) string {
+		if !canUpgrade(item) {
+			return "нельзя улучшить"
+		}
+		next := upgrades[item.Level]
+		return fmt.Sprintf("→ +%d, шанс %d%%, %s", next.Level, next.SuccessChance,
+			describeCost(nil, next.Materials, next.Imagination))
+	})
+	if index < 0 {
+		return
+	}
+
+	item := p.Inventory[index]
+	if item.Quantity() == 1 {
+		Upgrade(p, item)
+		return
+	}
+
+	// Улучшенная штука уходит из стопки в отдельную ячейку
+	if p.FreeSlots() <= 0 {
+		fmt.Println("❌ Чтобы улучшить предмет из стопки, нужна свободная ячейка рюкзака")
+		return
+	}
+	item = p.TakeItem(index)
+	Upgrade(p, item)
+	p.PutItem(item)
+}
+
+func canUpgrade(item *items.Item) bool {
+	if item.Level >= MaxLevel() {
+		return false
+	}
+	e := item.Effect
+	if item.BaseEffect != nil {
+		e = *item.BaseEffect
+	}
+	return e.Heal > 0 || e.Strength > 0 || e.MaxHP > 0
+}
+
+// Upgrade - попытка поднять уровень предмета. Цена списывается при любом исходе,
+// а на высоких уровнях неудача ещё и откатывает предмет на уровень ниже
+func Upgrade(p *player.Player, item *items.Item) bool {
+	if !canUpgrade(item) {
+		fmt.Println("❌ Этот предмет нельзя улучшить")
+		return false
+	}
+
+	next := upgrades[item.Level]
+	if p.Imagination < next.Imagination || !p.HasMaterials(next.Materials) {
+		fmt.Println("❌ Не хватает материалов или воображения")
+		return false
+	}
+	p.SpendMaterials(next.Materials)
+	p.Imagination -= next.Imagination
+
+	if rand.Intn(100) >= next.SuccessChance {
+		fmt.Printf("💥 Улучшение не удалось! (%d%%)\n", next.SuccessChance)
+		if next.DowngradeOnFail && item.Level > 0 {
+			setLevel(item, item.Level-1)
+			fmt.Printf("📉 Предмет ослаб: %s\n", item.DisplayName())
+		}
+		return false
+	}
+
+	setLevel(item, next.Level)
+	fmt.Printf("🌟 Успех! %s\n", item.DisplayName())
+	return true
+}
+
+// setLevel - пересчитывает эффект от базового, чтобы округления не копились
+func setLevel(item *items.Item, level int) {
+	if item.BaseEffect == nil {
+		base := item.Effect
+		item.BaseEffect = &base
+	}
+
+	percent := 0
+	if level > 0 {
+		percent = upgrades[level-1].EffectPercent
+	}
+
+	base := *item.BaseEffect
+	item.Effect = base
+	item.Effect.Heal = base.Heal + base.Heal*percent/100
+	item.Effect.Strength = base.Strength + base.Strength*percent/100
+	item.Effect.MaxHP = base.MaxHP + base.MaxHP*percent/100
+	item.Level = level
+}
+
+func salvageMenu(p *player.Player, reader *bufio.Reader) {
+	index := pickItem(p, reader, "Выберите предмет для разбора: ", func(item *items.Item) string {
+		return "→ " + formatMaterials(SalvageYield(item))
+	})
+	if index < 0 {
+		return
+	}
+
+	item := p.Inventory[index]
+	fmt.Printf("Разобрать %s? Предмет пропадёт навсегда (да/нет): ", item.DisplayName())
+	answer := strings.ToLower(readLine(reader))
+	if answer != "да" && answer != "д" && answer != "yes" {
+		return
+	}
+	Salvage(p, index)
+}
+
+// SalvageYield - материалы за разбор: по редкости и уровню улучшения
+func SalvageYield(item *items.Item) map[string]int {
+	yield := make(map[string]int)
+	for name, amount := range materials.Salvage[item.Rarity] {
+		yield[name] += amount
+	}
+	for name, amount := range materials.PerUpgradeLevel {
+		yield[name] += amount * item.Level
+	}
+	return yield
+}
+
+func Salvage(p *player.Player, index int) bool {
+	if index < 0 || index >= len(p.Inventory) {
+		fmt.Println("Неверный номер предмета")
+		return false
+	}
+
+	item := p.TakeItem(index)
+	fmt.Printf("♻️ Разобрано: %s\n", item.DisplayName())
+	yield := SalvageYield(item)
+	for _, name := range sortedKeys(yield) {
+		p.AddMaterial(name, yield[name])
+	}
+	return true
+}
+
+// pickItem - выбор предмета инвентаря; note подписывает каждую строку
+func pickItem(p *player.Player, reader *bufio.Reader, prompt string, note func(*items.Item) string) int {
+	if len(p.Inventory) == 0 {
+		fmt.Println("Инвентарь пуст. Надетые предметы сначала нужно снять")
+		return -1
+	}
+
+	fmt.Println()
+	for i, item := range p.Inventory {
+		fmt.Printf("%s%d. %s%s\033[0m %s\n", item.GetRarityColor(), i+1, item.DisplayName(), item.QuantityLabel(), note(item))
+	}
+	fmt.Println("0. Назад")
+	fmt.Print(prompt)
+
+	choice, err := strconv.Atoi(readLine(reader))
+	if err != nil || choice < 1 || choice > len(p.Inventory) {
+		return -1
+	}
+	return choice - 1
+}
+
+func describeCost(ingredients []string, need map[string]int, imagination int) string {
+	parts := make([]string, 0)
+	if len(ingredients) > 0 {
+		parts = append(parts, strings.Join(ingredients, " + "))
+	}
+	if len(need) > 0 {
+		parts = append(parts, formatMaterials(need))
+	}
+	if imagination > 0 {
+		parts = append(parts, fmt.Sprintf("%d✨", imagination))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatMaterials(m map[string]int) string {
+	parts := make([]string, 0, len(m))
+	for _, name := range sortedKeys(m) {
+		parts = append(parts, fmt.Sprintf("%s x%d", name, m[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for name := range m {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func readLine(reader *bufio.Reader) string {
+	input, _ := reader.ReadString('\n')
+	return strings.TrimSpace(input)
+}
@@ -0,0 +1,76 @@
+package crafting
+
+import (
+	"embed"
+	"encoding/json"
+	"game/items"
+)
+
+//go:embed data/*.json
+var dataFiles embed.FS
+
+// Recipe - из предметов, материалов и воображения получается новый предмет
+type Recipe struct {
+	Ingredients []string       `json:"ingredients"`
+	Materials   map[string]int `json:"materials"`
+	Imagination int            `json:"imagination"`
+	Result      items.Item     `json:"result"`
+}
+
+// UpgradeLevel - цена и риск перехода на уровень Level
+type UpgradeLevel struct {
+	Level           int            `json:"level"`
+	Imagination     int            `json:"imagination"`
+	Materials       map[string]int `json:"materials"`
+	SuccessChance   int            `json:"success_chance"`
+	EffectPercent   int            `json:"effect_percent"` // Прибавка к базовому эффекту на этом уровне
+	DowngradeOnFail bool           `json:"downgrade_on_fail"`
+}
+
+type materialTable struct {
+	Salvage         map[items.Rarity]map[string]int `json:"salvage"`
+	PerUpgradeLevel map[string]int                  `json:"per_upgrade_level"` // Возврат за каждый уровень улучшения при разборе
+}
+
+var (
+	recipes   = mustLoad[[]Recipe]("data/recipes.json")
+	upgrades  = mustLoad[[]UpgradeLevel]("data/upgrades.json")
+	materials = mustLoad[materialTable]("data/materials.json")
+)
+
+// MaxLevel - наибольший уровень улучшения
+func MaxLevel() int {
+	return len(upgrades)
+}
+
+// mustLoad - данные вшиты в бинарник, поэтому ошибка в них - ошибка сборки, а не игрока
+func mustLoad[T any](path string) T {
+	var value T
+	raw, err := dataFiles.ReadFile(path)
+	if err != nil {
+		panic("crafting: " + err.Error())
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		panic("crafting: " + path + ": " + err.Error())
+	}
+	return value
+}
+
+// MaxUpgradePercent - прибавка к эффекту на наивысшем уровне улучшения
+func MaxUpgradePercent() int {
+	if len(upgrades) == 0 {
+		return 0
+	}
+	return upgrades[len(upgrades)-1].EffectPercent
+}
+
+// FindResult - предмет, который можно создать в мастерской, по названию
+func FindResult(name string) *items.Item {
+	for _, recipe := range recipes {
+		if recipe.Result.Name == name {
+			result := recipe.Result
+			return &result
+		}
+	}
+	return nil
+}
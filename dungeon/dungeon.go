@@ -0,0 +1,266 @@
+package dungeon
+
+import (
+	"bufio"
+	"fmt"
+	"game/arena"
+	"game/fight"
+	"game/items"
+	"game/player"
+	"game/shop"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Run - один спуск в подземелье. Здоровье переносится между боями, реликвии живут до конца забега
+type Run struct {
+	Player   *player.Player
+	Seed     int64
+	Cycle    int
+	Map      *Map
+	Floor    int
+	Position int
+	Relics   []Relic
+	Mods     fight.Modifiers
+	Earned   int
+	rng      *rand.Rand
+	reader   *bufio.Reader
+}
+
+func NewRun(p *player.Player, seed int64, cycle int) *Run {
+	rng := rand.New(rand.NewSource(seed))
+	return &Run{
+		Player: p,
+		Seed:   seed,
+		Cycle:  cycle,
+		Map:    generateMap(rng),
+		Floor:  -1,
+		rng:    rng,
+		reader: bufio.NewReader(os.Stdin),
+	}
+}
+
+func (r *Run) Start() {
+	fmt.Println("\n=== 🕳 ПОДЗЕМЕЛЬЕ СНОВ ===")
+	fmt.Printf("🎲 Сид забега: %d (поделитесь им, чтобы друзья прошли ту же карту)\n", r.Seed)
+	fmt.Println("Здоровье не восстанавливается после боёв - берегите его.")
+	r.Player.ResetForBattle()
+
+	for r.Floor < len(r.Map.Floors)-1 {
+		next := r.nextRooms()
+		r.Map.Show(r.Floor, r.Position)
+		r.showStatus()
+
+		choice := r.choosePath(next)
+		if choice < 0 {
+			r.finish("🚪 Вы покинули подземелье.")
+			return
+		}
+
+		r.Floor++
+		r.Position = choice
+		room := r.Map.Floors[r.Floor][r.Position]
+		room.Visited = true
+
+		if !r.enterRoom(room) {
+			r.finish("💀 Подземелье поглотило вас...")
+			return
+		}
+	}
+
+	r.finish("🏆 Страж подземелья повержен! Забег пройден!")
+}
+
+func (r *Run) nextRooms() []int {
+	if r.Floor < 0 {
+		indices := make([]int, len(r.Map.Floors[0]))
+		for i := range indices {
+			indices[i] = i
+		}
+		return indices
+	}
+	return r.Map.Floors[r.Floor][r.Position].Next
+}
+
+func (r *Run) choosePath(next []int) int {
+	for {
+		fmt.Println("\nКУДА ИДТИ:")
+		for i, idx := range next {
+			fmt.Printf("%d — %s\n", i+1, r.Map.Floors[r.Floor+1][idx].Type)
+		}
+		fmt.Println("0 — Покинуть подземелье")
+		fmt.Print("> ")
+
+		input, _ := r.reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || choice < 0 || choice > len(next) {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return -1
+		}
+		return next[choice-1]
+	}
+}
+
+// enterRoom - возвращает false, если игрок погиб
+func (r *Run) enterRoom(room *Room) bool {
+	fmt.Printf("\n%s ЭТАЖ %d: %s %s\n", strings.Repeat("=", 8), r.Floor+1, room.Type, strings.Repeat("=", 8))
+
+	switch room.Type {
+	case RoomFight:
+		return r.battle(false, "fight")
+	case RoomElite:
+		if !r.battle(true, "elite") {
+			return false
+		}
+		r.gainRelic()
+	case RoomBoss:
+		return r.battle(true, "boss")
+	case RoomShop:
+		r.visitShop()
+	case RoomRest:
+		fmt.Println("Вы разводите костёр и отдыхаете.")
+		r.Player.Heal(r.Player.GetMaxHP() * 35 / 100)
+	case RoomEvent:
+		r.event()
+	case RoomTreasure:
+		fmt.Println("Вы находите сундук, покрытый звёздной пылью.")
+		r.gainRelic()
+	}
+	return r.Player.IsAlive()
+}
+
+// Reward - награда за событие подземелья на этаже floor (с нуля); false - такого события нет.
+// По этим же правилам сервер начисляет награду в кошелёк
+func Reward(event string, floor int) (int, bool) {
+	if floor < 0 || floor >= floorCount {
+		return 0, false
+	}
+	switch event {
+	case "fight":
+		return 15 + 5*floor, true
+	case "elite":
+		return 30 + 5*floor, true
+	case "boss":
+		return 150, true
+	case "relic":
+		return 25, true
+	case "library":
+		return 40, true
+	}
+	return 0, false
+}
+
+// earn - начисляет награду за событие и ставит её в очередь на сверку с сервером
+func (r *Run) earn(event string) {
+	reward, _ := Reward(event, r.Floor)
+	r.Earned += reward
+	r.Player.Earn(reward, fmt.Sprintf("dungeon|%s|%d", event, r.Floor))
+}
+
+func (r *Run) battle(elite bool, event string) bool {
+	enemy := arena.GenerateEnemy(r.rng, r.Floor+1, r.Cycle, elite)
+	if r.Map.Floors[r.Floor][r.Position].Type == RoomBoss {
+		enemy.Name = "👾 Страж подземелья - " + enemy.Name
+		enemy.MaxHP *= 2
+		enemy.HP = enemy.MaxHP
+	}
+
+	f := fight.NewFight(r.Player, enemy)
+	f.Mods.Add(r.Mods)
+	if !f.Start() {
+		return false
+	}
+
+	r.earn(event)
+	return true
+}
+
+func (r *Run) gainRelic() {
+	relic := randomRelic(r.rng, r.Relics)
+	if relic == nil {
+		fmt.Println("Вы уже собрали все реликвии. Внутри лишь немного воображения.")
+		r.earn("relic")
+		return
+	}
+	r.Relics = append(r.Relics, *relic)
+	r.Mods.Add(relic.Mods)
+	fmt.Printf("🔮 Реликвия: %s - %s\n", relic.Name, relic.Description)
+}
+
+// visitShop - подземная лавка с тремя случайными товарами из общего ассортимента
+func (r *Run) visitShop() {
+	all := items.GetAllItems()
+	r.rng.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+	if len(all) > 3 {
+		all = all[:3]
+	}
+	shop.NewFixedShop(all).Visit(r.Player)
+}
+
+func (r *Run) event() {
+	switch r.rng.Intn(4) {
+	case 0:
+		fmt.Println("⛲ Фонтан желаний. Бросить 30 воображения и исцелиться полностью? (да/нет)")
+		if r.confirm() && r.Player.SpendImagination(30) {
+			r.Player.Heal(r.Player.GetMaxHP())
+		}
+	case 1:
+		fmt.Println("📦 Забытый сундук. Открыть? Внутри может быть ловушка (да/нет)")
+		if !r.confirm() {
+			return
+		}
+		if r.rng.Intn(2) == 0 {
+			r.gainRelic()
+			return
+		}
+		damage := r.Player.GetMaxHP() / 5
+		if damage >= r.Player.HP {
+			damage = r.Player.HP - 1
+		}
+		fmt.Println("🪤 Ловушка!")
+		r.Player.TakeDamage(damage)
+	case 2:
+		fmt.Println("🌙 Странник снов предлагает реликвию за часть вашей жизненной силы (-25% текущего здоровья) (да/нет)")
+		if r.confirm() {
+			r.Player.TakeDamage(r.Player.HP / 4)
+			r.gainRelic()
+		}
+	default:
+		fmt.Println("📚 Тихая библиотека. Между страниц вы находите 40 воображения.")
+		r.earn("library")
+	}
+}
+
+func (r *Run) confirm() bool {
+	fmt.Print("> ")
+	input, _ := r.reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "да" || input == "д" || input == "yes"
+}
+
+func (r *Run) showStatus() {
+	fmt.Printf("\n❤️ Здоровье: %d/%d  ✨ Воображение: %d\n", r.Player.HP, r.Player.GetMaxHP(), r.Player.Imagination)
+	if len(r.Relics) > 0 {
+		fmt.Println("🔮 Реликвии:")
+		for _, relic := range r.Relics {
+			fmt.Printf("   %s - %s\n", relic.Name, relic.Description)
+		}
+	}
+}
+
+func (r *Run) finish(message string) {
+	fmt.Println("\n=== ИТОГИ ЗАБЕГА ===")
+	fmt.Println(message)
+	fmt.Printf("🎲 Сид: %d\n", r.Seed)
+	fmt.Printf("🕳 Достигнут этаж: %d/%d\n", r.Floor+1, len(r.Map.Floors))
+	fmt.Printf("🔮 Реликвий собрано: %d\n", len(r.Relics))
+	fmt.Printf("✨ Заработано воображения: %d\n", r.Earned)
+
+	// Реликвии исчезают вместе с подземельем
+	r.Player.HP = r.Player.GetMaxHP()
+}
@@ -0,0 +1,166 @@
+package dungeon
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+type RoomType int
+
+const (
+	RoomFight RoomType = iota
+	RoomElite
+	RoomShop
+	RoomRest
+	RoomEvent
+	RoomTreasure
+	RoomBoss
+)
+
+func (r RoomType) String() string {
+	switch r {
+	case RoomFight:
+		return "⚔️ Бой"
+	case RoomElite:
+		return "👑 Элита"
+	case RoomShop:
+		return "🏪 Лавка"
+	case RoomRest:
+		return "🔥 Привал"
+	case RoomEvent:
+		return "❓ Событие"
+	case RoomTreasure:
+		return "💎 Сокровище"
+	case RoomBoss:
+		return "👾 Страж подземелья"
+	default:
+		return "неизвестно"
+	}
+}
+
+func (r RoomType) icon() string {
+	return strings.Fields(r.String())[0]
+}
+
+type Room struct {
+	Type    RoomType
+	Next    []int // Индексы комнат следующего этажа, куда ведут проходы
+	Visited bool
+}
+
+type Map struct {
+	Floors [][]*Room
+}
+
+// Этажей до стража, включая стартовый
+const floorCount = 8
+
+type roomWeight struct {
+	Type   RoomType
+	Weight int
+}
+
+var roomWeights = []roomWeight{
+	{RoomFight, 45},
+	{RoomElite, 10},
+	{RoomShop, 10},
+	{RoomRest, 12},
+	{RoomEvent, 15},
+	{RoomTreasure, 8},
+}
+
+// generateMap - карта целиком определяется генератором, поэтому один сид даёт одну карту
+func generateMap(rng *rand.Rand) *Map {
+	m := &Map{}
+	for floor := 0; floor < floorCount; floor++ {
+		width := 2 + rng.Intn(3)
+		rooms := make([]*Room, width)
+		for i := range rooms {
+			rooms[i] = &Room{Type: pickRoomType(rng, floor)}
+		}
+		m.Floors = append(m.Floors, rooms)
+	}
+	m.Floors = append(m.Floors, []*Room{{Type: RoomBoss}})
+
+	for floor := 0; floor < len(m.Floors)-1; floor++ {
+		connectFloors(rng, m.Floors[floor], m.Floors[floor+1])
+	}
+	return m
+}
+
+func pickRoomType(rng *rand.Rand, floor int) RoomType {
+	switch {
+	case floor == 0:
+		return RoomFight
+	case floor == floorCount-1:
+		return RoomRest
+	}
+
+	total := 0
+	for _, w := range roomWeights {
+		if w.Type == RoomElite && floor < 2 {
+			continue
+		}
+		total += w.Weight
+	}
+
+	roll := rng.Intn(total)
+	for _, w := range roomWeights {
+		if w.Type == RoomElite && floor < 2 {
+			continue
+		}
+		if roll < w.Weight {
+			return w.Type
+		}
+		roll -= w.Weight
+	}
+	return RoomFight
+}
+
+// connectFloors - у каждой комнаты есть выход вперёд, и в каждую комнату ведёт хотя бы один проход
+func connectFloors(rng *rand.Rand, current, next []*Room) {
+	for i, room := range current {
+		target := i * len(next) / len(current)
+		room.Next = append(room.Next, target)
+		if target+1 < len(next) && rng.Intn(2) == 0 {
+			room.Next = append(room.Next, target+1)
+		}
+	}
+
+	for j := range next {
+		reachable := false
+		for _, room := range current {
+			for _, n := range room.Next {
+				if n == j {
+					reachable = true
+				}
+			}
+		}
+		if !reachable {
+			from := j * len(current) / len(next)
+			current[from].Next = append(current[from].Next, j)
+		}
+	}
+}
+
+func (m *Map) Show(currentFloor, currentRoom int) {
+	fmt.Println("\n=== 🗺 КАРТА ПОДЗЕМЕЛЬЯ ===")
+	for floor := len(m.Floors) - 1; floor >= 0; floor-- {
+		cells := make([]string, 0, len(m.Floors[floor]))
+		for i, room := range m.Floors[floor] {
+			cell := room.Type.icon()
+			switch {
+			case floor == currentFloor && i == currentRoom:
+				cell = "[" + cell + "]"
+			case room.Visited:
+				cell = "(" + cell + ")"
+			default:
+				cell = " " + cell + " "
+			}
+			cells = append(cells, cell)
+		}
+		fmt.Printf("%2d: %s\n", floor+1, strings.Join(cells, "  "))
+	}
+	fmt.Println("[ ] - вы здесь, ( ) - пройдено")
+}
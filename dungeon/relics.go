@@ -0,0 +1,68 @@
+package dungeon
+
+import (
+	"game/fight"
+	"math/rand"
+)
+
+// Relic - реликвия забега: действует только до конца текущего спуска
+type Relic struct {
+	Name        string
+	Description string
+	Mods        fight.Modifiers
+}
+
+var allRelics = []Relic{
+	{
+		Name:        "🗝 Ключ от чужого сна",
+		Description: "+20% к урону",
+		Mods:        fight.Modifiers{DamagePercent: 20},
+	},
+	{
+		Name:        "🩸 Клык ночного мотылька",
+		Description: "15% нанесённого урона возвращается здоровьем",
+		Mods:        fight.Modifiers{Lifesteal: 15},
+	},
+	{
+		Name:        "🧸 Заштопанный мишка",
+		Description: "Удачный блок снижает урон ещё на 20%",
+		Mods:        fight.Modifiers{BlockBonus: 20},
+	},
+	{
+		Name:        "🎲 Кость судьбы",
+		Description: "15% шанс нанести двойной урон",
+		Mods:        fight.Modifiers{CritChance: 15},
+	},
+	{
+		Name:        "🌵 Колючий плед",
+		Description: "Каждый удар по вам ранит врага на 8",
+		Mods:        fight.Modifiers{Thorns: 8},
+	},
+	{
+		Name:        "🪶 Перо сказочника",
+		Description: "+10% к урону и 10% шанс крита",
+		Mods:        fight.Modifiers{DamagePercent: 10, CritChance: 10},
+	},
+}
+
+// randomRelic - реликвия, которой у игрока ещё нет (nil, если собраны все)
+func randomRelic(rng *rand.Rand, owned []Relic) *Relic {
+	candidates := make([]Relic, 0, len(allRelics))
+	for _, relic := range allRelics {
+		has := false
+		for _, o := range owned {
+			if o.Name == relic.Name {
+				has = true
+				break
+			}
+		}
+		if !has {
+			candidates = append(candidates, relic)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	relic := candidates[rng.Intn(len(candidates))]
+	return &relic
+}
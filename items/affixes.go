@@ -0,0 +1,165 @@
+package items
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Affix - случайное свойство предмета. Префиксы и суффиксы не повторяются на одном предмете
+type Affix struct {
+	Name     string // Как свойство звучит в названии
+	Min, Max int
+	apply    func(e *ItemEffect, value int)
+}
+
+var prefixes = []Affix{
+	{Name: "остроты", Min: 5, Max: 12, apply: func(e *ItemEffect, v int) { e.DamagePercent += v }},
+	{Name: "меткости", Min: 4, Max: 10, apply: func(e *ItemEffect, v int) { e.CritChance += v }},
+	{Name: "стойкости", Min: 6, Max: 15, apply: func(e *ItemEffect, v int) { e.BlockBonus += v }},
+}
+
+var suffixes = []Affix{
+	{Name: "жажды", Min: 3, Max: 8, apply: func(e *ItemEffect, v int) { e.Lifesteal += v }},
+	{Name: "шипов", Min: 3, Max: 8, apply: func(e *ItemEffect, v int) { e.Thorns += v }},
+	{Name: "удачи", Min: 3, Max: 7, apply: func(e *ItemEffect, v int) { e.CritChance += v }},
+}
+
+// UniqueEffect - именной эффект легендарного предмета, одинаковый у всех его экземпляров
+type UniqueEffect struct {
+	Name   string
+	Effect ItemEffect
+}
+
+// Ключ - название легендарного предмета
+var uniqueEffects = map[string]UniqueEffect{
+	"🔥 Сердце дракончика":         {Name: "Драконье пламя", Effect: ItemEffect{DamagePercent: 10, Thorns: 6}},
+	"Каменное сердце великана":    {Name: "Несокрушимость", Effect: ItemEffect{BlockBonus: 20}},
+	"🌌 Клинок бесконечного цикла": {Name: "Эхо побед", Effect: ItemEffect{CritChance: 15}},
+	"💫 Мантия перерождения":       {Name: "Второе дыхание", Effect: ItemEffect{Lifesteal: 10}},
+}
+
+// Roll - экземпляр предмета со случайными свойствами. Расходники не меняются.
+// Обычные получают свойство с шансом, редкие - одно или два, легендарные - два и именной эффект
+func Roll(base *Item, rng *rand.Rand) *Item {
+	item := *base
+	if item.IsConsumable() {
+		return &item
+	}
+	item.EnsureDurability()
+
+	var wantPrefix, wantSuffix bool
+	switch item.Rarity {
+	case Common:
+		if rng.Intn(100) < 40 {
+			wantPrefix = rng.Intn(2) == 0
+			wantSuffix = !wantPrefix
+		}
+	case Rare:
+		wantPrefix = rng.Intn(2) == 0
+		wantSuffix = !wantPrefix || rng.Intn(100) < 40
+	case Legendary:
+		wantPrefix, wantSuffix = true, true
+	}
+
+	if wantPrefix {
+		affix := prefixes[rng.Intn(len(prefixes))]
+		affix.apply(&item.Effect, affix.Min+rng.Intn(affix.Max-affix.Min+1))
+		item.Prefix = affix.Name
+	}
+	if wantSuffix {
+		affix := suffixes[rng.Intn(len(suffixes))]
+		affix.apply(&item.Effect, affix.Min+rng.Intn(affix.Max-affix.Min+1))
+		item.Suffix = affix.Name
+	}
+	if unique, ok := uniqueEffects[item.Name]; ok && item.Rarity == Legendary {
+		item.Effect.Add(unique.Effect)
+		item.Unique = unique.Name
+	}
+	return &item
+}
+
+// RollRandom - Roll с общим генератором math/rand
+func RollRandom(base *Item) *Item {
+	return Roll(base, rand.New(rand.NewSource(rand.Int63())))
+}
+
+// Add - складывает боевые бонусы
+func (e *ItemEffect) Add(other ItemEffect) {
+	e.CritChance += other.CritChance
+	e.Lifesteal += other.Lifesteal
+	e.BlockBonus += other.BlockBonus
+	e.DamagePercent += other.DamagePercent
+	e.Thorns += other.Thorns
+}
+
+// Slot - к какому типу снаряжения относится предмет: оружие или защита
+func (i *Item) Slot() string {
+	switch {
+	case i.IsConsumable():
+		return ""
+	case i.Effect.Strength > 0:
+		return "weapon"
+	case i.Effect.MaxHP > 0:
+		return "armor"
+	default:
+		return ""
+	}
+}
+
+type statLine struct {
+	Label string
+	Value func(e ItemEffect) int
+	Unit  string
+}
+
+// Первые две строки - основные характеристики, остальные - боевые бонусы
+var statLines = []statLine{
+	{"силы", func(e ItemEffect) int { return e.Strength }, ""},
+	{"макс. HP", func(e ItemEffect) int { return e.MaxHP }, ""},
+	{"урона", func(e ItemEffect) int { return e.DamagePercent }, "%"},
+	{"крит", func(e ItemEffect) int { return e.CritChance }, "%"},
+	{"вампиризм", func(e ItemEffect) int { return e.Lifesteal }, "%"},
+	{"блок", func(e ItemEffect) int { return e.BlockBonus }, "%"},
+	{"шипы", func(e ItemEffect) int { return e.Thorns }, ""},
+}
+
+// BonusDescription - случайные свойства и именной эффект строкой (пусто, если их нет)
+func (i *Item) BonusDescription() string {
+	parts := make([]string, 0)
+	for _, line := range statLines[2:] {
+		if v := line.Value(i.Effect); v != 0 {
+			parts = append(parts, fmt.Sprintf("+%d%s %s", v, line.Unit, line.Label))
+		}
+	}
+	result := strings.Join(parts, ", ")
+	if i.Unique != "" {
+		result = fmt.Sprintf("🌟 %s; %s", i.Unique, result)
+	}
+	return result
+}
+
+// CompareStats - разница характеристик candidate относительно current (current может быть nil)
+func CompareStats(candidate, current *Item) string {
+	var base ItemEffect
+	if current != nil {
+		base = current.Effect
+	}
+
+	parts := make([]string, 0)
+	for _, line := range statLines {
+		delta := line.Value(candidate.Effect) - line.Value(base)
+		if delta == 0 {
+			continue
+		}
+		color := "\033[32m"
+		if delta < 0 {
+			color = "\033[31m"
+		}
+		parts = append(parts, fmt.Sprintf("%s%+d%s %s\033[0m", color, delta, line.Unit, line.Label))
+	}
+	if len(parts) == 0 {
+		return "без изменений"
+	}
+	return strings.Join(parts, ", ")
+}
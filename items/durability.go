@@ -0,0 +1,83 @@
+package items
+
+import "fmt"
+
+// При прочности не выше этого процента предмет работает вполсилы
+const worndownPercent = 25
+
+var rarityDurability = map[Rarity]int{
+	Common:    20,
+	Rare:      30,
+	Legendary: 40,
+}
+
+// EnsureDurability - выдаёт прочность снаряжению, у которого её ещё нет (старые сохранения, крафт)
+func (i *Item) EnsureDurability() {
+	if i.IsConsumable() || i.MaxDurability > 0 {
+		return
+	}
+	i.MaxDurability = rarityDurability[i.Rarity]
+	if i.MaxDurability == 0 {
+		i.MaxDurability = rarityDurability[Common]
+	}
+	i.Durability = i.MaxDurability
+}
+
+func (i *Item) IsBroken() bool {
+	return i.MaxDurability > 0 && i.Durability <= 0
+}
+
+// IsWorn - прочность на исходе, эффект ослаблен
+func (i *Item) IsWorn() bool {
+	return i.MaxDurability > 0 && i.Durability*100 <= i.MaxDurability*worndownPercent
+}
+
+// EffectiveEffect - эффект с учётом износа: сломанный предмет не даёт ничего, изношенный - половину
+func (i *Item) EffectiveEffect() ItemEffect {
+	switch {
+	case i.IsBroken():
+		return ItemEffect{}
+	case i.IsWorn():
+		e := i.Effect
+		e.Strength /= 2
+		e.MaxHP /= 2
+		e.CritChance /= 2
+		e.Lifesteal /= 2
+		e.BlockBonus /= 2
+		e.DamagePercent /= 2
+		e.Thorns /= 2
+		return e
+	default:
+		return i.Effect
+	}
+}
+
+// Wear - износ после боя. Сообщает, когда предмет изнашивается и когда ломается
+func (i *Item) Wear(amount int) {
+	if i.MaxDurability == 0 || i.IsBroken() {
+		return
+	}
+
+	wasWorn := i.IsWorn()
+	i.Durability -= amount
+	if i.Durability < 0 {
+		i.Durability = 0
+	}
+
+	switch {
+	case i.IsBroken():
+		fmt.Printf("💥 %s: предмет сломан! Почините его в лавке\n", i.DisplayName())
+	case i.IsWorn() && !wasWorn:
+		fmt.Printf("⚠️ %s: предмет изношен и работает вполсилы (%s)\n", i.DisplayName(), i.DurabilityLabel())
+	}
+}
+
+func (i *Item) DurabilityLabel() string {
+	if i.MaxDurability == 0 {
+		return ""
+	}
+	if i.IsBroken() {
+		return "💔 сломан"
+	}
+	return fmt.Sprintf("🔧 %d/%d", i.Durability, i.MaxDurability)
+}
@@ -1,112 +1,196 @@
-package items
-
-type Rarity string
-
-const (
-	Common    Rarity = "common"
-	Rare      Rarity = "rare"
-	Legendary Rarity = "legendary"
-)
-
-type ItemEffect struct {
-	Heal          int
-	Strength      int
-	MaxHP         int
-	Imagination   int
-	StunRounds    int
-	SpecialEffect string
-}
-
-type Item struct {
-	Name        string
-	Description string
-	Rarity      Rarity
-	Effect      ItemEffect
-	Price       int
-}
-
-func GetAllItems() []*Item {
-	return []*Item{
-		// Лечение
-		{
-			Name:        "🍵 Чай лунного сада",
-			Description: "Серебристый настой. Лечит 40 HP",
-			Rarity:      Common,
-			Effect:      ItemEffect{Heal: 40},
-			Price:       50,
-		},
-		{
-			Name:        "🍯 Банка меда светлячков",
-			Description: "Сладкий, слегка светится. Лечит 60 HP",
-			Rarity:      Rare,
-			Effect:      ItemEffect{Heal: 60},
-			Price:       100,
-		},
-		{
-			Name:        "🧃 Эликсир второго дыхания",
-			Description: "Возвращает силы в самый критичный момент. Лечит 80 HP",
-			Rarity:      Rare,
-			Effect:      ItemEffect{Heal: 80},
-			Price:       150,
-		},
-
-		// Усиление силы
-		{
-			Name:        "🗡 Деревянный меч фантазера",
-			Description: "Легкий, но наполненный верой. +15 к силе",
-			Rarity:      Common,
-			Effect:      ItemEffect{Strength: 15},
-			Price:       100,
-		},
-		{
-			Name:        "🧤 Перчатки храбрости",
-			Description: "Руки сами наносят удар увереннее. +25 к силе",
-			Rarity:      Rare,
-			Effect:      ItemEffect{Strength: 25},
-			Price:       200,
-		},
-		{
-			Name:        "🔥 Сердце дракончика",
-			Description: "Горит внутри владельца. +40 к силе",
-			Rarity:      Legendary,
-			Effect:      ItemEffect{Strength: 40},
-			Price:       250,
-		},
-
-		// Увеличение макс. HP
-		{
-			Name:        "🧥 Пальто из облаков",
-			Description: "Легкое, но оберегает душу. +30 к макс. HP",
-			Rarity:      Rare,
-			Effect:      ItemEffect{MaxHP: 30},
-			Price:       100,
-		},
-		{
-			Name:        "🛡 Щит сказочного стража",
-			Description: "Укрепляет тело и дух. +40 к макс. HP",
-			Rarity:      Rare,
-			Effect:      ItemEffect{MaxHP: 40},
-			Price:       200,
-		},
-		{
-			Name:        "Каменное сердце великана",
-			Description: "Делает владельца почти несокрушимым. +70 к макс. HP",
-			Rarity:      Legendary,
-			Effect:      ItemEffect{MaxHP: 70},
-			Price:       250,
-		},
-	}
-}
-
-func (i *Item) GetRarityColor() string {
-	switch i.Rarity {
-	case Common:
-		return "\033[37m" 
-	case Rare:
-		return "\033[36m" 
-	case Legendary:
-		return "\033[33m" 
-	default:
-		return "\033[0m"
-	}
+package items
+
+import "fmt"
+
+type Rarity string
+
+const (
+	Common    Rarity = "common"
+	Rare      Rarity = "rare"
+	Legendary Rarity = "legendary"
+)
+
+type ItemEffect struct {
+	Heal          int
+	Strength      int
+	MaxHP         int
+	Imagination   int
+	StunRounds    int
+	SpecialEffect string
+
+	// Боевые бонусы надетых предметов (проценты), складываются с fight.Modifiers
+	CritChance    int `json:",omitempty"`
+	Lifesteal     int `json:",omitempty"`
+	BlockBonus    int `json:",omitempty"`
+	DamagePercent int `json:",omitempty"`
+	Thorns        int `json:",omitempty"`
+}
+
+type Item struct {
+	Name        string
+	Description string
+	Rarity      Rarity
+	Effect      ItemEffect
+	Price       int
+
+	// Уровень улучшения (+1..+5) и эффект до улучшений
+	Level      int         `json:",omitempty"`
+	BaseEffect *ItemEffect `json:",omitempty"`
+
+	// Случайные свойства конкретного экземпляра (см. Roll)
+	Prefix string `json:",omitempty"`
+	Suffix string `json:",omitempty"`
+	Unique string `json:",omitempty"`
+
+	// Прочность снаряжения; 0 в MaxDurability - предмет не изнашивается
+	Durability    int `json:",omitempty"`
+	MaxDurability int `json:",omitempty"`
+
+	// Размер стопки одинаковых расходников; 0 - одиночный предмет
+	Count int `json:",omitempty"`
+
+	// Номер экземпляра снаряжения у сервера (серверная лавка, рынок); пусто - сервер о нём не знает
+	ServerID string `json:",omitempty"`
+}
+
+func GetAllItems() []*Item {
+	return []*Item{
+		// Лечение
+		{
+			Name:        "🍵 Чай лунного сада",
+			Description: "Серебристый настой. Лечит 40 HP",
+			Rarity:      Common,
+			Effect:      ItemEffect{Heal: 40},
+			Price:       50,
+		},
+		{
+			Name:        "🍯 Банка меда светлячков",
+			Description: "Сладкий, слегка светится. Лечит 60 HP",
+			Rarity:      Rare,
+			Effect:      ItemEffect{Heal: 60},
+			Price:       100,
+		},
+		{
+			Name:        "🧃 Эликсир второго дыхания",
+			Description: "Возвращает силы в самый критичный момент. Лечит 80 HP",
+			Rarity:      Rare,
+			Effect:      ItemEffect{Heal: 80},
+			Price:       150,
+		},
+
+		// Усиление силы
+		{
+			Name:        "🗡 Деревянный меч фантазера",
+			Description: "Легкий, но наполненный верой. +15 к силе",
+			Rarity:      Common,
+			Effect:      ItemEffect{Strength: 15},
+			Price:       100,
+		},
+		{
+			Name:        "🧤 Перчатки храбрости",
+			Description: "Руки сами наносят удар увереннее. +25 к силе",
+			Rarity:      Rare,
+			Effect:      ItemEffect{Strength: 25},
+			Price:       200,
+		},
+		{
+			Name:        "🔥 Сердце дракончика",
+			Description: "Горит внутри владельца. +40 к силе",
+			Rarity:      Legendary,
+			Effect:      ItemEffect{Strength: 40},
+			Price:       250,
+		},
+
+		// Увеличение макс. HP
+		{
+			Name:        "🧥 Пальто из облаков",
+			Description: "Легкое, но оберегает душу. +30 к макс. HP",
+			Rarity:      Rare,
+			Effect:      ItemEffect{MaxHP: 30},
+			Price:       100,
+		},
+		{
+			Name:        "🛡 Щит сказочного стража",
+			Description: "Укрепляет тело и дух. +40 к макс. HP",
+			Rarity:      Rare,
+			Effect:      ItemEffect{MaxHP: 40},
+			Price:       200,
+		},
+		{
+			Name:        "Каменное сердце великана",
+			Description: "Делает владельца почти несокрушимым. +70 к макс. HP",
+			Rarity:      Legendary,
+			Effect:      ItemEffect{MaxHP: 70},
+			Price:       250,
+		},
+	}
+}
+
+// GetNewGamePlusItems - легендарные предметы, доступные только в циклах Новой игры+
+func GetNewGamePlusItems() []*Item {
+	return []*Item{
+		{
+			Name:        "🌌 Клинок бесконечного цикла",
+			Description: "Помнит каждую вашу победу. +60 к силе",
+			Rarity:      Legendary,
+			Effect:      ItemEffect{Strength: 60},
+			Price:       450,
+		},
+		{
+			Name:        "💫 Мантия перерождения",
+			Description: "Соткана из прошлых жизней Хранителя. +100 к макс. HP",
+			Rarity:      Legendary,
+			Effect:      ItemEffect{MaxHP: 100},
+			Price:       450,
+		},
+		{
+			Name:        "🕯 Свеча вечного сна",
+			Description: "Её свет возвращает из-за грани. Лечит 150 HP",
+			Rarity:      Legendary,
+			Effect:      ItemEffect{Heal: 150},
+			Price:       300,
+		},
+	}
+}
+
+// FindByName - предмет каталога (включая предметы Новой игры+) по названию
+func FindByName(name string) *Item {
+	for _, item := range append(GetAllItems(), GetNewGamePlusItems()...) {
+		if item.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+// IsConsumable - расходный предмет (лечение, оглушение, особый эффект)
+func (i *Item) IsConsumable() bool {
+	return i.Effect.Heal > 0 || i.Effect.StunRounds > 0 || i.Effect.SpecialEffect != ""
+}
+
+// DisplayName - название с уровнем улучшения
+func (i *Item) DisplayName() string {
+	name := i.Name
+	if i.Prefix != "" && i.Suffix != "" {
+		name = fmt.Sprintf("%s «%s и %s»", name, i.Prefix, i.Suffix)
+	} else if i.Prefix != "" || i.Suffix != "" {
+		name = fmt.Sprintf("%s «%s%s»", name, i.Prefix, i.Suffix)
+	}
+	if i.Level > 0 {
+		name = fmt.Sprintf("%s +%d", name, i.Level)
+	}
+	return name
+}
+
+func (i *Item) GetRarityColor() string {
+	switch i.Rarity {
+	case Common:
+		return "\033[37m" 
+	case Rare:
+		return "\033[36m" 
+	case Legendary:
+		return "\033[33m" 
+	default:
+		return "\033[0m"
+	}
 }
\ No newline at end of file
@@ -0,0 +1,133 @@
+package items
+
+import "fmt"
+
+// SetBonus - бонус, который включается при Pieces надетых частях комплекта
+type SetBonus struct {
+	Pieces      int
+	Description string
+	Effect      ItemEffect
+}
+
+// ItemSet - именной комплект: чем больше разных частей надето, тем больше бонусов
+type ItemSet struct {
+	Name    string
+	Pieces  []string
+	Bonuses []SetBonus
+}
+
+var itemSets = []ItemSet{
+	{
+		Name: "Наряд сказочного стража",
+		Pieces: []string{
+			"🛡 Щит сказочного стража",
+			"🧥 Пальто из облаков",
+			"🗡 Деревянный меч фантазера",
+			"🧤 Перчатки храбрости",
+		},
+		Bonuses: []SetBonus{
+			{Pieces: 2, Description: "+20 к макс. HP", Effect: ItemEffect{MaxHP: 20}},
+			{Pieces: 3, Description: "+10 к силе, +10% к блоку", Effect: ItemEffect{Strength: 10, BlockBonus: 10}},
+			{Pieces: 4, Description: "+10% вампиризма, +5 шипов", Effect: ItemEffect{Lifesteal: 10, Thorns: 5}},
+		},
+	},
+	{
+		Name: "Драконья кровь",
+		Pieces: []string{
+			"🔥 Сердце дракончика",
+			"Каменное сердце великана",
+			"🐉 Драконья хватка",
+		},
+		Bonuses: []SetBonus{
+			{Pieces: 2, Description: "+10% шанса крита", Effect: ItemEffect{CritChance: 10}},
+			{Pieces: 3, Description: "+20% к урону, +8 шипов", Effect: ItemEffect{DamagePercent: 20, Thorns: 8}},
+		},
+	},
+	{
+		Name: "Наследие цикла",
+		Pieces: []string{
+			"🌌 Клинок бесконечного цикла",
+			"💫 Мантия перерождения",
+			"🌥 Облачный доспех",
+		},
+		Bonuses: []SetBonus{
+			{Pieces: 2, Description: "+30 к макс. HP", Effect: ItemEffect{MaxHP: 30}},
+			{Pieces: 3, Description: "+10% вампиризма, +10% шанса крита", Effect: ItemEffect{Lifesteal: 10, CritChance: 10}},
+		},
+	},
+}
+
+// ActiveSet - сколько частей комплекта надето
+type ActiveSet struct {
+	Set    ItemSet
+	Pieces int
+}
+
+// ActiveSets - комплекты, из которых надета хотя бы одна часть. Повторы одной части не считаются
+func ActiveSets(equippedNames []string) []ActiveSet {
+	worn := make(map[string]bool)
+	for _, name := range equippedNames {
+		worn[name] = true
+	}
+
+	result := make([]ActiveSet, 0)
+	for _, set := range itemSets {
+		count := 0
+		for _, piece := range set.Pieces {
+			if worn[piece] {
+				count++
+			}
+		}
+		if count > 0 {
+			result = append(result, ActiveSet{Set: set, Pieces: count})
+		}
+	}
+	return result
+}
+
+// SetEffect - суммарный эффект всех включённых бонусов комплектов
+func SetEffect(equippedNames []string) ItemEffect {
+	var total ItemEffect
+	for _, active := range ActiveSets(equippedNames) {
+		for _, bonus := range active.Set.Bonuses {
+			if active.Pieces >= bonus.Pieces {
+				total.Strength += bonus.Effect.Strength
+				total.MaxHP += bonus.Effect.MaxHP
+				total.Add(bonus.Effect)
+			}
+		}
+	}
+	return total
+}
+
+// SetOf - название комплекта, к которому относится предмет (пусто, если ни к какому)
+func SetOf(name string) string {
+	for _, set := range itemSets {
+		for _, piece := range set.Pieces {
+			if piece == name {
+				return set.Name
+			}
+		}
+	}
+	return ""
+}
+
+// ShowActiveSets - комплекты и их бонусы для экрана инвентаря
+func ShowActiveSets(equippedNames []string) {
+	active := ActiveSets(equippedNames)
+	if len(active) == 0 {
+		return
+	}
+
+	fmt.Println("\n=== 🧩 КОМПЛЕКТЫ ===")
+	for _, a := range active {
+		fmt.Printf("%s (%d/%d)\n", a.Set.Name, a.Pieces, len(a.Set.Pieces))
+		for _, bonus := range a.Set.Bonuses {
+			mark := "▫️"
+			if a.Pieces >= bonus.Pieces {
+				mark = "✅"
+			}
+			fmt.Printf("   %s %d части: %s\n", mark, bonus.Pieces, bonus.Description)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package items
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Quantity - сколько штук в ячейке
+func (i *Item) Quantity() int {
+	if i.Count < 1 {
+		return 1
+	}
+	return i.Count
+}
+
+// QuantityLabel - приписка к названию стопки: " ×3"
+func (i *Item) QuantityLabel() string {
+	if i.Quantity() > 1 {
+		return fmt.Sprintf(" ×%d", i.Quantity())
+	}
+	return ""
+}
+
+// Stackable - складываются только неулучшенные расходники: у них нет своих свойств
+func (i *Item) Stackable() bool {
+	return i.IsConsumable() && i.Level == 0
+}
+
+// StacksWith - можно ли положить предмет в эту стопку
+func (i *Item) StacksWith(other *Item) bool {
+	return i.Stackable() && other.Stackable() && i.Name == other.Name
+}
+
+// Kind - тип предмета для сортировки и фильтров
+func (i *Item) Kind() string {
+	if i.IsConsumable() {
+		return "consumable"
+	}
+	if slot := i.Slot(); slot != "" {
+		return slot
+	}
+	return "other"
+}
+
+// KindName - тип предмета по-русски
+func KindName(kind string) string {
+	switch kind {
+	case "consumable":
+		return "расходники"
+	case "weapon":
+		return "оружие"
+	case "armor":
+		return "броня"
+	default:
+		return "прочее"
+	}
+}
+
+var rarityOrder = map[Rarity]int{Legendary: 0, Rare: 1, Common: 2}
+
+var kindOrder = map[string]int{"consumable": 0, "weapon": 1, "armor": 2, "other": 3}
+
+// Порядок сортировки инвентаря
+const (
+	SortByRarity = "rarity"
+	SortByKind   = "kind"
+	SortByName   = "name"
+)
+
+// Sort - упорядочивает предметы на месте; при равенстве - по названию
+func Sort(list []*Item, by string) {
+	sort.SliceStable(list, func(a, b int) bool {
+		x, y := list[a], list[b]
+		switch by {
+		case SortByRarity:
+			if rarityOrder[x.Rarity] != rarityOrder[y.Rarity] {
+				return rarityOrder[x.Rarity] < rarityOrder[y.Rarity]
+			}
+		case SortByKind:
+			if kindOrder[x.Kind()] != kindOrder[y.Kind()] {
+				return kindOrder[x.Kind()] < kindOrder[y.Kind()]
+			}
+		}
+		return x.Name < y.Name
+	})
+}
@@ -0,0 +1,190 @@
+package leaderboard
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"game/player"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Board - рейтинговый сезон, лиги и таблицы лидеров с сервера
+type Board struct {
+	serverURL  string
+	httpClient *http.Client
+}
+
+func NewBoard(serverURL string) *Board {
+	return &Board{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+func (b *Board) Open(p *player.Player) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println("\n=== 🏅 РЕЙТИНГ PvP ===")
+		fmt.Println("1. Таблица сезона")
+		fmt.Println("2. Лучшие за неделю")
+		fmt.Println("3. Среди друзей")
+		fmt.Println("4. Добавить друга")
+		fmt.Println("5. Удалить друга")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			b.show(p, "/leaderboard", "🏆 ТАБЛИЦА СЕЗОНА")
+		case "2":
+			b.show(p, "/leaderboard/weekly", "📅 ЛУЧШИЕ ЗА НЕДЕЛЮ")
+		case "3":
+			b.show(p, "/leaderboard/friends", "🤝 СРЕДИ ДРУЗЕЙ")
+		case "4":
+			b.editFriends(p, reader, "/friends/add")
+		case "5":
+			b.editFriends(p, reader, "/friends/remove")
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+// show - печатает таблицу: первая строка - сезон и место игрока, дальше строки таблицы
+func (b *Board) show(p *player.Player, path, title string) {
+	response, err := b.get(path + "?player=" + url.QueryEscape(p.Name))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	weekly := strings.HasSuffix(path, "/weekly")
+	fmt.Printf("\n=== %s ===\n", title)
+	rows := 0
+	for _, line := range strings.Split(strings.TrimSpace(response), "\n") {
+		switch {
+		case strings.HasPrefix(line, "season:"):
+			showSeason(strings.Split(strings.TrimPrefix(line, "season:"), "|"))
+		case strings.HasPrefix(line, "week:"):
+			fmt.Printf("Неделя %s\n", strings.TrimPrefix(line, "week:"))
+		default:
+			fields := strings.Split(line, "|")
+			if len(fields) < 7 {
+				continue
+			}
+			rows++
+			mark := "  "
+			if fields[1] == p.Name {
+				mark = "👉"
+			}
+			name := fields[1]
+			if len(fields) >= 8 && fields[7] != "0" {
+				name += " [НИ+" + fields[7] + "]"
+			}
+			if weekly {
+				fmt.Printf("%s%s. %s - %s очков за неделю (%s, %s)\n", mark, fields[0], name, signed(fields[6]), fields[2], fields[3])
+			} else {
+				fmt.Printf("%s%s. %s - %s %s, побед %s из %s\n", mark, fields[0], name, fields[2], fields[3], fields[5], fields[4])
+			}
+		}
+	}
+	if rows == 0 {
+		fmt.Println("Пока пусто - сыграйте рейтинговый бой!")
+	}
+}
+
+// showSeason - номер|конец (unix)|рейтинг|лига|место
+func showSeason(fields []string) {
+	if len(fields) < 5 {
+		return
+	}
+	endsUnix, _ := strconv.ParseInt(fields[1], 10, 64)
+	left := time.Until(time.Unix(endsUnix, 0))
+	fmt.Printf("📆 Сезон %s, до конца %d дн. %d ч.\n", fields[0], int(left.Hours())/24, int(left.Hours())%24)
+	place := "ещё не играли в этом сезоне"
+	if fields[4] != "0" {
+		place = fields[4] + " место"
+	}
+	fmt.Printf("🏅 Ваш рейтинг: %s, лига %s, %s\n", fields[2], fields[3], place)
+	fmt.Println("🎁 В конце сезона награда зависит от лиги, рейтинг наполовину возвращается к 1200")
+}
+
+func (b *Board) editFriends(p *player.Player, reader *bufio.Reader, path string) {
+	fmt.Print("Ник друга: ")
+	friend, _ := reader.ReadString('\n')
+	friend = strings.TrimSpace(friend)
+	if friend == "" {
+		return
+	}
+
+	response, err := b.post(path, p.Name+"|"+friend)
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch strings.TrimSpace(response) {
+	case "ok":
+		fmt.Println("✅ Готово")
+	case "error:self":
+		fmt.Println("❌ Себя добавить нельзя")
+	case "error:unknown":
+		fmt.Println("❌ Такой игрок ещё не участвовал в PvP")
+	case "error:exists":
+		fmt.Println("❌ Он уже в списке друзей")
+	case "error:limit":
+		fmt.Println("❌ Список друзей переполнен")
+	case "error:not_friend":
+		fmt.Println("❌ Такого игрока нет в списке друзей")
+	default:
+		fmt.Println("⚠️", response)
+	}
+}
+
+func signed(n string) string {
+	if strings.HasPrefix(n, "-") || n == "0" {
+		return n
+	}
+	return "+" + n
+}
+
+func (b *Board) get(path string) (string, error) {
+	resp, err := b.httpClient.Get(b.serverURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(raw)))
+	}
+	return string(raw), nil
+}
+
+func (b *Board) post(path, body string) (string, error) {
+	resp, err := b.httpClient.Post(b.serverURL+path, "text/plain", strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(raw)))
+	}
+	return string(raw), nil
+}
@@ -1,272 +1,555 @@
-package main
-
-import (
-	"bufio"
-	"fmt"
-	"game/client"
-	"game/player"
-	"game/pvp"
-	"game/shop"
-	"game/tournament"
-	"io"
-	"net/http"
-	"os"
-	"regexp"
-	"strconv"
-	"strings"
-	"time"
-)
-
-func isValidNickname(name string) bool {
-	re := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
-	return re.MatchString(name)
-}
-
-func main() {
-	fmt.Println("=== ДОБРО ПОЖАЛОВАТЬ В ВООБРАЖАРИУМ ===")
-	fmt.Print("Введите имя вашего Хранителя: ")
-
-	reader := bufio.NewReader(os.Stdin)
-	var name string
-
-	for {
-		fmt.Print("Введите ваш ник (латиница, цифры, _): ")
-		input, _ := reader.ReadString('\n')
-		name = strings.TrimSpace(input)
-
-		if name == "" {
-			fmt.Println("❌ Имя не может быть пустым!")
-			continue
-		}
-
-		if !isValidNickname(name) {
-			fmt.Println("❌ Разрешены только латиница, цифры и '_'")
-			continue
-		}
-
-		if checkNicknameExistsOnServer(name) {
-			fmt.Println("❌ Этот ник уже занят!")
-			continue
-		}
-
-		break
-	}
-
-	// tournament.IntroPlay()
-	registerNicknameOnServer(name)
-	fmt.Printf("\nПриветствую, %s!\n", name)
-
-	p := player.NewPlayer(name)
-	shopInstance := shop.NewShop()
-	tournamentInstance := tournament.NewTournament(p)
-
-	// Добавляем стартовые предметы (убираем вызов items.GetAllItems)
-	// Вместо этого добавим базовые предметы через магазин позже
-	fmt.Println("💰 Вам выдано 150 воображения для стартовых покупок!")
-
-	for {
-		showMainMenu(p, tournamentInstance)
-
-		fmt.Print("Выберите действие: ")
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
-
-		choice, err := strconv.Atoi(input)
-		if err != nil {
-			fmt.Println("Неверный ввод!")
-			continue
-		}
-
-		switch choice {
-		case 1:
-			// Бой с гильдией
-			if tournamentInstance.IsComplete() && !tournamentInstance.IsFinalDefeated() {
-				fmt.Println("\n👾 Вы победили все гильдии! Пора бросить вызов Древнему Хаосу!")
-				fmt.Print("Начать финальный бой? (да/нет): ")
-				confirm, _ := reader.ReadString('\n')
-				confirm = strings.TrimSpace(strings.ToLower(confirm))
-				if confirm == "да" || confirm == "д" || confirm == "yes" {
-					p.ResetForBattle()
-					tournamentInstance.StartFinalBoss()
-				}
-			} else if tournamentInstance.CurrentGuild < len(tournamentInstance.Guilds) {
-				p.ResetForBattle()
-				tournamentInstance.StartNextGuild()
-			} else if tournamentInstance.IsFinalDefeated() {
-				fmt.Println("\n🏆 Вы уже победили Древнего Хаоса! Игра пройдена! 🏆")
-			} else {
-				fmt.Println("\n❌ Нет доступных гильдий для битвы")
-			}
-
-		case 2:
-			// PvP
-			fmt.Println("\n=== PvP РЕЖИМ ===")
-			fmt.Println("Подключение к серверу localhost:8080...")
-
-			pvpClient := pvp.NewPvPClient("https://curly-orbit-966q99p46jq29vww-8080.app.github.dev/")
-			result := pvpClient.Play(p)
-
-			if result == "loss" {
-				p.AddImagination(50)
-				fmt.Println("✨ За участие в PvP вы получили 50 воображения!")
-				p.HP = p.GetMaxHP()
-			} else if result == "win" {
-				p.AddImagination(100)
-				p.Wins++
-				fmt.Println("✨ За победу в PvP вы получили 100 воображения!")
-				p.HP = p.GetMaxHP()
-			}
-
-		case 3:
-			// Чат
-			fmt.Println("\n=== ЧАТ ===")
-			fmt.Println("Подключение к чат-серверу localhost:8080...")
-
-			// Создаем клиент
-			chatClient := client.NewChatClient("https://curly-orbit-966q99p46jq29vww-8080.app.github.dev/")
-
-			// Запускаем чат (он БЛОКИРУЕТ выполнение до выхода)
-			chatClient.Start()
-
-			// После выхода из чата показываем меню снова
-			fmt.Println("\n🔙 Возврат в главное меню...")
-			// Небольшая пауза для читаемости
-			time.Sleep(1 * time.Second)
-
-		case 4:
-			// Магазин
-			shopInstance.Visit(p)
-
-		case 5:
-			// Инвентарь / Экипировка
-			manageInventory(p, reader)
-
-		case 6:
-			// Показать прогресс
-			tournamentInstance.ShowProgress()
-
-		case 0:
-			fmt.Println("Выход из игры...")
-			return
-
-		default:
-			fmt.Println("Неверный выбор!")
-		}
-	}
-}
-
-func showMainMenu(p *player.Player, t *tournament.Tournament) {
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("ГЛАВНОЕ МЕНЮ")
-	fmt.Println(strings.Repeat("=", 50))
-
-	p.ShowStats()
-
-	fmt.Println("\nДОСТУПНЫЕ ДЕЙСТВИЯ:")
-	fmt.Println("1. Бой с гильдией")
-	fmt.Println("2. PvP (игрок против игрока)")
-	fmt.Println("3. Чат")
-	fmt.Println("4. Магазин")
-	fmt.Println("5. Инвентарь / Экипировка")
-	fmt.Println("6. Прогресс турнира")
-	fmt.Println("0. Выход")
-}
-
-func manageInventory(p *player.Player, reader *bufio.Reader) {
-	for {
-		fmt.Println("\n" + strings.Repeat("=", 50))
-		fmt.Println("УПРАВЛЕНИЕ ИНВЕНТАРЕМ")
-		fmt.Println(strings.Repeat("=", 50))
-
-		fmt.Println("1. Показать инвентарь")
-		fmt.Println("2. Экипировать предмет")
-		fmt.Println("3. Снять предмет")
-		fmt.Println("4. Использовать предмет (вне боя)")
-		fmt.Println("5. Назад")
-		fmt.Print("Выберите действие: ")
-
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
-
-		choice, err := strconv.Atoi(input)
-		if err != nil {
-			fmt.Println("Неверный ввод!")
-			continue
-		}
-
-		switch choice {
-		case 1:
-			p.ShowInventory()
-
-		case 2:
-			p.ShowInventory()
-			if len(p.Inventory) == 0 {
-				fmt.Println("Нет предметов для экипировки.")
-				continue
-			}
-			fmt.Print("Введите номер предмета для экипировки: ")
-			idxInput, _ := reader.ReadString('\n')
-			idxInput = strings.TrimSpace(idxInput)
-			idx, _ := strconv.Atoi(idxInput)
-			if idx >= 1 && idx <= len(p.Inventory) {
-				p.EquipItem(idx - 1)
-			} else {
-				fmt.Println("Неверный номер!")
-			}
-
-		case 3:
-			if len(p.Equipped) == 0 {
-				fmt.Println("Нет надетых предметов.")
-				continue
-			}
-			fmt.Println("\n=== НАДЕТО ===")
-			for i, item := range p.Equipped {
-				fmt.Printf("%d. %s\n", i+1, item.Name)
-			}
-			fmt.Print("Введите номер предмета для снятия: ")
-			idxInput, _ := reader.ReadString('\n')
-			idxInput = strings.TrimSpace(idxInput)
-			idx, _ := strconv.Atoi(idxInput)
-			if idx >= 1 && idx <= len(p.Equipped) {
-				p.UnequipItem(idx - 1)
-			} else {
-				fmt.Println("Неверный номер!")
-			}
-
-		case 4:
-			p.ShowInventory()
-			if len(p.Inventory) == 0 {
-				fmt.Println("Нет предметов для использования.")
-				continue
-			}
-			fmt.Print("Введите номер предмета для использования: ")
-			idxInput, _ := reader.ReadString('\n')
-			idxInput = strings.TrimSpace(idxInput)
-			idx, _ := strconv.Atoi(idxInput)
-			if idx >= 1 && idx <= len(p.Inventory) {
-				p.UseItem(idx - 1)
-			} else {
-				fmt.Println("Неверный номер!")
-			}
-
-		case 5:
-			return
-		}
-	}
-}
-
-func checkNicknameExistsOnServer(name string) bool {
-	resp, err := http.Get("https://curly-orbit-966q99p46jq29vww-8080.app.github.dev/check-nick?name=" + name)
-	if err != nil {
-		return false
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	return string(body) == "exists"
-}
-
-func registerNicknameOnServer(name string) {
-	http.Post("https://curly-orbit-966q99p46jq29vww-8080.app.github.dev/register-nick", "text/plain", strings.NewReader(name))
-}
-
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"game/arena"
+	"game/client"
+	"game/crafting"
+	"game/dungeon"
+	"game/items"
+	"game/leaderboard"
+	"game/market"
+	"game/player"
+	"game/pvp"
+	"game/save"
+	"game/shop"
+	"game/tournament"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const serverURL = "https://curly-orbit-966q99p46jq29vww-8080.app.github.dev/"
+
+func isValidNickname(name string) bool {
+	re := regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+	return re.MatchString(name)
+}
+
+func main() {
+	fmt.Println("=== ДОБРО ПОЖАЛОВАТЬ В ВООБРАЖАРИУМ ===")
+	fmt.Print("Введите имя вашего Хранителя: ")
+
+	reader := bufio.NewReader(os.Stdin)
+	var name string
+
+	for {
+		fmt.Print("Введите ваш ник (латиница, цифры, _): ")
+		input, _ := reader.ReadString('\n')
+		name = strings.TrimSpace(input)
+
+		if name == "" {
+			fmt.Println("❌ Имя не может быть пустым!")
+			continue
+		}
+
+		if !isValidNickname(name) {
+			fmt.Println("❌ Разрешены только латиница, цифры и '_'")
+			continue
+		}
+
+		if checkNicknameExistsOnServer(name) {
+			fmt.Println("❌ Этот ник уже занят!")
+			continue
+		}
+
+		break
+	}
+
+	// tournament.IntroPlay()
+	registerNicknameOnServer(name)
+	fmt.Printf("\nПриветствую, %s!\n", name)
+
+	p, tournamentInstance, shopInstance := loadOrCreate(name, reader)
+	shopInstance.Remote = shop.NewRemoteShop(serverURL)
+	if tournamentInstance.Cycle > 0 {
+		shopInstance.UnlockNewGamePlusItems()
+	}
+
+	// Прошлый PvP-бой мог прерваться из-за сбоя - предлагаем вернуться
+	if result, rewarded := pvp.NewPvPClient(serverURL).Resume(p); result != "" {
+		if rewarded {
+			applyPvPResult(p, result, shopInstance)
+		}
+		saveGame(p, tournamentInstance, shopInstance)
+	}
+
+	for {
+		showMainMenu(p, tournamentInstance)
+
+		fmt.Print("Выберите действие: ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		choice, err := strconv.Atoi(input)
+		if err != nil {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+
+		switch choice {
+		case 1:
+			// Бой с гильдией
+			if tournamentInstance.IsComplete() && !tournamentInstance.IsFinalDefeated() {
+				fmt.Println("\n👾 Вы победили все гильдии! Пора бросить вызов Древнему Хаосу!")
+				fmt.Print("Начать финальный бой? (да/нет): ")
+				confirm, _ := reader.ReadString('\n')
+				confirm = strings.TrimSpace(strings.ToLower(confirm))
+				if confirm == "да" || confirm == "д" || confirm == "yes" {
+					playTournamentBattle(p, tournamentInstance, reader, true)
+					shopInstance.Restock()
+				}
+			} else if tournamentInstance.CurrentGuild < len(tournamentInstance.Guilds) {
+				playTournamentBattle(p, tournamentInstance, reader, false)
+				shopInstance.Restock()
+			} else if tournamentInstance.IsFinalDefeated() {
+				fmt.Println("\n🏆 Вы уже победили Древнего Хаоса! Игра пройдена! 🏆")
+				fmt.Printf("Начать Новую игру+ (цикл %d)? Снаряжение и воображение сохранятся, боссы станут сильнее (да/нет): ", tournamentInstance.Cycle+1)
+				confirm, _ := reader.ReadString('\n')
+				confirm = strings.TrimSpace(strings.ToLower(confirm))
+				if confirm == "да" || confirm == "д" || confirm == "yes" {
+					tournamentInstance.StartNewGamePlus()
+					shopInstance.UnlockNewGamePlusItems()
+					shopInstance.Rotate()
+				}
+			} else {
+				fmt.Println("\n❌ Нет доступных гильдий для битвы")
+			}
+
+			if tournamentInstance.IsGameOver() {
+				if tournamentInstance.Rules.Hardcore {
+					fmt.Println("\n☠️ Хранитель пал навсегда. Начинается новая история...")
+					save.Delete(name)
+					p = player.NewPlayer(name)
+					shopInstance = shop.NewShop()
+					shopInstance.Remote = shop.NewRemoteShop(serverURL)
+					tournamentInstance = tournament.NewTournament(p, tournamentInstance.Rules)
+				} else {
+					fmt.Println("\n🔄 Жизни исчерпаны. Турнир начинается заново, снаряжение остаётся с вами.")
+					tournamentInstance.Restart()
+				}
+			}
+
+		case 2:
+			// PvP
+			fmt.Println("\n=== PvP РЕЖИМ ===")
+			fmt.Println("Подключение к серверу localhost:8080...")
+
+			pvpClient := pvp.NewPvPClient(serverURL)
+			applyPvPResult(p, pvpClient.Play(p), shopInstance)
+
+		case 3:
+			// Чат
+			fmt.Println("\n=== ЧАТ ===")
+			fmt.Println("Подключение к чат-серверу localhost:8080...")
+
+			// Создаем клиент
+			chatClient := client.NewChatClient(serverURL)
+
+			// Запускаем чат (он БЛОКИРУЕТ выполнение до выхода)
+			chatClient.Start()
+
+			// После выхода из чата показываем меню снова
+			fmt.Println("\n🔙 Возврат в главное меню...")
+			// Небольшая пауза для читаемости
+			time.Sleep(1 * time.Second)
+
+		case 4:
+			// Магазин
+			shopInstance.Visit(p)
+
+		case 5:
+			// Инвентарь / Экипировка
+			manageInventory(p, reader)
+
+		case 6:
+			// Показать прогресс
+			tournamentInstance.ShowProgress()
+
+		case 7:
+			// Арена выживания
+			arenaMenu(p, tournamentInstance, reader)
+			shopInstance.Restock()
+
+		case 8:
+			// Подземелье
+			startDungeon(p, tournamentInstance, reader)
+			shopInstance.Restock()
+
+		case 9:
+			// Рынок между игроками
+			market.NewMarket(serverURL).Open(p)
+
+		case 10:
+			// Рейтинговый сезон: награды лиг приходят в серверный кошелёк
+			leaderboard.NewBoard(serverURL).Open(p)
+
+		case 11:
+			// Журналы прошедших боёв хранятся на сервере
+			pvp.NewPvPClient(serverURL).Replays(p)
+
+		case 12:
+			// Трансляции идущих боёв, только для чтения
+			pvp.NewPvPClient(serverURL).Spectate(p)
+
+		case 13:
+			// Турниры проводит сервер, призы приходят в серверный кошелёк
+			pvp.NewPvPClient(serverURL).Cups(p)
+
+		case 14:
+			// Вызовы друзьям и приватные лобби, без рейтинга и наград
+			pvp.NewPvPClient(serverURL).Friendly(p)
+
+		case 0:
+			saveGame(p, tournamentInstance, shopInstance)
+			fmt.Println("Выход из игры...")
+			return
+
+		default:
+			fmt.Println("Неверный выбор!")
+		}
+
+		saveGame(p, tournamentInstance, shopInstance)
+	}
+}
+
+// applyPvPResult - награда и износ после PvP-боя. Уход из боя засчитывается как поражение
+func applyPvPResult(p *player.Player, result string, shopInstance *shop.Shop) {
+	// Сервер сам начисляет награду за PvP в серверный кошелёк, поэтому она уже сверена
+	if result == "loss" || result == "exit" {
+		p.AddImagination(50)
+		p.SyncedImagination += 50
+		fmt.Println("✨ За участие в PvP вы получили 50 воображения!")
+		p.HP = p.GetMaxHP()
+	} else if result == "win" {
+		p.AddImagination(100)
+		p.SyncedImagination += 100
+		p.Wins++
+		fmt.Println("✨ За победу в PvP вы получили 100 воображения!")
+		p.HP = p.GetMaxHP()
+	}
+	if result == "win" || result == "loss" || result == "exit" {
+		p.WearEquipment()
+		shopInstance.Restock()
+	}
+}
+
+// loadOrCreate - продолжает сохранённую кампанию или начинает новую
+func loadOrCreate(name string, reader *bufio.Reader) (*player.Player, *tournament.Tournament, *shop.Shop) {
+	if save.Exists(name) {
+		fmt.Print("💾 Найдено сохранение. Продолжить? (да/нет): ")
+		confirm, _ := reader.ReadString('\n')
+		confirm = strings.TrimSpace(strings.ToLower(confirm))
+		if confirm == "да" || confirm == "д" || confirm == "yes" {
+			p, t, s, err := save.Load(name)
+			if err == nil {
+				fmt.Println("✅ Сохранение загружено!")
+				return p, t, s
+			}
+			fmt.Println("❌ Не удалось загрузить сохранение:", err)
+		}
+	}
+
+	p := player.NewPlayer(name)
+	t := tournament.NewTournament(p, chooseRules(reader))
+
+	// Добавляем стартовые предметы (убираем вызов items.GetAllItems)
+	// Вместо этого добавим базовые предметы через магазин позже
+	fmt.Println("💰 Вам выдано 150 воображения для стартовых покупок!")
+	return p, t, shop.NewShop()
+}
+
+func saveGame(p *player.Player, t *tournament.Tournament, s *shop.Shop) {
+	if err := save.Save(p, t, s); err != nil {
+		fmt.Println("⚠️ Не удалось сохранить игру:", err)
+	}
+}
+
+func showMainMenu(p *player.Player, t *tournament.Tournament) {
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	fmt.Println("ГЛАВНОЕ МЕНЮ")
+	fmt.Println(strings.Repeat("=", 50))
+
+	p.ShowStats()
+
+	fmt.Println("\nДОСТУПНЫЕ ДЕЙСТВИЯ:")
+	fmt.Println("1. Бой с гильдией")
+	fmt.Println("2. PvP (игрок против игрока)")
+	fmt.Println("3. Чат")
+	fmt.Println("4. Магазин")
+	fmt.Println("5. Инвентарь / Экипировка")
+	fmt.Println("6. Прогресс турнира")
+	fmt.Println("7. Арена выживания")
+	fmt.Println("8. Подземелье снов (забег)")
+	fmt.Println("9. Рынок (обмен и аукцион)")
+	fmt.Println("10. Рейтинг PvP и таблица лидеров")
+	fmt.Println("11. Записи PvP боёв")
+	fmt.Println("12. Смотреть PvP бои")
+	fmt.Println("13. PvP-турниры")
+	fmt.Println("14. Товарищеские бои (вызовы и лобби)")
+	fmt.Println("0. Выход")
+}
+
+func arenaMenu(p *player.Player, t *tournament.Tournament, reader *bufio.Reader) {
+	for {
+		fmt.Println("\n=== 🏟 АРЕНА ВЫЖИВАНИЯ ===")
+		fmt.Println("1. Начать забег")
+		fmt.Println("2. Таблица рекордов")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			arena.NewArena(p, t.Cycle).Run()
+		case "2":
+			arena.ShowHighScores()
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный выбор!")
+		}
+	}
+}
+
+func startDungeon(p *player.Player, t *tournament.Tournament, reader *bufio.Reader) {
+	fmt.Print("\nВведите сид забега (Enter - случайный): ")
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+
+	seed := time.Now().UnixNano()
+	if input != "" {
+		parsed, err := strconv.ParseInt(input, 10, 64)
+		if err != nil {
+			fmt.Println("❌ Сид должен быть числом")
+			return
+		}
+		seed = parsed
+	}
+
+	dungeon.NewRun(p, seed, t.Cycle).Start()
+}
+
+func chooseRules(reader *bufio.Reader) tournament.Rules {
+	fmt.Println("\nВЫБЕРИТЕ РЕЖИМ КАМПАНИИ:")
+	fmt.Println("1. Обычный (3 жизни, поражение отнимает воображение и расходный предмет)")
+	fmt.Println("2. Хардкор (одна жизнь, поражение стирает Хранителя)")
+	fmt.Print("Выберите режим: ")
+
+	input, _ := reader.ReadString('\n')
+	rules := tournament.DefaultRules()
+	if strings.TrimSpace(input) == "2" {
+		rules = tournament.HardcoreRules()
+	}
+
+	fmt.Println("\nВЫБЕРИТЕ СЛОЖНОСТЬ:")
+	difficulties := tournament.AllDifficulties()
+	for i, d := range difficulties {
+		settings := d.Settings()
+		fmt.Printf("%d. %s (здоровье боссов x%.1f, сила x%.1f, награды x%.2f)\n",
+			i+1, settings.Title, settings.HPMultiplier, settings.StrengthMult, settings.RewardMultiplier)
+	}
+	fmt.Print("Выберите сложность: ")
+	input, _ = reader.ReadString('\n')
+	idx, err := strconv.Atoi(strings.TrimSpace(input))
+	if err == nil && idx >= 1 && idx <= len(difficulties) {
+		rules.Difficulty = difficulties[idx-1]
+	}
+
+	fmt.Print("Адаптивная сложность (боссы растут вместе с вашим снаряжением)? (да/нет): ")
+	confirm, _ := reader.ReadString('\n')
+	confirm = strings.TrimSpace(strings.ToLower(confirm))
+	rules.Adaptive = confirm == "да" || confirm == "д" || confirm == "yes"
+
+	return rules
+}
+
+// playTournamentBattle - бой турнира; после поражения можно повторить, отступить или сдаться
+func playTournamentBattle(p *player.Player, t *tournament.Tournament, reader *bufio.Reader, final bool) {
+	for {
+		p.ResetForBattle()
+
+		var victory bool
+		if final {
+			victory = t.StartFinalBoss()
+		} else {
+			victory = t.StartNextGuild()
+		}
+		if victory || t.IsGameOver() {
+			return
+		}
+
+		fmt.Println("\n1. Повторить бой")
+		fmt.Println("2. Отступить (вернуться в меню)")
+		fmt.Println("3. Сдаться (начать турнир заново)")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			continue
+		case "3":
+			t.Abandon()
+			return
+		default:
+			return
+		}
+	}
+}
+
+func manageInventory(p *player.Player, reader *bufio.Reader) {
+	for {
+		fmt.Println("\n" + strings.Repeat("=", 50))
+		fmt.Println("УПРАВЛЕНИЕ ИНВЕНТАРЕМ")
+		fmt.Println(strings.Repeat("=", 50))
+
+		fmt.Println("1. Показать инвентарь")
+		fmt.Println("2. Экипировать предмет")
+		fmt.Println("3. Снять предмет")
+		fmt.Println("4. Использовать предмет (вне боя)")
+		fmt.Println("5. Мастерская (крафт, улучшение, разбор)")
+		fmt.Println("6. Сортировать рюкзак")
+		fmt.Println("7. Показать по типу или редкости")
+		fmt.Println("8. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		choice, err := strconv.Atoi(input)
+		if err != nil {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+
+		switch choice {
+		case 1:
+			p.ShowInventory()
+
+		case 2:
+			// Номера в отфильтрованном списке - те же, что и в полном инвентаре
+			if p.ShowItems(func(item *items.Item) bool { return !item.IsConsumable() }) == 0 {
+				fmt.Println("Нет предметов для экипировки.")
+				continue
+			}
+			fmt.Print("Введите номер предмета для экипировки: ")
+			idxInput, _ := reader.ReadString('\n')
+			idxInput = strings.TrimSpace(idxInput)
+			idx, _ := strconv.Atoi(idxInput)
+			if idx >= 1 && idx <= len(p.Inventory) {
+				p.EquipItem(idx - 1)
+			} else {
+				fmt.Println("Неверный номер!")
+			}
+
+		case 3:
+			if len(p.Equipped) == 0 {
+				fmt.Println("Нет надетых предметов.")
+				continue
+			}
+			fmt.Println("\n=== НАДЕТО ===")
+			for i, item := range p.Equipped {
+				fmt.Printf("%d. %s\n", i+1, item.Name)
+			}
+			fmt.Print("Введите номер предмета для снятия: ")
+			idxInput, _ := reader.ReadString('\n')
+			idxInput = strings.TrimSpace(idxInput)
+			idx, _ := strconv.Atoi(idxInput)
+			if idx >= 1 && idx <= len(p.Equipped) {
+				p.UnequipItem(idx - 1)
+			} else {
+				fmt.Println("Неверный номер!")
+			}
+
+		case 4:
+			if p.ShowItems((*items.Item).IsConsumable) == 0 {
+				fmt.Println("Нет предметов для использования.")
+				continue
+			}
+			fmt.Print("Введите номер предмета для использования: ")
+			idxInput, _ := reader.ReadString('\n')
+			idxInput = strings.TrimSpace(idxInput)
+			idx, _ := strconv.Atoi(idxInput)
+			if idx >= 1 && idx <= len(p.Inventory) {
+				p.UseItem(idx - 1)
+			} else {
+				fmt.Println("Неверный номер!")
+			}
+
+		case 5:
+			crafting.Open(p, reader)
+
+		case 6:
+			sortInventory(p, reader)
+
+		case 7:
+			filterInventory(p, reader)
+
+		case 8:
+			return
+		}
+	}
+}
+
+func sortInventory(p *player.Player, reader *bufio.Reader) {
+	fmt.Println("Сортировать: 1 - по редкости, 2 - по типу, 3 - по названию")
+	input, _ := reader.ReadString('\n')
+	switch strings.TrimSpace(input) {
+	case "1":
+		p.SortInventory(items.SortByRarity)
+	case "2":
+		p.SortInventory(items.SortByKind)
+	case "3":
+		p.SortInventory(items.SortByName)
+	default:
+		fmt.Println("Неверный ввод!")
+		return
+	}
+	fmt.Println("✅ Рюкзак отсортирован")
+	p.ShowItems(nil)
+}
+
+// filterInventory - показывает часть рюкзака; номера остаются номерами ячеек
+func filterInventory(p *player.Player, reader *bufio.Reader) {
+	fmt.Println("Показать: 1 - расходники, 2 - оружие, 3 - броня, 4 - обычные, 5 - редкие, 6 - легендарные")
+	input, _ := reader.ReadString('\n')
+
+	var filter func(*items.Item) bool
+	switch strings.TrimSpace(input) {
+	case "1", "2", "3":
+		kind := map[string]string{"1": "consumable", "2": "weapon", "3": "armor"}[strings.TrimSpace(input)]
+		filter = func(item *items.Item) bool { return item.Kind() == kind }
+	case "4", "5", "6":
+		rarity := map[string]items.Rarity{"4": items.Common, "5": items.Rare, "6": items.Legendary}[strings.TrimSpace(input)]
+		filter = func(item *items.Item) bool { return item.Rarity == rarity }
+	default:
+		fmt.Println("Неверный ввод!")
+		return
+	}
+
+	fmt.Printf("\n=== 🎒 ИНВЕНТАРЬ (%d/%d) ===\n", len(p.Inventory), p.BagCapacity())
+	if p.ShowItems(filter) == 0 {
+		fmt.Println("Таких предметов нет")
+	}
+}
+
+func checkNicknameExistsOnServer(name string) bool {
+	resp, err := http.Get(serverURL + "check-nick?name=" + name)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	return string(body) == "exists"
+}
+
+func registerNicknameOnServer(name string) {
+	http.Post(serverURL+"register-nick", "text/plain", strings.NewReader(name))
+}
+
@@ -1,8 +1,8 @@
-package main
-
-import "game/client"
-
-func main() {
-	cl := client.NewChatClient("http://localhost:8080")
-	cl.Start()
+package main
+
+import "game/client"
+
+func main() {
+	cl := client.NewChatClient("http://localhost:8080")
+	cl.Start()
 }
\ No newline at end of file
@@ -1,8 +1,8 @@
-package main
-
-import "game/server"
-
-func main() {
-	srv := server.NewChatServer()
-	srv.Start("8080")
-}
+package main
+
+import "game/server"
+
+func main() {
+	srv := server.NewChatServer()
+	srv.Start("8080")
+}
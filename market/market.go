@@ -0,0 +1,595 @@
+package market
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"game/items"
+	"game/player"
+	"game/shop"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Market - обмен между игроками и аукцион. Всё имущество, участвующее в сделках,
+// хранит сервер: клиент только показывает его и забирает пришедшие предметы
+type Market struct {
+	serverURL  string
+	httpClient *http.Client
+	wallet     *shop.RemoteShop
+	key        string // ключ серверного аккаунта: без него сервер не примет ни одной сделки
+}
+
+type tradeInfo struct {
+	ID              string
+	From            string
+	To              string
+	FromItems       string
+	FromImagination string
+	ToItems         string
+	ToImagination   string
+}
+
+type lotInfo struct {
+	ID          string
+	Seller      string
+	Item        string
+	Price       int
+	MinBid      int
+	Buyout      int
+	HighBidder  string
+	SecondsLeft int
+}
+
+func NewMarket(serverURL string) *Market {
+	return &Market{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		wallet: shop.NewRemoteShop(serverURL),
+	}
+}
+
+func (m *Market) Open(p *player.Player) {
+	if err := m.wallet.Sync(p); err != nil {
+		fmt.Println("⚠️ Рынок недоступен:", err)
+		return
+	}
+	key, err := m.wallet.AccountKey(p.Name)
+	if err != nil {
+		fmt.Println("⚠️ Рынок недоступен:", err)
+		return
+	}
+	m.key = key
+	m.claim(p)
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println("\n=== 🤝 РЫНОК ===")
+		fmt.Printf("💰 Баланс на сервере: %d\n", p.Imagination)
+		fmt.Println("1. Мои предметы на сервере")
+		fmt.Println("2. Предложить обмен")
+		fmt.Println("3. Мои обмены")
+		fmt.Println("4. Аукцион")
+		fmt.Println("5. Выставить лот")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			m.showInventory(p)
+		case "2":
+			m.offerTrade(p, reader)
+		case "3":
+			m.tradesMenu(p, reader)
+		case "4":
+			m.auctionMenu(p, reader)
+		case "5":
+			m.createLot(p, reader)
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+
+		m.refresh(p)
+	}
+}
+
+// claim - забирает предметы, полученные от других игроков
+func (m *Market) claim(p *player.Player) {
+	response, err := m.post("/market/claim", p.Name)
+	if err != nil {
+		return
+	}
+	for _, line := range splitLines(response) {
+		// Снаряжение приходит экземпляром со всеми свойствами, расходники - названием
+		var item *items.Item
+		if strings.HasPrefix(line, "item:") {
+			item, _ = shop.DecodeItem(line)
+		} else if base := items.FindByName(line); base != nil {
+			itemCopy := *base
+			item = &itemCopy
+		}
+		if item == nil {
+			continue
+		}
+		if !p.PutItem(item) {
+			// Сервер уже отдал предмет, поэтому он ложится сверх вместимости рюкзака
+			p.Inventory = append(p.Inventory, item)
+		}
+		fmt.Printf("📬 Получен предмет: %s\n", item.DisplayName())
+	}
+	if free := p.FreeSlots(); free < 0 {
+		fmt.Printf("⚠️ Рюкзак переполнен на %d ячеек: освободите место, чтобы получать новые предметы\n", -free)
+	}
+}
+
+// refresh - забирает почту и принимает баланс сервера после сделки
+func (m *Market) refresh(p *player.Player) {
+	m.claim(p)
+	if err := m.wallet.Sync(p); err != nil {
+		fmt.Println("⚠️ Не удалось сверить кошелёк:", err)
+	}
+}
+
+func (m *Market) showInventory(p *player.Player) {
+	response, err := m.get("/market/inventory?player=" + url.QueryEscape(p.Name))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	fmt.Println("\n=== 📦 ПРЕДМЕТЫ НА СЕРВЕРЕ ===")
+	lines := splitLines(response)
+	if len(lines) == 0 {
+		fmt.Println("Пусто. На рынке можно торговать только предметами, купленными в серверной лавке или полученными от игроков")
+		return
+	}
+	for _, line := range lines {
+		parts := strings.Split(line, "|")
+		if len(parts) == 2 {
+			fmt.Printf("• %s x%s\n", parts[0], parts[1])
+		}
+	}
+}
+
+func (m *Market) offerTrade(p *player.Player, reader *bufio.Reader) {
+	fmt.Print("Кому предложить обмен: ")
+	to := readLine(reader)
+	if to == "" || to == p.Name {
+		fmt.Println("❌ Неверное имя игрока")
+		return
+	}
+
+	giveItems := m.pickItems(p, reader)
+	giveRefs := make([]string, 0, len(giveItems))
+	for _, item := range giveItems {
+		ref, _ := tradeRef(item)
+		giveRefs = append(giveRefs, ref)
+	}
+	fmt.Print("Сколько воображения добавить (Enter - 0): ")
+	giveImagination := readNumber(reader)
+	fmt.Print("Какие предметы хотите взамен (через запятую, Enter - ничего): ")
+	wantItems := readLine(reader)
+	fmt.Print("Сколько воображения хотите взамен (Enter - 0): ")
+	wantImagination := readNumber(reader)
+
+	if len(giveItems) == 0 && giveImagination == 0 {
+		fmt.Println("❌ Нечего предложить")
+		return
+	}
+
+	body := fmt.Sprintf("%s|%s|%s|%d|%s|%d", p.Name, to, strings.Join(giveRefs, ","), giveImagination, wantItems, wantImagination)
+	response, err := m.post("/trade/offer", body)
+	if err != nil {
+		fmt.Println("⚠️ Предложение не отправлено:", err)
+		return
+	}
+	if m.reportError(response) {
+		return
+	}
+
+	for _, item := range giveItems {
+		removeInstance(p, item)
+	}
+	fmt.Printf("✅ Предложение отправлено игроку %s. Ваша часть удерживается сервером до ответа\n", to)
+}
+
+// pickItems - выбор предметов из инвентаря по номерам через запятую.
+// Возвращает сами выбранные экземпляры: из стопки - столько раз, сколько штук выбрано
+func (m *Market) pickItems(p *player.Player, reader *bufio.Reader) []*items.Item {
+	if len(p.Inventory) == 0 {
+		return nil
+	}
+
+	fmt.Println("\nВаш инвентарь:")
+	for i, item := range p.Inventory {
+		fmt.Printf("%d. %s%s\n", i+1, item.DisplayName(), item.QuantityLabel())
+	}
+	fmt.Print("Номера предметов для обмена через запятую, номер стопки можно повторить (Enter - без предметов): ")
+
+	picked := make([]*items.Item, 0)
+	used := make(map[int]int)
+	for _, field := range strings.Split(readLine(reader), ",") {
+		index, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || index < 1 || index > len(p.Inventory) || used[index] >= p.Inventory[index-1].Quantity() {
+			continue
+		}
+		item := p.Inventory[index-1]
+		if _, reason := tradeRef(item); reason != "" {
+			fmt.Printf("❌ %s: %s\n", item.DisplayName(), reason)
+			continue
+		}
+		used[index]++
+		picked = append(picked, item)
+	}
+	return picked
+}
+
+// tradeRef - как сервер узнаёт предмет в сделке: снаряжение - по номеру экземпляра, расходник - по названию.
+// Если предмет выставить нельзя, вместо номера - причина
+func tradeRef(item *items.Item) (string, string) {
+	switch {
+	case item.Level > 0:
+		return "", "улучшенные предметы на рынок не принимаются"
+	case item.IsConsumable():
+		return item.Name, ""
+	case item.ServerID == "":
+		return "", "сервер не знает об этом предмете: торговать можно только снаряжением из серверной лавки или от игроков"
+	case item.Durability < item.MaxDurability:
+		return "", "предмет изношен, почините его перед сделкой"
+	}
+	return item.ServerID, ""
+}
+
+// pickWanted - предметы из инвентаря под желаемые названия обмена; пустая причина - всё нашлось
+func pickWanted(p *player.Player, wanted string) ([]*items.Item, string) {
+	picked := make([]*items.Item, 0)
+	used := make(map[*items.Item]int)
+	for _, name := range strings.Split(wanted, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var found *items.Item
+		for _, item := range p.Inventory {
+			if item.Name != name || used[item] >= item.Quantity() {
+				continue
+			}
+			if _, reason := tradeRef(item); reason == "" {
+				found = item
+				break
+			}
+		}
+		if found == nil {
+			return nil, fmt.Sprintf("нет подходящего предмета «%s» (неулучшенного, целого и известного серверу)", name)
+		}
+		used[found]++
+		picked = append(picked, found)
+	}
+	return picked, ""
+}
+
+func (m *Market) tradesMenu(p *player.Player, reader *bufio.Reader) {
+	response, err := m.get("/trade/list?player=" + url.QueryEscape(p.Name))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	trades := make([]tradeInfo, 0)
+	for _, line := range splitLines(response) {
+		parts := strings.Split(line, "|")
+		if len(parts) < 7 {
+			continue
+		}
+		trades = append(trades, tradeInfo{parts[0], parts[1], parts[2], parts[3], parts[4], parts[5], parts[6]})
+	}
+
+	fmt.Println("\n=== 🔁 ОБМЕНЫ ===")
+	if len(trades) == 0 {
+		fmt.Println("Активных обменов нет")
+		return
+	}
+	for i, t := range trades {
+		direction := "📤 Вы → " + t.To
+		if t.To == p.Name {
+			direction = "📥 " + t.From + " → вам"
+		}
+		fmt.Printf("%d. %s\n", i+1, direction)
+		fmt.Printf("   Отдаёт %s: %s, %s✨\n", t.From, orNothing(t.FromItems), t.FromImagination)
+		fmt.Printf("   Просит: %s, %s✨\n", orNothing(t.ToItems), t.ToImagination)
+	}
+
+	fmt.Print("\nВыберите обмен (0 - назад): ")
+	choice := readNumber(reader)
+	if choice < 1 || choice > len(trades) {
+		return
+	}
+	t := trades[choice-1]
+
+	if t.From == p.Name {
+		fmt.Print("Отозвать предложение? (да/нет): ")
+		if isYes(readLine(reader)) {
+			m.simpleAction(p, "/trade/cancel", p.Name+"|"+t.ID, "✅ Предложение отозвано, предметы вернутся в инвентарь")
+		}
+		return
+	}
+
+	fmt.Println("1. Принять")
+	fmt.Println("2. Отклонить")
+	fmt.Print("Выберите действие: ")
+	switch readLine(reader) {
+	case "1":
+		giveItems, reason := pickWanted(p, t.ToItems)
+		if reason != "" {
+			fmt.Println("❌", reason)
+			return
+		}
+		refs := make([]string, 0, len(giveItems))
+		for _, item := range giveItems {
+			ref, _ := tradeRef(item)
+			refs = append(refs, ref)
+		}
+		response, err := m.post("/trade/accept", p.Name+"|"+t.ID+"|"+strings.Join(refs, ","))
+		if err != nil {
+			fmt.Println("⚠️ Обмен не удался:", err)
+			return
+		}
+		if m.reportError(response) {
+			return
+		}
+		for _, item := range giveItems {
+			removeInstance(p, item)
+		}
+		fmt.Println("✅ Обмен завершён!")
+	case "2":
+		m.simpleAction(p, "/trade/cancel", p.Name+"|"+t.ID, "✅ Предложение отклонено")
+	}
+}
+
+func (m *Market) auctionMenu(p *player.Player, reader *bufio.Reader) {
+	response, err := m.get("/auction/list")
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	lots := make([]lotInfo, 0)
+	for _, line := range splitLines(response) {
+		parts := strings.Split(line, "|")
+		if len(parts) < 8 {
+			continue
+		}
+		lot := lotInfo{ID: parts[0], Seller: parts[1], Item: parts[2], HighBidder: parts[6]}
+		lot.Price, _ = strconv.Atoi(parts[3])
+		lot.MinBid, _ = strconv.Atoi(parts[4])
+		lot.Buyout, _ = strconv.Atoi(parts[5])
+		lot.SecondsLeft, _ = strconv.Atoi(parts[7])
+		lots = append(lots, lot)
+	}
+
+	fmt.Println("\n=== 🔨 АУКЦИОН ===")
+	if len(lots) == 0 {
+		fmt.Println("Лотов нет")
+		return
+	}
+	for i, lot := range lots {
+		leader := "ставок нет"
+		if lot.HighBidder != "" {
+			leader = "лидер: " + lot.HighBidder
+		}
+		buyout := ""
+		if lot.Buyout > 0 {
+			buyout = fmt.Sprintf(", выкуп %d✨", lot.Buyout)
+		}
+		fmt.Printf("%d. %s от %s - %d✨ (%s%s), осталось %s\n",
+			i+1, lot.Item, lot.Seller, lot.Price, leader, buyout, formatSeconds(lot.SecondsLeft))
+	}
+
+	fmt.Print("\nВыберите лот (0 - назад): ")
+	choice := readNumber(reader)
+	if choice < 1 || choice > len(lots) {
+		return
+	}
+	lot := lots[choice-1]
+
+	if lot.Seller == p.Name {
+		fmt.Print("Снять лот с торгов? (да/нет): ")
+		if isYes(readLine(reader)) {
+			m.simpleAction(p, "/auction/cancel", p.Name+"|"+lot.ID, "✅ Лот снят, предмет вернётся в инвентарь")
+		}
+		return
+	}
+
+	fmt.Printf("1. Сделать ставку (минимум %d✨)\n", lot.MinBid)
+	if lot.Buyout > 0 {
+		fmt.Printf("2. Выкупить за %d✨\n", lot.Buyout)
+	}
+	fmt.Print("Выберите действие: ")
+	switch readLine(reader) {
+	case "1":
+		fmt.Print("Ваша ставка: ")
+		amount := readNumber(reader)
+		m.simpleAction(p, "/auction/bid", fmt.Sprintf("%s|%s|%d", p.Name, lot.ID, amount), "✅ Ставка принята. Если её перебьют, воображение вернётся")
+	case "2":
+		if lot.Buyout > 0 {
+			m.simpleAction(p, "/auction/buyout", p.Name+"|"+lot.ID, "✅ Лот выкуплен!")
+		}
+	}
+}
+
+func (m *Market) createLot(p *player.Player, reader *bufio.Reader) {
+	if len(p.Inventory) == 0 {
+		fmt.Println("Инвентарь пуст - выставлять нечего.")
+		return
+	}
+
+	fmt.Println("\nВаш инвентарь:")
+	for i, item := range p.Inventory {
+		fmt.Printf("%d. %s%s\n", i+1, item.DisplayName(), item.QuantityLabel())
+	}
+	fmt.Print("Какой предмет выставить (0 - назад): ")
+	choice := readNumber(reader)
+	if choice < 1 || choice > len(p.Inventory) {
+		return
+	}
+	item := p.Inventory[choice-1]
+	ref, reason := tradeRef(item)
+	if reason != "" {
+		fmt.Printf("❌ %s: %s\n", item.DisplayName(), reason)
+		return
+	}
+
+	fmt.Print("Стартовая цена: ")
+	start := readNumber(reader)
+	fmt.Print("Цена выкупа (Enter - без выкупа): ")
+	buyout := readNumber(reader)
+	fmt.Print("Длительность в минутах (Enter - 60): ")
+	minutes := readNumber(reader)
+	if minutes == 0 {
+		minutes = 60
+	}
+
+	body := fmt.Sprintf("%s|%s|%d|%d|%d", p.Name, ref, start, buyout, minutes)
+	response, err := m.post("/auction/create", body)
+	if err != nil {
+		fmt.Println("⚠️ Лот не выставлен:", err)
+		return
+	}
+	if m.reportError(response) {
+		return
+	}
+
+	removeInstance(p, item)
+	fmt.Printf("✅ %s выставлен на аукцион\n", item.DisplayName())
+}
+
+func (m *Market) simpleAction(p *player.Player, path, body, success string) {
+	response, err := m.post(path, body)
+	if err != nil {
+		fmt.Println("⚠️ Действие не удалось:", err)
+		return
+	}
+	if m.reportError(response) {
+		return
+	}
+	fmt.Println(success)
+}
+
+// reportError - печатает понятное сообщение, если сервер ответил error:...
+func (m *Market) reportError(response string) bool {
+	if !strings.HasPrefix(response, "error:") {
+		return false
+	}
+
+	code := strings.TrimPrefix(strings.TrimSpace(response), "error:")
+	switch {
+	case strings.HasPrefix(code, "not_enough"):
+		fmt.Println("❌ Недостаточно воображения на сервере!")
+	case strings.HasPrefix(code, "not_owned"):
+		fmt.Println("❌ Сервер не знает об этом предмете: торговать можно только предметами, купленными в серверной лавке или полученными от игроков")
+	case strings.HasPrefix(code, "low_bid"):
+		fmt.Println("❌ Ставка слишком мала:", strings.TrimPrefix(code, "low_bid|"))
+	case code == "use_buyout":
+		fmt.Println("❌ Ставка не меньше цены выкупа - просто выкупите лот")
+	case code == "own_lot":
+		fmt.Println("❌ Это ваш собственный лот")
+	case code == "wrong_items":
+		fmt.Println("❌ Отданные предметы не совпали с тем, что просили")
+	case code == "has_bids":
+		fmt.Println("❌ На лот уже есть ставки, снять его нельзя")
+	default:
+		fmt.Println("❌ Сделка недоступна:", code)
+	}
+	return true
+}
+
+func (m *Market) get(path string) (string, error) {
+	resp, err := m.httpClient.Get(m.serverURL + shop.WithKey(path, m.key))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(raw)))
+	}
+	return string(raw), nil
+}
+
+func (m *Market) post(path, body string) (string, error) {
+	resp, err := m.httpClient.Post(m.serverURL+shop.WithKey(path, m.key), "text/plain", strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(raw)))
+	}
+	return string(raw), nil
+}
+
+// removeInstance - убирает из инвентаря одну штуку именно этого предмета (из стопки - одну)
+func removeInstance(p *player.Player, target *items.Item) {
+	for i, item := range p.Inventory {
+		if item == target {
+			p.TakeItem(i)
+			return
+		}
+	}
+}
+
+func splitLines(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	return strings.Split(raw, "\n")
+}
+
+func readLine(reader *bufio.Reader) string {
+	input, _ := reader.ReadString('\n')
+	return strings.TrimSpace(input)
+}
+
+func readNumber(reader *bufio.Reader) int {
+	n, _ := strconv.Atoi(readLine(reader))
+	return n
+}
+
+func isYes(answer string) bool {
+	answer = strings.ToLower(answer)
+	return answer == "да" || answer == "д" || answer == "yes"
+}
+
+func orNothing(list string) string {
+	if list == "" {
+		return "без предметов"
+	}
+	return list
+}
+
+func formatSeconds(seconds int) string {
+	if seconds < 60 {
+		return fmt.Sprintf("%d сек", seconds)
+	}
+	if seconds < 3600 {
+		return fmt.Sprintf("%d мин", seconds/60)
+	}
+	return fmt.Sprintf("%d ч %d мин", seconds/3600, seconds%3600/60)
+}
@@ -0,0 +1,131 @@
+package player
+
+import (
+	"fmt"
+	"game/items"
+)
+
+// DefaultBagSize - ячеек в рюкзаке у нового героя (стопка расходников занимает одну ячейку)
+const DefaultBagSize = 12
+
+// BagCapacity - сколько ячеек в рюкзаке. У старых сохранений размер не записан
+func (p *Player) BagCapacity() int {
+	if p.BagSize <= 0 {
+		return DefaultBagSize
+	}
+	return p.BagSize
+}
+
+// FreeSlots - свободные ячейки рюкзака (может быть меньше нуля у старых переполненных сохранений)
+func (p *Player) FreeSlots() int {
+	return p.BagCapacity() - len(p.Inventory)
+}
+
+// stackFor - ячейка, в которую можно доложить предмет, или -1
+func (p *Player) stackFor(item *items.Item) int {
+	for i, slot := range p.Inventory {
+		if slot.StacksWith(item) {
+			return i
+		}
+	}
+	return -1
+}
+
+// HasRoomFor - поместится ли предмет: в готовую стопку или в свободную ячейку
+func (p *Player) HasRoomFor(item *items.Item) bool {
+	return p.stackFor(item) >= 0 || p.FreeSlots() > 0
+}
+
+// PutItem - кладёт предмет в рюкзак без сообщений; false, если места нет
+func (p *Player) PutItem(item *items.Item) bool {
+	if i := p.stackFor(item); i >= 0 {
+		p.Inventory[i].Count = p.Inventory[i].Quantity() + item.Quantity()
+		return true
+	}
+	if p.FreeSlots() <= 0 {
+		return false
+	}
+	p.Inventory = append(p.Inventory, item)
+	return true
+}
+
+// TakeItem - достаёт из ячейки одну штуку. Из стопки возвращается отдельная копия
+func (p *Player) TakeItem(index int) *items.Item {
+	if index < 0 || index >= len(p.Inventory) {
+		return nil
+	}
+
+	item := p.Inventory[index]
+	if item.Quantity() > 1 {
+		item.Count--
+		single := *item
+		single.Count = 0
+		return &single
+	}
+	p.Inventory = append(p.Inventory[:index], p.Inventory[index+1:]...)
+	item.Count = 0
+	return item
+}
+
+// TakeItemByName - достаёт одну штуку по названию; улучшенные предметы не трогает
+func (p *Player) TakeItemByName(name string) *items.Item {
+	for i, item := range p.Inventory {
+		if item.Name == name && item.Level == 0 {
+			return p.TakeItem(i)
+		}
+	}
+	return nil
+}
+
+// CountItem - сколько неулучшенных предметов с таким названием в рюкзаке
+func (p *Player) CountItem(name string) int {
+	count := 0
+	for _, item := range p.Inventory {
+		if item.Name == name && item.Level == 0 {
+			count += item.Quantity()
+		}
+	}
+	return count
+}
+
+// StackInventory - собирает одинаковые расходники в стопки (сохранения до появления стопок)
+func (p *Player) StackInventory() {
+	loose := p.Inventory
+	p.Inventory = make([]*items.Item, 0, len(loose))
+	for _, item := range loose {
+		if i := p.stackFor(item); i >= 0 {
+			p.Inventory[i].Count = p.Inventory[i].Quantity() + item.Quantity()
+			continue
+		}
+		p.Inventory = append(p.Inventory, item)
+	}
+}
+
+// SortInventory - переставляет ячейки рюкзака; номера в списке меняются только здесь
+func (p *Player) SortInventory(by string) {
+	items.Sort(p.Inventory, by)
+}
+
+// ShowItems - предметы, подходящие под фильтр, с их настоящими номерами ячеек,
+// чтобы выбор по номеру работал и в отфильтрованном списке. Возвращает число показанных
+func (p *Player) ShowItems(filter func(*items.Item) bool) int {
+	shown := 0
+	for i, item := range p.Inventory {
+		if filter != nil && !filter(item) {
+			continue
+		}
+		shown++
+		color := item.GetRarityColor()
+		fmt.Printf("%s%d. %s%s - %s\033[0m\n", color, i+1, item.DisplayName(), item.QuantityLabel(), item.Description)
+		if bonus := item.BonusDescription(); bonus != "" {
+			fmt.Printf("   ✦ %s\n", bonus)
+		}
+		if set := items.SetOf(item.Name); set != "" {
+			fmt.Printf("   🧩 Комплект «%s»\n", set)
+		}
+		if item.Slot() != "" {
+			p.showComparison(item)
+		}
+	}
+	return shown
+}
@@ -1,219 +1,384 @@
-package player
-
-import (
-	"fmt"
-	"game/items"
-)
-
-type Player struct {
-	Name          string
-	HP            int
-	MaxHP         int
-	BaseStrength  int
-	Imagination   int
-	Inventory     []*items.Item
-	Equipped      []*items.Item
-	ActiveEffects map[string]int
-	Wins          int
-}
-
-func NewPlayer(name string) *Player {
-	return &Player{
-		Name:          name,
-		HP:            120,
-		MaxHP:         120,
-		BaseStrength:  20,
-		Imagination:   150,
-		Inventory:     make([]*items.Item, 0),
-		Equipped:      make([]*items.Item, 0),
-		ActiveEffects: make(map[string]int),
-		Wins:          0,
-	}
-}
-
-func (p *Player) GetStrength() int {
-	total := p.BaseStrength
-	for _, item := range p.Equipped {
-		total += item.Effect.Strength
-	}
-	if bonus, ok := p.ActiveEffects["strength_bonus"]; ok {
-		total += bonus
-	}
-	return total
-}
-
-func (p *Player) GetMaxHP() int {
-	total := p.MaxHP
-	for _, item := range p.Equipped {
-		total += item.Effect.MaxHP
-	}
-	return total
-}
-
-func (p *Player) AddItem(item *items.Item) {
-	p.Inventory = append(p.Inventory, item)
-	color := item.GetRarityColor()
-	fmt.Printf("%s🎒 Получен предмет: %s - %s\033[0m\n", color, item.Name, item.Description)
-}
-
-func (p *Player) EquipItem(index int) bool {
-	if index < 0 || index >= len(p.Inventory) {
-		fmt.Println("Неверный номер предмета")
-		return false
-	}
-
-	item := p.Inventory[index]
-
-	// Проверяем, можно ли экипировать (не расходный)
-	if item.Effect.Heal > 0 || item.Effect.StunRounds > 0 || item.Effect.SpecialEffect != "" {
-		if item.Effect.Heal > 0 {
-			fmt.Println("❌ Лечебные предметы нельзя экипировать, их нужно использовать в бою")
-		} else if item.Effect.StunRounds > 0 {
-			fmt.Println("❌ Предметы оглушения нельзя экипировать, их нужно использовать в бою")
-		} else {
-			fmt.Println("❌ Этот предмет нельзя экипировать")
-		}
-		return false
-	}
-
-	// Удаляем из инвентаря и добавляем в экипировку
-	p.Inventory = append(p.Inventory[:index], p.Inventory[index+1:]...)
-	p.Equipped = append(p.Equipped, item)
-
-	// Применяем эффекты
-	if item.Effect.MaxHP > 0 {
-		oldMax := p.MaxHP
-		p.MaxHP += item.Effect.MaxHP
-		p.HP += item.Effect.MaxHP
-		fmt.Printf("❤️ Максимальное здоровье увеличено: %d -> %d\n", oldMax, p.MaxHP)
-	}
-
-	fmt.Printf("✅ Экипировано: %s\n", item.Name)
-	return true
-}
-
-func (p *Player) UnequipItem(index int) bool {
-	if index < 0 || index >= len(p.Equipped) {
-		fmt.Println("Неверный номер предмета")
-		return false
-	}
-
-	item := p.Equipped[index]
-	p.Equipped = append(p.Equipped[:index], p.Equipped[index+1:]...)
-	p.Inventory = append(p.Inventory, item)
-
-	if item.Effect.MaxHP > 0 {
-		p.MaxHP -= item.Effect.MaxHP
-		if p.HP > p.MaxHP {
-			p.HP = p.MaxHP
-		}
-		fmt.Printf("❤️ Максимальное здоровье уменьшено: %d\n", p.MaxHP)
-	}
-
-	fmt.Printf("✅ Снято: %s\n", item.Name)
-	return true
-}
-
-func (p *Player) UseItem(index int) (*items.ItemEffect, bool) {
-	if index < 0 || index >= len(p.Inventory) {
-		fmt.Println("Неверный номер предмета")
-		return nil, false
-	}
-
-	item := p.Inventory[index]
-	color := item.GetRarityColor()
-	if item.Effect.MaxHP > 0 || item.Effect.Strength > 0 {
-		fmt.Println("Данную вещь можно только экипировать")
-		return nil, false
-	} else {
-		fmt.Printf("\n%s✨ Используется: %s\033[0m\n", color, item.Name)
-
-		// Удаляем использованный предмет
-		p.Inventory = append(p.Inventory[:index], p.Inventory[index+1:]...)
-		fmt.Println("Предмет использован")
-
-		return &item.Effect, true
-	}
-}
-
-func (p *Player) Heal(amount int) {
-	oldHP := p.HP
-	p.HP += amount
-	maxHP := p.GetMaxHP()
-	if p.HP > maxHP {
-		p.HP = maxHP
-	}
-	healed := p.HP - oldHP
-	if healed > 0 {
-		fmt.Printf("❤️ Восстановлено %d здоровья! (%d/%d)\n", healed, p.HP, maxHP)
-	}
-}
-
-func (p *Player) TakeDamage(damage int) {
-	p.HP -= damage
-	if p.HP < 0 {
-		p.HP = 0
-	}
-	fmt.Printf("💔 Получено %d урона! Осталось: %d/%d\n", damage, p.HP, p.GetMaxHP())
-}
-
-func (p *Player) AddImagination(amount int) {
-	p.Imagination += amount
-	fmt.Printf("✨ Получено %d воображения! Теперь: %d\n", amount, p.Imagination)
-}
-
-func (p *Player) SpendImagination(amount int) bool {
-	if p.Imagination >= amount {
-		p.Imagination -= amount
-		return true
-	}
-	fmt.Println("❌ Недостаточно воображения!")
-	return false
-}
-
-func (p *Player) IsAlive() bool {
-	return p.HP > 0
-}
-
-func (p *Player) ResetForBattle() {
-	// Сбрасываем временные эффекты
-	p.ActiveEffects = make(map[string]int)
-	// Восстанавливаем здоровье до максимума
-	p.HP = p.GetMaxHP()
-}
-
-func (p *Player) ShowInventory() {
-	fmt.Println("\n=== 🎒 ИНВЕНТАРЬ ===")
-	if len(p.Inventory) == 0 {
-		fmt.Println("Инвентарь пуст")
-	} else {
-		for i, item := range p.Inventory {
-			color := item.GetRarityColor()
-			fmt.Printf("%s%d. %s - %s\033[0m\n", color, i+1, item.Name, item.Description)
-		}
-	}
-
-	if len(p.Equipped) > 0 {
-		fmt.Println("\n=== ⚔️ ЭКИПИРОВАНО ===")
-		for i, item := range p.Equipped {
-			color := item.GetRarityColor()
-			fmt.Printf("%s%d. %s\033[0m\n", color, i+1, item.Name)
-		}
-	}
-
-	p.ShowStats()
-}
-
-func (p *Player) ShowStats() {
-	fmt.Printf("\n❤️ Здоровье: %d/%d\n", p.HP, p.GetMaxHP())
-	fmt.Printf("⚔️ Сила: %d (базовая: %d", p.GetStrength(), p.BaseStrength)
-	if len(p.Equipped) > 0 {
-		fmt.Printf(" + предметы")
-	}
-	if bonus, ok := p.ActiveEffects["strength_bonus"]; ok {
-		fmt.Printf(" +%d бонус", bonus)
-	}
-	fmt.Printf(")\n")
-	fmt.Printf("✨ Воображение: %d\n", p.Imagination)
-	fmt.Printf("🏆 Побед: %d\n", p.Wins)
-}
+package player
+
+import (
+	"fmt"
+	"game/items"
+	"math/rand"
+)
+
+type Player struct {
+	Name          string
+	HP            int
+	MaxHP         int
+	BaseStrength  int
+	Imagination   int
+	Inventory     []*items.Item
+	Equipped      []*items.Item
+	ActiveEffects map[string]int
+	Wins          int
+	// Баланс на момент последней сверки с серверным кошельком
+	SyncedImagination int
+	// Материалы мастерской: название -> количество
+	Materials map[string]int
+	// Ячеек в рюкзаке, расширяется в лавке
+	BagSize int
+	// Награды одиночной игры, ещё не заявленные серверному кошельку
+	PendingRewards []Reward
+}
+
+// Reward - заработанное воображение и за что оно получено.
+// Claim - тело запроса /shop/reward без имени: вид|подробности; сумму сервер считает сам
+type Reward struct {
+	Claim  string
+	Amount int
+}
+
+// Стартовые характеристики персонажа, по ним же сервер проверяет заявки на PvP
+const (
+	StartHP       = 120
+	StartStrength = 20
+)
+
+func NewPlayer(name string) *Player {
+	return &Player{
+		Name:          name,
+		HP:            StartHP,
+		MaxHP:         StartHP,
+		BaseStrength:  StartStrength,
+		Imagination:   150,
+		Inventory:     make([]*items.Item, 0),
+		Equipped:      make([]*items.Item, 0),
+		ActiveEffects: make(map[string]int),
+		Wins:          0,
+		// Серверный кошелёк открывается с тем же стартовым балансом
+		SyncedImagination: 150,
+		Materials:         make(map[string]int),
+		BagSize:           DefaultBagSize,
+	}
+}
+
+func (p *Player) GetStrength() int {
+	total := p.BaseStrength
+	for _, item := range p.Equipped {
+		total += item.EffectiveEffect().Strength
+	}
+	total += items.SetEffect(p.EquippedNames()).Strength
+	if bonus, ok := p.ActiveEffects["strength_bonus"]; ok {
+		total += bonus
+	}
+	return total
+}
+
+// GetMaxHP - MaxHP хранит только собственное здоровье Хранителя, предметы и комплекты добавляются здесь
+func (p *Player) GetMaxHP() int {
+	total := p.MaxHP
+	for _, item := range p.Equipped {
+		total += item.EffectiveEffect().MaxHP
+	}
+	total += items.SetEffect(p.EquippedNames()).MaxHP
+	return total
+}
+
+// EquippedNames - названия надетых целых предметов (для комплектов)
+func (p *Player) EquippedNames() []string {
+	names := make([]string, 0, len(p.Equipped))
+	for _, item := range p.Equipped {
+		if !item.IsBroken() {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+// EquipmentBonuses - сумма боевых бонусов надетых предметов и комплектов (крит, вампиризм, блок...)
+func (p *Player) EquipmentBonuses() items.ItemEffect {
+	var total items.ItemEffect
+	for _, item := range p.Equipped {
+		total.Add(item.EffectiveEffect())
+	}
+	total.Add(items.SetEffect(p.EquippedNames()))
+	return total
+}
+
+// AddItem - кладёт предмет в рюкзак (расходник - в стопку). Если места нет, предмет не берётся
+func (p *Player) AddItem(item *items.Item) bool {
+	color := item.GetRarityColor()
+	if !p.PutItem(item) {
+		fmt.Printf("%s🎒 Рюкзак полон (%d/%d), %s пришлось оставить\033[0m\n", color, len(p.Inventory), p.BagCapacity(), item.Name)
+		return false
+	}
+	fmt.Printf("%s🎒 Получен предмет: %s - %s\033[0m\n", color, item.Name, item.Description)
+	return true
+}
+
+func (p *Player) EquipItem(index int) bool {
+	if index < 0 || index >= len(p.Inventory) {
+		fmt.Println("Неверный номер предмета")
+		return false
+	}
+
+	item := p.Inventory[index]
+
+	// Проверяем, можно ли экипировать (не расходный)
+	if item.IsConsumable() {
+		if item.Effect.Heal > 0 {
+			fmt.Println("❌ Лечебные предметы нельзя экипировать, их нужно использовать в бою")
+		} else if item.Effect.StunRounds > 0 {
+			fmt.Println("❌ Предметы оглушения нельзя экипировать, их нужно использовать в бою")
+		} else {
+			fmt.Println("❌ Этот предмет нельзя экипировать")
+		}
+		return false
+	}
+
+	// Удаляем из инвентаря и добавляем в экипировку
+	item.EnsureDurability()
+	oldMax := p.GetMaxHP()
+	p.Inventory = append(p.Inventory[:index], p.Inventory[index+1:]...)
+	p.Equipped = append(p.Equipped, item)
+
+	// Применяем эффекты (предмет мог ещё и включить бонус комплекта)
+	if newMax := p.GetMaxHP(); newMax > oldMax {
+		p.HP += newMax - oldMax
+		fmt.Printf("❤️ Максимальное здоровье увеличено: %d -> %d\n", oldMax, newMax)
+	}
+
+	fmt.Printf("✅ Экипировано: %s\n", item.Name)
+	if set := items.SetOf(item.Name); set != "" {
+		fmt.Printf("🧩 Часть комплекта «%s»\n", set)
+	}
+	return true
+}
+
+func (p *Player) UnequipItem(index int) bool {
+	if index < 0 || index >= len(p.Equipped) {
+		fmt.Println("Неверный номер предмета")
+		return false
+	}
+
+	if p.FreeSlots() <= 0 {
+		fmt.Println("❌ Рюкзак полон - снятый предмет некуда положить")
+		return false
+	}
+
+	item := p.Equipped[index]
+	oldMax := p.GetMaxHP()
+	p.Equipped = append(p.Equipped[:index], p.Equipped[index+1:]...)
+	p.Inventory = append(p.Inventory, item)
+
+	if newMax := p.GetMaxHP(); newMax < oldMax {
+		if p.HP > newMax {
+			p.HP = newMax
+		}
+		fmt.Printf("❤️ Максимальное здоровье уменьшено: %d\n", newMax)
+	}
+
+	fmt.Printf("✅ Снято: %s\n", item.Name)
+	return true
+}
+
+// WearEquipment - износ надетых предметов после боя
+func (p *Player) WearEquipment() {
+	for _, item := range p.Equipped {
+		item.EnsureDurability()
+		item.Wear(1)
+	}
+	if maxHP := p.GetMaxHP(); p.HP > maxHP {
+		p.HP = maxHP
+	}
+}
+
+func (p *Player) UseItem(index int) (*items.ItemEffect, bool) {
+	if index < 0 || index >= len(p.Inventory) {
+		fmt.Println("Неверный номер предмета")
+		return nil, false
+	}
+
+	item := p.Inventory[index]
+	color := item.GetRarityColor()
+	if item.Effect.MaxHP > 0 || item.Effect.Strength > 0 {
+		fmt.Println("Данную вещь можно только экипировать")
+		return nil, false
+	} else {
+		fmt.Printf("\n%s✨ Используется: %s\033[0m\n", color, item.Name)
+
+		// Тратим одну штуку из стопки
+		p.TakeItem(index)
+		fmt.Println("Предмет использован")
+
+		return &item.Effect, true
+	}
+}
+
+func (p *Player) Heal(amount int) {
+	oldHP := p.HP
+	p.HP += amount
+	maxHP := p.GetMaxHP()
+	if p.HP > maxHP {
+		p.HP = maxHP
+	}
+	healed := p.HP - oldHP
+	if healed > 0 {
+		fmt.Printf("❤️ Восстановлено %d здоровья! (%d/%d)\n", healed, p.HP, maxHP)
+	}
+}
+
+func (p *Player) TakeDamage(damage int) {
+	p.HP -= damage
+	if p.HP < 0 {
+		p.HP = 0
+	}
+	fmt.Printf("💔 Получено %d урона! Осталось: %d/%d\n", damage, p.HP, p.GetMaxHP())
+}
+
+func (p *Player) AddImagination(amount int) {
+	p.Imagination += amount
+	fmt.Printf("✨ Получено %d воображения! Теперь: %d\n", amount, p.Imagination)
+}
+
+// Earn - награда одиночной игры: зачисляется сразу и запоминается для сверки с сервером
+func (p *Player) Earn(amount int, claim string) {
+	p.AddImagination(amount)
+	p.PendingRewards = append(p.PendingRewards, Reward{Claim: claim, Amount: amount})
+}
+
+// Mark - заявка без награды о начале кампании или забега: с неё сервер заново отсчитывает порядок наград
+func (p *Player) Mark(claim string) {
+	p.PendingRewards = append(p.PendingRewards, Reward{Claim: claim})
+}
+
+func (p *Player) SpendImagination(amount int) bool {
+	if p.Imagination >= amount {
+		p.Imagination -= amount
+		return true
+	}
+	fmt.Println("❌ Недостаточно воображения!")
+	return false
+}
+
+// LoseImagination - штраф: забирает процент от текущего воображения
+func (p *Player) LoseImagination(percent int) int {
+	lost := p.Imagination * percent / 100
+	p.Imagination -= lost
+	if lost > 0 {
+		fmt.Printf("💸 Потеряно %d воображения. Осталось: %d\n", lost, p.Imagination)
+	}
+	return lost
+}
+
+// LoseRandomConsumable - штраф: случайный расходный предмет пропадает из инвентаря
+func (p *Player) LoseRandomConsumable() *items.Item {
+	candidates := make([]int, 0)
+	for i, item := range p.Inventory {
+		if item.IsConsumable() {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	index := candidates[rand.Intn(len(candidates))]
+	item := p.TakeItem(index)
+	fmt.Printf("🎒 Потерян предмет: %s\n", item.Name)
+	return item
+}
+
+func (p *Player) AddMaterial(name string, amount int) {
+	if p.Materials == nil {
+		p.Materials = make(map[string]int)
+	}
+	p.Materials[name] += amount
+	fmt.Printf("🧱 Получено: %s x%d (всего %d)\n", name, amount, p.Materials[name])
+}
+
+// HasMaterials - хватает ли материалов на рецепт
+func (p *Player) HasMaterials(need map[string]int) bool {
+	for name, amount := range need {
+		if p.Materials[name] < amount {
+			return false
+		}
+	}
+	return true
+}
+
+// SpendMaterials - списывает материалы, только если хватает всех
+func (p *Player) SpendMaterials(need map[string]int) bool {
+	if !p.HasMaterials(need) {
+		return false
+	}
+	for name, amount := range need {
+		p.Materials[name] -= amount
+		if p.Materials[name] == 0 {
+			delete(p.Materials, name)
+		}
+	}
+	return true
+}
+
+func (p *Player) IsAlive() bool {
+	return p.HP > 0
+}
+
+func (p *Player) ResetForBattle() {
+	// Сбрасываем временные эффекты
+	p.ActiveEffects = make(map[string]int)
+	// Восстанавливаем здоровье до максимума
+	p.HP = p.GetMaxHP()
+}
+
+func (p *Player) ShowInventory() {
+	fmt.Printf("\n=== 🎒 ИНВЕНТАРЬ (%d/%d) ===\n", len(p.Inventory), p.BagCapacity())
+	if len(p.Inventory) == 0 {
+		fmt.Println("Инвентарь пуст")
+	} else {
+		p.ShowItems(nil)
+	}
+
+	if len(p.Equipped) > 0 {
+		fmt.Println("\n=== ⚔️ ЭКИПИРОВАНО ===")
+		for i, item := range p.Equipped {
+			color := item.GetRarityColor()
+			fmt.Printf("%s%d. %s\033[0m %s\n", color, i+1, item.DisplayName(), item.DurabilityLabel())
+		}
+	}
+
+	items.ShowActiveSets(p.EquippedNames())
+
+	if len(p.Materials) > 0 {
+		fmt.Println("\n=== 🧱 МАТЕРИАЛЫ ===")
+		for name, amount := range p.Materials {
+			fmt.Printf("%s x%d\n", name, amount)
+		}
+	}
+
+	p.ShowStats()
+}
+
+// showComparison - чем предмет лучше или хуже надетого предмета того же типа
+func (p *Player) showComparison(item *items.Item) {
+	for _, equipped := range p.Equipped {
+		if equipped.Slot() == item.Slot() {
+			fmt.Printf("   ⇄ против %s: %s\n", equipped.DisplayName(), items.CompareStats(item, equipped))
+			return
+		}
+	}
+	fmt.Printf("   ⇄ ничего такого не надето: %s\n", items.CompareStats(item, nil))
+}
+
+func (p *Player) ShowStats() {
+	fmt.Printf("\n❤️ Здоровье: %d/%d\n", p.HP, p.GetMaxHP())
+	fmt.Printf("⚔️ Сила: %d (базовая: %d", p.GetStrength(), p.BaseStrength)
+	if len(p.Equipped) > 0 {
+		fmt.Printf(" + предметы")
+	}
+	if bonus, ok := p.ActiveEffects["strength_bonus"]; ok {
+		fmt.Printf(" +%d бонус", bonus)
+	}
+	fmt.Printf(")\n")
+	if bonus := (&items.Item{Effect: p.EquipmentBonuses()}).BonusDescription(); bonus != "" {
+		fmt.Printf("🎯 Бонусы снаряжения: %s\n", bonus)
+	}
+	fmt.Printf("✨ Воображение: %d\n", p.Imagination)
+	fmt.Printf("🏆 Побед: %d\n", p.Wins)
+}
@@ -0,0 +1,351 @@
+package pvp
+
+import (
+	"bufio"
+	"fmt"
+	"game/combat"
+	"game/player"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Friendly - товарищеские бои: вызовы по нику и приватные лобби с кодом. Без рейтинга и наград
+func (c *PvPClient) Friendly(p *player.Player) {
+	reader := bufio.NewReader(os.Stdin)
+	c.playerName = p.Name
+	for {
+		fmt.Println("\n=== 🤝 ТОВАРИЩЕСКИЕ БОИ ===")
+		fmt.Println("1. Вызвать игрока на бой")
+		fmt.Println("2. Мои вызовы")
+		fmt.Println("3. Создать приватное лобби")
+		fmt.Println("4. Войти в лобби по коду")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			c.sendChallenge(p, reader)
+		case "2":
+			c.challenges(p, reader)
+		case "3":
+			c.createLobby(p, reader)
+		case "4":
+			c.joinLobby(p, reader)
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+// playerStats - характеристики для заявки на бой: Имя|HP|MaxHP|Сила|номера надетого снаряжения
+func playerStats(p *player.Player) string {
+	return fmt.Sprintf("%s|%d|%d|%d|%s", p.Name, p.HP, p.GetMaxHP(), p.GetStrength(), strings.Join(wornGear(p), ","))
+}
+
+// wornGear - номера надетых целых экземпляров, известных серверу: силу и здоровье бойца
+// сервер считает только по ним
+func wornGear(p *player.Player) []string {
+	ids := make([]string, 0, len(p.Equipped))
+	for _, item := range p.Equipped {
+		if item.ServerID != "" && !item.IsBroken() {
+			ids = append(ids, item.ServerID)
+		}
+	}
+	return ids
+}
+
+func askYes(reader *bufio.Reader, question string) bool {
+	fmt.Print(question + " (да/нет): ")
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "да" || input == "д" || input == "yes"
+}
+
+func askRules(reader *bufio.Reader) combat.MatchRules {
+	var rules combat.MatchRules
+	rules.NormalizeHP = askYes(reader, fmt.Sprintf("Выровнять здоровье обоим до %d?", combat.NormalizedHP))
+	rules.NoItems = askYes(reader, "Запретить предметы?")
+	fmt.Printf("Лимит раундов, до %d (Enter - без лимита): ", combat.MaxRoundLimit)
+	input, _ := reader.ReadString('\n')
+	if n, err := strconv.Atoi(strings.TrimSpace(input)); err == nil && n > 0 {
+		rules.RoundLimit = min(n, combat.MaxRoundLimit)
+	}
+	return rules
+}
+
+func (c *PvPClient) sendChallenge(p *player.Player, reader *bufio.Reader) {
+	fmt.Print("Кого вызвать (ник): ")
+	opponent, _ := reader.ReadString('\n')
+	opponent = strings.TrimSpace(opponent)
+	if opponent == "" {
+		return
+	}
+	rules := askRules(reader)
+
+	answer, err := c.post("/challenge/send", fmt.Sprintf("%s|%s|%s", playerStats(p), opponent, rules))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch {
+	case answer == "error:self":
+		fmt.Println("❌ Нельзя вызвать самого себя")
+	case answer == "error:bot_name":
+		fmt.Println(botNameText)
+	case answer == "error:busy":
+		fmt.Println("❌ Вы уже в бою")
+	case answer == "error:shutdown":
+		fmt.Println("❌ Сервер останавливается")
+	case strings.HasPrefix(answer, "ok:"):
+		id := c.takeToken(strings.TrimPrefix(answer, "ok:"))
+		fmt.Printf("📨 Вызов отправлен игроку %s (%s)\n", opponent, rules.Describe())
+		c.waitInvite(p, "/challenge/status", "/challenge/decline", id, rules)
+	default:
+		fmt.Println("❌ Не удалось отправить вызов:", answer)
+	}
+}
+
+// challenges - входящие вызовы с ответом и состояние отправленных
+func (c *PvPClient) challenges(p *player.Player, reader *bufio.Reader) {
+	answer, err := c.get("/challenge/list?player=" + url.QueryEscape(p.Name))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	states := map[string]string{
+		"pending":   "⏳ ждёт ответа",
+		"accepted":  "✅ принят",
+		"declined":  "❌ отклонён",
+		"cancelled": "↩️ отменён",
+		"expired":   "⌛ истёк",
+	}
+	incoming := make([][]string, 0)
+	fmt.Println("\n=== 📨 ВЫЗОВЫ ===")
+	for _, line := range strings.Split(strings.TrimSpace(answer), "\n") {
+		fields := strings.Split(line, "|")
+		switch {
+		case fields[0] == "in" && len(fields) >= 5:
+			incoming = append(incoming, fields)
+			fmt.Printf("%d. ⚔️ %s вызывает вас: %s (осталось %s сек.)\n",
+				len(incoming), fields[2], combat.ParseRules(fields[3]).Describe(), fields[4])
+		case fields[0] == "out" && len(fields) >= 6:
+			fmt.Printf("   ➡️ ваш вызов игроку %s: %s\n", fields[2], states[fields[3]])
+		}
+	}
+	if len(incoming) == 0 {
+		fmt.Println("Входящих вызовов нет")
+		return
+	}
+
+	fmt.Print("Номер вызова (0 - назад): ")
+	input, _ := reader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || choice < 1 || choice > len(incoming) {
+		return
+	}
+	id := incoming[choice-1][1]
+	fmt.Print("1 - принять, 2 - отклонить: ")
+	input, _ = reader.ReadString('\n')
+	switch strings.TrimSpace(input) {
+	case "1":
+		answer, err := c.post("/challenge/accept", id+"|"+playerStats(p))
+		if err != nil {
+			fmt.Println("⚠️ Сервер недоступен:", err)
+			return
+		}
+		c.startInvited(p, answer)
+	case "2":
+		if answer, err := c.post("/challenge/decline", id+"|"+p.Name); err == nil && answer == "ok" {
+			fmt.Println("Вызов отклонён")
+		} else {
+			fmt.Println("❌ На вызов уже ответили или он истёк")
+		}
+	}
+}
+
+func (c *PvPClient) createLobby(p *player.Player, reader *bufio.Reader) {
+	rules := askRules(reader)
+	answer, err := c.post("/lobby/create", fmt.Sprintf("%s|%s", playerStats(p), rules))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch {
+	case answer == "error:busy":
+		fmt.Println("❌ Вы уже в бою")
+	case answer == "error:shutdown":
+		fmt.Println("❌ Сервер останавливается")
+	case answer == "error:bot_name":
+		fmt.Println(botNameText)
+	case strings.HasPrefix(answer, "ok:"):
+		code := c.takeToken(strings.TrimPrefix(answer, "ok:"))
+		fmt.Printf("🔐 Лобби создано! Код для друга: %s (%s)\n", code, rules.Describe())
+		c.waitInvite(p, "/lobby/status", "/lobby/close", code, rules)
+	default:
+		fmt.Println("❌ Не удалось создать лобби:", answer)
+	}
+}
+
+func (c *PvPClient) joinLobby(p *player.Player, reader *bufio.Reader) {
+	fmt.Print("Код лобби: ")
+	code, _ := reader.ReadString('\n')
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return
+	}
+	answer, err := c.post("/lobby/join", code+"|"+playerStats(p))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	c.startInvited(p, answer)
+}
+
+// startInvited - разбирает ответ на принятие вызова или вход в лобби и начинает бой
+func (c *PvPClient) startInvited(p *player.Player, answer string) {
+	switch answer {
+	case "error:not_found":
+		fmt.Println("❌ Приглашение не найдено или уже закрыто")
+		return
+	case "error:expired":
+		fmt.Println("❌ Вызов истёк")
+		return
+	case "error:answered", "error:full":
+		fmt.Println("❌ Приглашение уже принято")
+		return
+	case "error:self":
+		fmt.Println("❌ Это ваше собственное лобби")
+		return
+	case "error:busy":
+		fmt.Println("❌ Кто-то из вас уже в другом бою")
+		return
+	case "error:bot_name":
+		fmt.Println(botNameText)
+		return
+	}
+
+	lines := strings.Split(answer, "\n")
+	if len(lines) < 3 || !strings.HasPrefix(lines[0], "rules:") || !strings.HasPrefix(lines[1], "match:") ||
+		!strings.HasPrefix(lines[2], "token:") {
+		fmt.Println("❌ Неожиданный ответ сервера:", answer)
+		return
+	}
+	rulesLine, matchLine := lines[0], lines[1]
+	c.token = strings.TrimPrefix(lines[2], "token:")
+	c.friendlyBattle(p, matchLine, combat.ParseRules(strings.TrimPrefix(rulesLine, "rules:")))
+}
+
+// takeToken - разбирает ответ ID|токен на создание приглашения: токен нужен в бою, ID возвращается
+func (c *PvPClient) takeToken(answer string) string {
+	id, token, _ := strings.Cut(answer, "|")
+	c.token = token
+	return id
+}
+
+// waitInvite - ждёт, пока вызов примут или в лобби войдёт гость. Enter отменяет приглашение.
+// Ввод читается одним слушателем на ожидание и бой, чтобы ни одна строка не потерялась
+func (c *PvPClient) waitInvite(p *player.Player, statusPath, cancelPath, id string, rules combat.MatchRules) {
+	fmt.Println("⏳ Ожидание соперника... (Enter для отмены)")
+	c.running = true
+	c.listening = true
+	c.startInputListener()
+	defer func() { c.listening = false }()
+
+	statusURL := fmt.Sprintf("%s?id=%s&player=%s", statusPath, url.QueryEscape(id), url.QueryEscape(p.Name))
+	lastInfo := time.Now()
+	for {
+		select {
+		case <-c.inputCh:
+			answer, err := c.post(cancelPath, id+"|"+p.Name)
+			if err == nil && answer == "ok" {
+				c.stopListening("❌ Приглашение отменено")
+				return
+			}
+			// Соперник успел принять приглашение - бой начинается
+			if status, err := c.get(statusURL); err == nil && strings.HasPrefix(status, "match:") {
+				fmt.Println("⚔️ Соперник уже принял приглашение, отменить не получилось")
+				c.friendlyBattle(p, status, rules)
+				c.stopListening("")
+				return
+			}
+			c.stopListening("❌ Приглашение закрыто")
+			return
+		default:
+		}
+
+		status, err := c.get(statusURL)
+		if err != nil {
+			time.Sleep(2 * time.Second)
+			continue
+		}
+		kind, rest, _ := strings.Cut(status, ":")
+		switch kind {
+		case "pending":
+			if time.Since(lastInfo) >= 15*time.Second {
+				lastInfo = time.Now()
+				fmt.Printf("⏳ Ждём соперника, приглашение действует ещё %s сек. (Enter для отмены)\n", rest)
+			}
+			time.Sleep(2 * time.Second)
+			continue
+		case "match":
+			c.friendlyBattle(p, status, rules)
+			c.stopListening("")
+		case "declined":
+			c.stopListening("❌ Вызов отклонён")
+		case "expired":
+			c.stopListening("⌛ Никто не ответил - приглашение истекло")
+		default:
+			c.stopListening("❌ Приглашение закрыто")
+		}
+		return
+	}
+}
+
+// stopListening - завершает слушатель ввода: он ждёт ещё одну строку, поэтому просим нажать Enter
+func (c *PvPClient) stopListening(message string) {
+	if message != "" {
+		fmt.Println("\n" + message)
+	}
+	c.running = false
+	fmt.Println("Нажмите Enter, чтобы продолжить")
+	<-c.inputCh
+}
+
+// friendlyBattle - товарищеский бой по строке матча от сервера
+func (c *PvPClient) friendlyBattle(p *player.Player, matchLine string, rules combat.MatchRules) {
+	fields := strings.Split(strings.TrimPrefix(matchLine, "match:"), "|")
+	if len(fields) < 5 {
+		fmt.Println("❌ Неожиданный ответ сервера:", matchLine)
+		return
+	}
+	c.rememberMatch(fields)
+	fmt.Printf("\n✅ Товарищеский бой с %s (❤️ %s/%s, ⚔️ %s)\n", fields[1], fields[2], fields[3], fields[4])
+	fmt.Printf("📜 Правила: %s. Рейтинг и награды не меняются\n", rules.Describe())
+
+	p.HP = p.GetMaxHP()
+	if rules.NormalizeHP {
+		p.HP = combat.NormalizedHP
+	}
+	c.running = true
+	result := c.startBattle(p)
+	c.running = false
+	clearSession(p.Name)
+
+	switch result {
+	case "win":
+		fmt.Println("🏆 Победа в товарищеском бою!")
+	case "loss", "exit":
+		fmt.Println("💀 Поражение в товарищеском бою")
+	case "draw":
+		fmt.Println("🤝 Ничья")
+	}
+	p.HP = p.GetMaxHP()
+	p.WearEquipment()
+}
@@ -0,0 +1,373 @@
+package pvp
+
+import (
+	"bufio"
+	"fmt"
+	"game/player"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var cupFormats = map[string]string{
+	"single": "олимпийская",
+	"double": "двойное выбывание",
+	"swiss":  "швейцарская",
+}
+
+var cupStates = map[string]string{
+	"signup":    "📝 идёт запись",
+	"running":   "⚔️ идёт",
+	"finished":  "🏁 завершён",
+	"cancelled": "❌ отменён",
+}
+
+// Cups - PvP-турниры на сервере: запись, сетка и бои
+func (c *PvPClient) Cups(p *player.Player) {
+	reader := bufio.NewReader(os.Stdin)
+	c.playerName = p.Name
+	for {
+		fmt.Println("\n=== 🏆 PvP-ТУРНИРЫ ===")
+		fmt.Println("1. Список турниров")
+		fmt.Println("2. Создать турнир")
+		fmt.Println("3. Записаться в турнир")
+		fmt.Println("4. Сетка турнира")
+		fmt.Println("5. Играть в своём турнире")
+		fmt.Println("6. Отказаться от участия")
+		fmt.Println("0. Назад")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			c.listCups()
+		case "2":
+			c.createCup(p, reader)
+		case "3":
+			if id := c.pickCup(reader); id != "" {
+				c.joinCup(p, id, reader)
+			}
+		case "4":
+			if id := c.pickCup(reader); id != "" {
+				c.showBracket(id)
+			}
+		case "5":
+			if id := c.pickCup(reader); id != "" {
+				c.playCup(p, id, reader)
+			}
+		case "6":
+			if id := c.pickCup(reader); id != "" {
+				c.leaveCup(p, id)
+			}
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+// listCups - печатает турниры и возвращает их ID по порядку
+func (c *PvPClient) listCups() []string {
+	answer, err := c.get("/cup/list")
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return nil
+	}
+
+	ids := make([]string, 0)
+	fmt.Println("\n=== 🏆 ТУРНИРЫ ===")
+	for _, line := range strings.Split(strings.TrimSpace(answer), "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 8 {
+			continue
+		}
+		ids = append(ids, fields[0])
+		fmt.Printf("%d. «%s» (%s), %s, участников %s/%s, организатор %s\n",
+			len(ids), fields[1], cupFormats[fields[2]], cupStates[fields[3]], fields[4], fields[5], fields[7])
+		if fields[3] == "signup" {
+			ends, _ := strconv.ParseInt(fields[6], 10, 64)
+			fmt.Printf("   ⏳ старт через %d сек.\n", int(time.Until(time.Unix(ends, 0)).Seconds()))
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Println("Турниров пока нет - создайте первый!")
+	}
+	return ids
+}
+
+func (c *PvPClient) pickCup(reader *bufio.Reader) string {
+	ids := c.listCups()
+	if len(ids) == 0 {
+		return ""
+	}
+	fmt.Print("Номер турнира (0 - отмена): ")
+	input, _ := reader.ReadString('\n')
+	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || choice < 1 || choice > len(ids) {
+		return ""
+	}
+	return ids[choice-1]
+}
+
+func (c *PvPClient) createCup(p *player.Player, reader *bufio.Reader) {
+	fmt.Print("Название турнира: ")
+	title, _ := reader.ReadString('\n')
+
+	fmt.Println("Система: 1 - олимпийская, 2 - двойное выбывание, 3 - швейцарская")
+	fmt.Print("Выбор: ")
+	input, _ := reader.ReadString('\n')
+	format := map[string]string{"1": "single", "2": "double", "3": "swiss"}[strings.TrimSpace(input)]
+	if format == "" {
+		fmt.Println("Неверный ввод!")
+		return
+	}
+
+	fmt.Print("Сколько минут идёт запись (Enter - 5): ")
+	input, _ = reader.ReadString('\n')
+	minutes, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || minutes <= 0 {
+		minutes = 5
+	}
+	fmt.Print("Максимум участников, от 2 до 32 (Enter - 8): ")
+	input, _ = reader.ReadString('\n')
+	maxPlayers, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil {
+		maxPlayers = 8
+	}
+
+	title = strings.ReplaceAll(strings.TrimSpace(title), "|", "/")
+	answer, err := c.post("/cup/create", fmt.Sprintf("%s|%s|%s|%d|%d", p.Name, title, format, minutes*60, maxPlayers))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch answer {
+	case "error:signup":
+		fmt.Println("❌ Запись не может длиться дольше часа")
+		return
+	case "error:players":
+		fmt.Println("❌ Участников может быть от 2 до 32")
+		return
+	case "error:shutdown":
+		fmt.Println("❌ Сервер останавливается")
+		return
+	}
+	if !strings.HasPrefix(answer, "ok:") {
+		fmt.Println("❌ Не удалось создать турнир:", answer)
+		return
+	}
+	id := strings.TrimPrefix(answer, "ok:")
+	fmt.Printf("✅ Турнир создан! Запись открыта %d мин.\n", minutes)
+	c.joinCup(p, id, reader)
+}
+
+func (c *PvPClient) joinCup(p *player.Player, id string, reader *bufio.Reader) {
+	answer, err := c.post("/cup/join", id+"|"+playerStats(p))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch {
+	case strings.HasPrefix(answer, "ok"):
+		fields := strings.Split(answer, "|")
+		fee := ""
+		if len(fields) >= 3 {
+			fee = fields[1]
+			saveCupToken(p.Name, id, fields[2])
+		}
+		fmt.Printf("✅ Вы записаны в турнир! Взнос %s воображения списан с серверного кошелька\n", fee)
+	case answer == "error:not_enough":
+		fmt.Println("❌ Не хватает воображения на серверном кошельке для взноса")
+		return
+	case answer == "error:exists":
+		fmt.Println("ℹ️ Вы уже записаны в этот турнир")
+	case answer == "error:closed":
+		fmt.Println("❌ Запись в этот турнир закрыта")
+		return
+	case answer == "error:full":
+		fmt.Println("❌ В турнире нет свободных мест")
+		return
+	case answer == "error:bot_name":
+		fmt.Println(botNameText)
+		return
+	default:
+		fmt.Println("❌ Турнир не найден")
+		return
+	}
+
+	fmt.Print("Ждать начала и играть? (да/нет): ")
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	if input == "да" || input == "д" || input == "yes" {
+		c.playCup(p, id, reader)
+	}
+}
+
+func (c *PvPClient) leaveCup(p *player.Player, id string) {
+	answer, err := c.post("/cup/leave", id+"|"+p.Name)
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	switch answer {
+	case "ok":
+		fmt.Println("✅ Вы отказались от участия, взнос вернётся в серверный кошелёк")
+	case "error:closed":
+		fmt.Println("❌ Турнир уже начался - можно только сдаться в бою")
+	default:
+		fmt.Println("❌ Вы не записаны в этот турнир")
+	}
+}
+
+// showBracket - участники, пары по турам и призовые места
+func (c *PvPClient) showBracket(id string) {
+	answer, err := c.get("/cup/bracket?id=" + url.QueryEscape(id))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+
+	groups := map[string]string{"W": "", "L": " (нижняя сетка)", "F": " (финал)", "S": ""}
+	lastRound := ""
+	swiss := false
+	for _, line := range strings.Split(strings.TrimSpace(answer), "\n") {
+		kind, rest, _ := strings.Cut(line, ":")
+		fields := strings.Split(rest, "|")
+		switch {
+		case kind == "cup" && len(fields) >= 8:
+			fmt.Printf("\n=== 🏆 «%s» - %s, %s ===\n", fields[1], cupFormats[fields[2]], cupStates[fields[3]])
+			swiss = fields[2] == "swiss"
+			if fields[2] == "swiss" && fields[5] != "0" {
+				fmt.Printf("Тур %s из %s. ", fields[4], fields[5])
+			} else if fields[4] != "0" {
+				fmt.Printf("Тур %s. ", fields[4])
+			}
+			fmt.Printf("Призовой фонд: %s воображения\n", fields[7])
+			fmt.Println("\nУчастники:")
+		case kind == "entrant" && len(fields) >= 8:
+			extra := ""
+			if swiss {
+				extra = fmt.Sprintf(", очков %s", fields[6])
+			} else if fields[7] != "0" {
+				extra = fmt.Sprintf(", выбыл в туре %s", fields[7])
+			}
+			fmt.Printf("  #%-2s %-16s 🏅 %s  %s-%s-%s%s\n",
+				fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], extra)
+		case kind == "pair" && len(fields) >= 7:
+			if fields[0] != lastRound {
+				lastRound = fields[0]
+				fmt.Printf("\nТур %s:\n", lastRound)
+			}
+			switch {
+			case fields[3] == "":
+				fmt.Printf("  %s - пропуск тура%s\n", fields[2], groups[fields[1]])
+			case fields[6] == "1":
+				fmt.Printf("  %s vs %s%s → 🏆 %s\n", fields[2], fields[3], groups[fields[1]], cupWinnerText(fields[4]))
+			default:
+				fmt.Printf("  %s vs %s%s - идёт бой\n", fields[2], fields[3], groups[fields[1]])
+			}
+		case kind == "place" && len(fields) >= 3:
+			if fields[0] == "1" {
+				fmt.Println("\nИтоги:")
+			}
+			prize := ""
+			if fields[2] != "0" {
+				prize = fmt.Sprintf(" - приз %s воображения", fields[2])
+			}
+			fmt.Printf("  %s. %s%s\n", fields[0], fields[1], prize)
+		case kind == "error":
+			fmt.Println("❌ Турнир не найден")
+		}
+	}
+}
+
+func cupWinnerText(winner string) string {
+	if winner == "" {
+		return "ничья"
+	}
+	return winner
+}
+
+// playCup - ждёт своих боёв в турнире и проводит их, пока игрок не выбыл или турнир не закончился
+func (c *PvPClient) playCup(p *player.Player, id string, reader *bufio.Reader) {
+	c.playerName = p.Name
+	c.token = loadCupToken(p.Name, id)
+	for {
+		status, err := c.get(fmt.Sprintf("/cup/status?id=%s&player=%s", url.QueryEscape(id), url.QueryEscape(p.Name)))
+		if err != nil {
+			fmt.Println("⚠️ Сервер недоступен:", err)
+			return
+		}
+
+		kind, rest, _ := strings.Cut(status, ":")
+		fields := strings.Split(rest, "|")
+		switch kind {
+		case "match":
+			if len(fields) < 5 {
+				fmt.Println("❌ Неожиданный ответ сервера:", status)
+				return
+			}
+			c.rememberMatch(fields)
+			fmt.Printf("\n⚔️ Ваш соперник в туре: %s (❤️ %s/%s, ⚔️ %s)\n", fields[1], fields[2], fields[3], fields[4])
+			c.running = true
+			result := c.startBattle(p)
+			c.running = false
+			clearSession(p.Name)
+			switch result {
+			case "win":
+				fmt.Println("🏆 Победа в бою турнира!")
+			case "loss", "exit":
+				fmt.Println("💀 Бой турнира проигран")
+			case "draw":
+				fmt.Println("🤝 Ничья")
+			}
+			// Следующий бой турнира начинается с полным здоровьем
+			p.HP = p.GetMaxHP()
+			p.WearEquipment()
+			continue
+		case "signup":
+			if len(fields) >= 3 {
+				fmt.Printf("\n📝 Идёт запись: %s/%s участников, старт через %s сек.\n", fields[0], fields[1], fields[2])
+			}
+		case "wait":
+			fmt.Printf("\n⏳ Тур %s: ждём, пока закончатся остальные бои\n", rest)
+		case "eliminated":
+			fmt.Printf("\n💀 Вы выбыли из турнира в туре %s. Сетку можно смотреть и дальше\n", rest)
+			return
+		case "finished":
+			if len(fields) >= 2 {
+				fmt.Printf("\n🏁 Турнир завершён! Ваше место: %s\n", fields[0])
+				if fields[1] != "0" {
+					fmt.Printf("✨ Приз %s воображения зачислен в серверный кошелёк\n", fields[1])
+				}
+			}
+			return
+		case "cancelled":
+			fmt.Println("\n❌ Турнир отменён: не набралось участников. Взнос вернётся в серверный кошелёк")
+			return
+		default:
+			fmt.Println("❌ Вы не участвуете в этом турнире")
+			return
+		}
+
+		fmt.Print("Enter - проверить снова, 0 - выйти (бой начнётся и без вас, опоздание дольше минуты - поражение): ")
+		input, _ := reader.ReadString('\n')
+		if strings.TrimSpace(input) == "0" {
+			return
+		}
+	}
+}
+
+func (c *PvPClient) post(path, data string) (string, error) {
+	resp, err := c.httpClient.Post(c.serverURL+path, "text/plain", strings.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return strings.TrimSpace(string(body)), err
+}
@@ -1,484 +1,661 @@
-package pvp
-
-import (
-	"bufio"
-	"crypto/tls"
-	"fmt"
-	"game/player"
-	"io"
-	"net/http"
-	"net/url"
-	"os"
-	"strconv"
-	"strings"
-	"time"
-	"sync"
-)
-
-type PvPClient struct {
-	serverURL     string
-	httpClient    *http.Client
-	matchID       string
-	playerName    string
-	running       bool
-	lastTurnOwner string
-	inputCh       chan string
-	done          chan struct{}
-	chatOpen      bool
-	chatLastCount int
-	chatMu        sync.Mutex
-}
-
-func NewPvPClient(serverURL string) *PvPClient {
-	serverURL = strings.TrimRight(serverURL, "/")
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-	}
-	return &PvPClient{
-		serverURL:  serverURL,
-		httpClient: client,
-		inputCh:    make(chan string),
-		done:       make(chan struct{}),
-	}
-}
-
-func (c *PvPClient) Play(p *player.Player) string {
-	c.playerName = p.Name
-	c.running = true
-	fmt.Println("\n=== ПОИСК PvP СОПЕРНИКА ===")
-
-	data := fmt.Sprintf("%s|%d|%d|%d", p.Name, p.HP, p.GetMaxHP(), p.GetStrength())
-	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/join", c.serverURL), "text/plain", strings.NewReader(data))
-	if err != nil {
-		fmt.Println("❌ Ошибка подключения к PvP-серверу:", err)
-		return "error"
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	response := string(body)
-
-	if strings.HasPrefix(response, "queued") {
-		fmt.Println("⏳ Ожидание противника... (Enter для отмены)")
-		cancelCh := make(chan bool)
-		go c.waitForCancel(cancelCh)
-
-		matchFound := false
-		for !matchFound && c.running {
-			select {
-			case <-cancelCh:
-				fmt.Println("\n❌ Поиск отменен")
-				return "cancelled"
-			default:
-				matchID, opponent := c.checkMatchStatus()
-				if matchID != "" {
-					c.matchID = matchID
-					fmt.Printf("\n✅ ПРОТИВНИК НАЙДЕН!\n%s\n", opponent)
-					matchFound = true
-				} else {
-					time.Sleep(1 * time.Second)
-				}
-			}
-		}
-	} else if strings.HasPrefix(response, "match:") {
-		parts := strings.Split(response, ":")
-		if len(parts) == 2 {
-			matchParts := strings.Split(parts[1], "|")
-			if len(matchParts) >= 5 {
-				c.matchID = matchParts[0]
-				fmt.Printf("\n✅ ПРОТИВНИК НАЙДЕН!\n")
-				fmt.Printf("👤 Имя: %s\n❤️ Здоровье: %s/%s\n⚔️ Сила: %s\n",
-					matchParts[1], matchParts[2], matchParts[3], matchParts[4])
-			}
-		}
-	}
-
-	result := c.startBattle(p)
-
-	// c.matchID = ""        // сбрасываем ТОЛЬКО после выхода
-	// c.lastTurnOwner = ""  // заодно
-	c.running = false
-
-	return result
-}
-
-func (c *PvPClient) waitForCancel(cancelCh chan<- bool) {
-	reader := bufio.NewReader(os.Stdin)
-	reader.ReadString('\n')
-	cancelCh <- true
-	c.running = false
-}
-
-func (c *PvPClient) checkMatchStatus() (string, string) {
-	resp, err := c.httpClient.Get(fmt.Sprintf("%s/pvp/status?player=%s", c.serverURL, c.playerName))
-	if err != nil {
-		return "", ""
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	status := string(body)
-
-	if strings.HasPrefix(status, "match:") {
-		parts := strings.Split(status, ":")
-		if len(parts) == 2 {
-			matchParts := strings.Split(parts[1], "|")
-			if len(matchParts) >= 2 {
-				info := fmt.Sprintf("%s (❤️ %s/%s, ⚔️ %s)",
-					matchParts[1], matchParts[2], matchParts[3], matchParts[4])
-				return matchParts[0], info
-			}
-		}
-	}
-	return "", ""
-}
-
-func (c *PvPClient) startBattle(p *player.Player) string {
-	c.done = make(chan struct{})
-	c.chatLastCount = 0
-	c.startChatListener()
-	c.startInputListener()
-
-	fmt.Println("\n=== БОЙ НАЧИНАЕТСЯ ===")
-	var isMyTurn bool
-
-	for c.running {
-		resp, err := c.httpClient.Get(fmt.Sprintf("%s/pvp/battle?matchId=%s&player=%s",
-			c.serverURL, c.matchID, c.playerName))
-		if err != nil {
-			time.Sleep(1 * time.Second)
-			continue
-		}
-		if resp.StatusCode == http.StatusNotFound {
-			fmt.Println("\n⚠️ Матч больше не существует. Бой завершён.")
-			c.running = false
-			close(c.done)
-			return "error"
-		}
-
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		status := string(body)
-
-		if strings.HasPrefix(status, "finished:") {
-			c.running = false
-			close(c.done)
-
-			result := "error"
-			parts := strings.Split(status, ":")
-			if len(parts) == 2 {
-				result = parts[1]
-			}
-
-			c.matchID = ""
-			c.lastTurnOwner = ""
-
-			return result
-		}
-
-		if p.HP <= 0 {
-			c.running = false
-			close(c.done)
-			fmt.Println("\n💀 Вы погибли. Бой завершен.")
-			return "loss"
-		}
-
-		if strings.HasPrefix(status, "round_result:") {
-			c.printRoundResult(status, p)
-			time.Sleep(300 * time.Millisecond)
-			continue
-		}
-
-		if strings.HasPrefix(status, "wait_turn:") {
-			parts := strings.Split(status, ":")
-			if len(parts) == 2 {
-				turnPlayer := parts[1]
-				isMyTurn = turnPlayer == c.playerName
-				if c.lastTurnOwner != turnPlayer {
-					c.lastTurnOwner = turnPlayer
-					if isMyTurn {
-						fmt.Println("\n⚔️ ВАШ ХОД!")
-						fmt.Println("1 — Открыть чат")
-						fmt.Println("2 — Атака")
-						fmt.Println("3 — Использовать предмет")
-						fmt.Println("4 — Инвентарь")
-						fmt.Print("> ")
-					} else {
-						fmt.Printf("\n⏳ Ожидание хода %s...\n", turnPlayer)
-						fmt.Println("1 — Открыть чат")
-						fmt.Println("4 — Инвентарь")
-						fmt.Print("> ")
-					}
-				}
-			}
-		}
-
-		select {
-		case input := <-c.inputCh:
-			if input == "/exit" {
-				c.StopBattle()
-				return "exit"
-			}
-			c.handleInput(input, isMyTurn, p)
-		default:
-		}
-
-		time.Sleep(200 * time.Millisecond)
-	}
-
-	return "error"
-}
-
-func (c *PvPClient) printRoundResult(status string, p *player.Player) {
-	parts := strings.Split(status, ":")
-	if len(parts) != 2 {
-		return
-	}
-	data := strings.Split(parts[1], "|")
-	if len(data) < 7 {
-		return
-	}
-
-	round, _ := strconv.Atoi(data[0])
-	yourDamage, _ := strconv.Atoi(data[1])
-	yourHPBefore, _ := strconv.Atoi(data[2])
-	yourHPAfter, _ := strconv.Atoi(data[3])
-	damageToYou, _ := strconv.Atoi(data[4])
-	opponentHPBefore, _ := strconv.Atoi(data[5])
-	opponentHPAfter, _ := strconv.Atoi(data[6])
-
-	fmt.Printf("\n=== РЕЗУЛЬТАТ РАУНДА %d ===\n", round)
-	fmt.Println("═══════════════════════════")
-	fmt.Printf("💥 ВЫ нанесли: %d урона\n", yourDamage)
-	fmt.Printf("💔 ВАМ нанесли: %d урона\n", damageToYou)
-	fmt.Println("───────────────────────────")
-	fmt.Printf("❤️ ВАШЕ здоровье: %d → %d\n", yourHPBefore, yourHPAfter)
-	fmt.Printf("❤️ Здоровье ПРОТИВНИКА: %d → %d\n", opponentHPBefore, opponentHPAfter)
-	fmt.Println("═══════════════════════════")
-
-	p.HP = yourHPAfter
-}
-
-func (c *PvPClient) chooseHit() int {
-	fmt.Println("\nКуда атаковать?")
-	fmt.Println("1 — Голова\n2 — Тело\n3 — Ноги")
-	for {
-		select {
-		case <-c.done:
-			return -1
-		case input := <-c.inputCh:
-			if choice, err := strconv.Atoi(input); err == nil && choice >= 1 && choice <= 3 {
-				return choice - 1
-			}
-			fmt.Println("❌ Введите 1-3")
-		}
-	}
-}
-
-func (c *PvPClient) chooseBlock() int {
-	fmt.Println("\nЧто защищать?")
-	fmt.Println("1 — Голова\n2 — Тело\n3 — Ноги")
-	for {
-		select {
-		case <-c.done:
-			return -1
-		case input := <-c.inputCh:
-			if choice, err := strconv.Atoi(input); err == nil && choice >= 1 && choice <= 3 {
-				return choice - 1
-			}
-			fmt.Println("❌ Введите 1-3")
-		}
-	}
-}
-
-func (c *PvPClient) sendChat(playerName, message string) {
-	endpoint := fmt.Sprintf("%s/pvp/chat?matchID=%s&player=%s&msg=%s",
-		c.serverURL, c.matchID, url.QueryEscape(playerName), url.QueryEscape(message))
-	c.httpClient.Get(endpoint)
-}
-
-func (c *PvPClient) openPvPChat() {
-	c.chatMu.Lock()
-	c.chatOpen = true
-	c.chatMu.Unlock()
-
-	defer func() {
-		c.chatMu.Lock()
-		c.chatOpen = false
-		c.chatMu.Unlock()
-		fmt.Println("\n=== ЧАТ ЗАКРЫТ ===")
-	}()
-
-	fmt.Println("\n=== PvP ЧАТ ===")
-	fmt.Println("Введите /back для возврата")
-
-	// Показываем историю
-	resp, err := c.httpClient.Get(fmt.Sprintf(
-		"%s/pvp/chat/history?matchID=%s",
-		c.serverURL, c.matchID,
-	))
-	if err == nil {
-		body, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		raw := strings.TrimSpace(string(body))
-		if raw != "" {
-			fmt.Println("\n--- ИСТОРИЯ ЧАТА ---")
-			fmt.Println(raw)
-
-			lines := strings.Split(raw, "\n")
-
-			c.chatMu.Lock()
-			c.chatLastCount = len(lines)
-			c.chatMu.Unlock()
-		}
-	}
-
-	fmt.Print("> ")
-
-	for c.running {
-		input := <-c.inputCh
-
-		switch input {
-		case "/back":
-			return
-
-		case "/exit":
-			c.StopBattle()
-			return
-
-		default:
-			if strings.TrimSpace(input) != "" {
-				c.sendChat(c.playerName, input)
-			}
-		}
-
-		fmt.Print("> ")
-	}
-}
-
-func (c *PvPClient) useItemInBattle(p *player.Player) {
-	if len(p.Inventory) == 0 {
-		fmt.Println("Инвентарь пуст.")
-		return
-	}
-
-	p.ShowInventory()
-	fmt.Print("Введите номер предмета: ")
-	input := <-c.inputCh
-	idx, err := strconv.Atoi(input)
-	if err != nil || idx < 1 || idx > len(p.Inventory) {
-		fmt.Println("❌ Неверный номер!")
-		return
-	}
-	p.UseItem(idx - 1)
-}
-
-func (c *PvPClient) startInputListener() {
-	go func() {
-		reader := bufio.NewReader(os.Stdin)
-		for c.running {
-			text, err := reader.ReadString('\n')
-			if err != nil {
-				continue
-			}
-			c.inputCh <- strings.TrimSpace(text)
-		}
-	}()
-}
-
-func (c *PvPClient) handleInput(input string, isMyTurn bool, p *player.Player) {
-	if !c.running {
-		fmt.Println("❌ Бой завершён, нельзя вводить команды")
-		return
-	}
-
-	switch input {
-	case "1":
-		c.openPvPChat()
-	case "2":
-		if !isMyTurn {
-			fmt.Println("❌ Сейчас не ваш ход!")
-			return
-		}
-		attack := c.chooseHit()
-		if attack == -1 {
-			return
-		}
-		block := c.chooseBlock()
-		if block == -1 {
-			return
-		}
-		moveData := fmt.Sprintf("%s|%s|%d|%d", c.matchID, c.playerName, attack, block)
-		c.httpClient.Post(fmt.Sprintf("%s/pvp/move", c.serverURL), "text/plain", strings.NewReader(moveData))
-	case "3":
-		if !isMyTurn {
-			fmt.Println("❌ Сейчас не ваш ход!")
-			return
-		}
-		c.useItemInBattle(p)
-	case "4":
-		p.ShowInventory()
-	default:
-		fmt.Println("❌ Неверная команда")
-	}
-}
-
-func (c *PvPClient) startChatListener() {
-	go func() {
-		for c.running {
-			resp, err := c.httpClient.Get(fmt.Sprintf(
-				"%s/pvp/chat/history?matchID=%s",
-				c.serverURL, c.matchID,
-			))
-			if err != nil {
-				time.Sleep(500 * time.Millisecond)
-				continue
-			}
-
-			body, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-
-			raw := strings.TrimSpace(string(body))
-			if raw == "" {
-				time.Sleep(500 * time.Millisecond)
-				continue
-			}
-
-			lines := strings.Split(raw, "\n")
-
-			c.chatMu.Lock()
-
-			if len(lines) > c.chatLastCount {
-				for i := c.chatLastCount; i < len(lines); i++ {
-					line := strings.TrimSpace(lines[i])
-					if line == "" {
-						continue
-					}
-
-					if c.chatOpen {
-						fmt.Printf("\r\033[K%s\n> ", line)
-					} else {
-						fmt.Printf("\n💬 %s\n> ", line)
-					}
-				}
-				c.chatLastCount = len(lines)
-			}
-
-			c.chatMu.Unlock()
-
-			time.Sleep(500 * time.Millisecond)
-		}
-	}()
-}
-
-func (c *PvPClient) StopBattle() {
-	if c.running {
-		c.running = false
-		close(c.done)
-		fmt.Println("\n❌ Вы покинули бой PvP.")
-	}
-}
+package pvp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"game/combat"
+	"game/items"
+	"game/player"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"sync"
+)
+
+type PvPClient struct {
+	serverURL     string
+	httpClient    *http.Client
+	matchID       string
+	token         string // секрет сессии: по нему можно вернуться в бой
+	playerName    string
+	running       bool
+	lastTurnOwner string
+	inputCh       chan string
+	done          chan struct{}
+	chatOpen      bool
+	chatLastCount int
+	chatMu        sync.Mutex
+	listening     bool // ввод уже читается (с экрана ожидания), бою не нужен второй читатель
+}
+
+func NewPvPClient(serverURL string) *PvPClient {
+	serverURL = strings.TrimRight(serverURL, "/")
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+	return &PvPClient{
+		serverURL:  serverURL,
+		httpClient: client,
+		inputCh:    make(chan string),
+		done:       make(chan struct{}),
+	}
+}
+
+func (c *PvPClient) Play(p *player.Player) string {
+	c.playerName = p.Name
+	c.token = ""
+	c.running = true
+	fmt.Println("\n=== ПОИСК PvP СОПЕРНИКА ===")
+
+	data := playerStats(p)
+	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/join", c.serverURL), "text/plain", strings.NewReader(data))
+	if err != nil {
+		fmt.Println("❌ Ошибка подключения к PvP-серверу:", err)
+		return "error"
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	response := string(body)
+
+	if response == "shutdown" {
+		fmt.Println("❌ Сервер останавливается, PvP сейчас недоступно")
+		return "error"
+	}
+
+	if response == "error:bot_name" {
+		fmt.Println(botNameText)
+		return "error"
+	}
+
+	if response == "already_queued" {
+		fmt.Println("❌ Вы уже стоите в очереди (возможно, в другом окне игры)")
+		return "cancelled"
+	}
+
+	if strings.HasPrefix(response, "queued:") {
+		// queued:рейтинг|токен - токен сервер выдаёт только здесь, по нему он узнаёт нас в бою
+		fields := strings.Split(strings.TrimPrefix(response, "queued:"), "|")
+		if len(fields) >= 2 {
+			c.token = fields[1]
+		}
+		fmt.Printf("🏅 Ваш рейтинг: %s. Подбираем соперника вашего уровня, со временем круг поиска расширяется\n", fields[0])
+		fmt.Println("⏳ Ожидание противника... (Enter для отмены)")
+		cancelCh := make(chan bool)
+		go c.waitForCancel(cancelCh)
+
+		matchFound := false
+		lastQueueInfo := time.Time{}
+		for !matchFound && c.running {
+			select {
+			case <-cancelCh:
+				// Соперник мог найтись за мгновение до отмены - тогда бой всё-таки начинается
+				if !c.leaveQueue() {
+					fmt.Println("\n❌ Поиск отменен")
+					return "cancelled"
+				}
+				fmt.Println("\n⚔️ Соперник уже найден, отменить поиск не получилось")
+			default:
+				matchID, opponent, status := c.checkMatchStatus()
+				switch {
+				case matchID != "":
+					matchParts := strings.Split(strings.TrimPrefix(status, "match:"), "|")
+					c.rememberMatch(matchParts)
+					fmt.Printf("\n✅ ПРОТИВНИК НАЙДЕН!\n%s\n", opponent)
+					showBotNotice(matchParts)
+					matchFound = true
+				case status == "not_queued":
+					fmt.Println("\n⚠️ Место в очереди потеряно (нет связи с сервером). Начните поиск заново")
+					return "error"
+				case status == "shutdown":
+					fmt.Println("\n⚠️ Сервер останавливается, поиск прерван")
+					return "error"
+				default:
+					if time.Since(lastQueueInfo) >= 10*time.Second {
+						lastQueueInfo = time.Now()
+						showQueueInfo(status)
+					}
+					time.Sleep(1 * time.Second)
+				}
+			}
+		}
+	} else {
+		fmt.Println("❌ Сервер не принял запись на бой:", response)
+		return "error"
+	}
+
+	result := c.startBattle(p)
+	clearSession(p.Name)
+
+	// c.matchID = ""        // сбрасываем ТОЛЬКО после выхода
+	// c.lastTurnOwner = ""  // заодно
+	c.running = false
+
+	return result
+}
+
+func (c *PvPClient) waitForCancel(cancelCh chan<- bool) {
+	reader := bufio.NewReader(os.Stdin)
+	reader.ReadString('\n')
+	cancelCh <- true
+}
+
+// leaveQueue - сообщает серверу об отмене поиска. true - отменить поздно, матч уже создан
+func (c *PvPClient) leaveQueue() bool {
+	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/leave", c.serverURL), "text/plain", strings.NewReader(c.playerName))
+	if err != nil {
+		// Без опросов сервер сам уберёт нас из очереди
+		return false
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	return strings.TrimSpace(string(body)) == "in_match"
+}
+
+// botNameText - ответ на error:bot_name: сервер не пускает игроков с именем, похожим на бота
+const botNameText = "❌ Имена с 🤖 зарезервированы за ботами сервера"
+
+// showBotNotice - предупреждает, что соперник - бот сервера (восьмое поле строки матча - вид боя)
+func showBotNotice(matchParts []string) {
+	if len(matchParts) >= 8 && matchParts[7] == "bot" {
+		fmt.Println("🤖 Живых соперников не нашлось, с вами сразится бот сервера. Награда будет, рейтинг не изменится")
+	}
+}
+
+// showQueueInfo - место в очереди и примерное ожидание: queued:место|всего|секунд|рейтинг
+func showQueueInfo(status string) {
+	fields := strings.Split(strings.TrimPrefix(status, "queued:"), "|")
+	if !strings.HasPrefix(status, "queued:") || len(fields) < 3 {
+		return
+	}
+	fmt.Printf("⏳ Место в очереди: %s из %s, примерное ожидание: %s сек. (Enter для отмены)\n", fields[0], fields[1], fields[2])
+}
+
+// checkMatchStatus - опрос сервера (он же продлевает место в очереди): ID матча, описание соперника и сырой ответ
+func (c *PvPClient) checkMatchStatus() (string, string, string) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("%s/pvp/status?player=%s", c.serverURL, url.QueryEscape(c.playerName)))
+	if err != nil {
+		return "", "", ""
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	status := string(body)
+
+	if strings.HasPrefix(status, "match:") {
+		parts := strings.Split(status, ":")
+		if len(parts) == 2 {
+			matchParts := strings.Split(parts[1], "|")
+			if len(matchParts) >= 2 {
+				info := fmt.Sprintf("%s (❤️ %s/%s, ⚔️ %s)",
+					matchParts[1], matchParts[2], matchParts[3], matchParts[4])
+				if len(matchParts) >= 7 {
+					info += fmt.Sprintf("\n🏅 Рейтинг: %s (ваш: %s)", matchParts[5], matchParts[6])
+				}
+				return matchParts[0], info, status
+			}
+		}
+	}
+	return "", "", status
+}
+
+func (c *PvPClient) startBattle(p *player.Player) string {
+	c.done = make(chan struct{})
+	c.chatLastCount = 0
+	c.startChatListener()
+	if !c.listening {
+		c.startInputListener()
+	}
+
+	fmt.Println("\n=== БОЙ НАЧИНАЕТСЯ ===")
+	var isMyTurn bool
+
+	for c.running {
+		resp, err := c.httpClient.Get(fmt.Sprintf("%s/pvp/battle?matchId=%s&player=%s&token=%s",
+			c.serverURL, c.matchID, url.QueryEscape(c.playerName), url.QueryEscape(c.token)))
+		if err != nil {
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			resp.Body.Close()
+			fmt.Println("\n⚠️ Сервер не узнал сессию этого боя. Бой прерван.")
+			c.running = false
+			close(c.done)
+			return "error"
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			fmt.Println("\n⚠️ Матч больше не существует. Бой завершён.")
+			c.running = false
+			close(c.done)
+			return "error"
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		status := string(body)
+
+		if status == "shutdown" {
+			c.running = false
+			close(c.done)
+			fmt.Println("\n⚠️ Сервер останавливается. Бой прерван, рейтинг не изменился.")
+			return "error"
+		}
+
+		if status == "finished:abandoned" {
+			c.running = false
+			close(c.done)
+			fmt.Println("\n⚠️ Бой закрыт сервером: оба игрока долго не отвечали.")
+			return "error"
+		}
+
+		if strings.HasPrefix(status, "finished:") {
+			c.running = false
+			close(c.done)
+
+			result := "error"
+			parts := strings.Split(status, ":")
+			if len(parts) == 2 {
+				result = parts[1]
+			}
+
+			c.matchID = ""
+			c.lastTurnOwner = ""
+
+			return result
+		}
+
+		if p.HP <= 0 {
+			c.running = false
+			close(c.done)
+			fmt.Println("\n💀 Вы погибли. Бой завершен.")
+			return "loss"
+		}
+
+		if strings.HasPrefix(status, "round_result:") {
+			c.printRoundResult(status, p)
+			time.Sleep(300 * time.Millisecond)
+			continue
+		}
+
+		if strings.HasPrefix(status, "opponent_away:") {
+			left := strings.TrimPrefix(status, "opponent_away:")
+			if c.lastTurnOwner != "away" {
+				c.lastTurnOwner = "away"
+				fmt.Printf("\n📡 Соперник потерял связь. Если не вернётся за %s сек., победа ваша\n", left)
+			}
+		}
+
+		if strings.HasPrefix(status, "wait_turn:") {
+			parts := strings.Split(status, ":")
+			if len(parts) == 2 {
+				turnPlayer := parts[1]
+				isMyTurn = turnPlayer == c.playerName
+				if c.lastTurnOwner != turnPlayer {
+					c.lastTurnOwner = turnPlayer
+					if isMyTurn {
+						fmt.Println("\n⚔️ ВАШ ХОД!")
+						fmt.Println("1 — Открыть чат")
+						fmt.Println("2 — Атака")
+						fmt.Println("3 — Использовать предмет")
+						fmt.Println("4 — Инвентарь")
+						fmt.Print("> ")
+					} else {
+						fmt.Printf("\n⏳ Ожидание хода %s...\n", turnPlayer)
+						fmt.Println("1 — Открыть чат")
+						fmt.Println("4 — Инвентарь")
+						fmt.Print("> ")
+					}
+				}
+			}
+		}
+
+		select {
+		case input := <-c.inputCh:
+			if input == "/exit" {
+				c.leaveBattle()
+				return "exit"
+			}
+			c.handleInput(input, isMyTurn, p)
+			if !c.running {
+				// Из боя вышли командой /exit в чате
+				return "exit"
+			}
+		default:
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	return "error"
+}
+
+func (c *PvPClient) printRoundResult(status string, p *player.Player) {
+	parts := strings.Split(status, ":")
+	if len(parts) != 2 {
+		return
+	}
+	data := strings.Split(parts[1], "|")
+	if len(data) < 7 {
+		return
+	}
+
+	round, _ := strconv.Atoi(data[0])
+	yourDamage, _ := strconv.Atoi(data[1])
+	yourHPBefore, _ := strconv.Atoi(data[2])
+	yourHPAfter, _ := strconv.Atoi(data[3])
+	damageToYou, _ := strconv.Atoi(data[4])
+	opponentHPBefore, _ := strconv.Atoi(data[5])
+	opponentHPAfter, _ := strconv.Atoi(data[6])
+
+	fmt.Printf("\n=== РЕЗУЛЬТАТ РАУНДА %d ===\n", round)
+	fmt.Println("═══════════════════════════")
+	fmt.Printf("💥 ВЫ нанесли: %d урона\n", yourDamage)
+	fmt.Printf("💔 ВАМ нанесли: %d урона\n", damageToYou)
+	fmt.Println("───────────────────────────")
+	fmt.Printf("❤️ ВАШЕ здоровье: %d → %d\n", yourHPBefore, yourHPAfter)
+	fmt.Printf("❤️ Здоровье ПРОТИВНИКА: %d → %d\n", opponentHPBefore, opponentHPAfter)
+	fmt.Println("═══════════════════════════")
+
+	p.HP = yourHPAfter
+}
+
+func (c *PvPClient) chooseHit() int {
+	fmt.Println("\nКуда атаковать?")
+	fmt.Println("1 — Голова\n2 — Тело\n3 — Ноги")
+	for {
+		select {
+		case <-c.done:
+			return -1
+		case input := <-c.inputCh:
+			if choice, err := strconv.Atoi(input); err == nil && choice >= 1 && choice <= 3 {
+				return choice - 1
+			}
+			fmt.Println("❌ Введите 1-3")
+		}
+	}
+}
+
+func (c *PvPClient) chooseBlock() int {
+	fmt.Println("\nЧто защищать?")
+	fmt.Println("1 — Голова\n2 — Тело\n3 — Ноги")
+	for {
+		select {
+		case <-c.done:
+			return -1
+		case input := <-c.inputCh:
+			if choice, err := strconv.Atoi(input); err == nil && choice >= 1 && choice <= 3 {
+				return choice - 1
+			}
+			fmt.Println("❌ Введите 1-3")
+		}
+	}
+}
+
+func (c *PvPClient) sendChat(playerName, message string) {
+	endpoint := fmt.Sprintf("%s/pvp/chat?matchID=%s&player=%s&msg=%s&token=%s",
+		c.serverURL, c.matchID, url.QueryEscape(playerName), url.QueryEscape(message), url.QueryEscape(c.token))
+	c.httpClient.Get(endpoint)
+}
+
+func (c *PvPClient) openPvPChat() {
+	c.chatMu.Lock()
+	c.chatOpen = true
+	c.chatMu.Unlock()
+
+	defer func() {
+		c.chatMu.Lock()
+		c.chatOpen = false
+		c.chatMu.Unlock()
+		fmt.Println("\n=== ЧАТ ЗАКРЫТ ===")
+	}()
+
+	fmt.Println("\n=== PvP ЧАТ ===")
+	fmt.Println("Введите /back для возврата")
+
+	// Показываем историю
+	resp, err := c.httpClient.Get(fmt.Sprintf(
+		"%s/pvp/chat/history?matchID=%s",
+		c.serverURL, c.matchID,
+	))
+	if err == nil {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		raw := strings.TrimSpace(string(body))
+		if raw != "" {
+			fmt.Println("\n--- ИСТОРИЯ ЧАТА ---")
+			fmt.Println(raw)
+
+			lines := strings.Split(raw, "\n")
+
+			c.chatMu.Lock()
+			c.chatLastCount = len(lines)
+			c.chatMu.Unlock()
+		}
+	}
+
+	fmt.Print("> ")
+
+	for c.running {
+		input := <-c.inputCh
+
+		switch input {
+		case "/back":
+			return
+
+		case "/exit":
+			c.leaveBattle()
+			return
+
+		default:
+			if strings.TrimSpace(input) != "" {
+				c.sendChat(c.playerName, input)
+			}
+		}
+
+		fmt.Print("> ")
+	}
+}
+
+func (c *PvPClient) useItemInBattle(p *player.Player) {
+	if len(p.Inventory) == 0 {
+		fmt.Println("Инвентарь пуст.")
+		return
+	}
+
+	if p.ShowItems((*items.Item).IsConsumable) == 0 {
+		fmt.Println("Нет предметов, которые можно использовать в бою.")
+		return
+	}
+	fmt.Print("Введите номер предмета: ")
+	input := <-c.inputCh
+	idx, err := strconv.Atoi(input)
+	if err != nil || idx < 1 || idx > len(p.Inventory) {
+		fmt.Println("❌ Неверный номер!")
+		return
+	}
+	item := p.Inventory[idx-1]
+	if !item.IsConsumable() {
+		fmt.Println("❌ Данную вещь можно только экипировать")
+		return
+	}
+
+	// Предмет занимает ход, но защищаться всё равно нужно
+	block := c.chooseBlock()
+	if block == -1 {
+		return
+	}
+
+	// Эффект применяет сервер, предмет тратится только после его согласия
+	data := fmt.Sprintf("%s|%s|%s|%d|%s", c.matchID, c.playerName, item.Name, block, c.token)
+	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/item", c.serverURL), "text/plain", strings.NewReader(data))
+	if err != nil {
+		fmt.Println("❌ Сервер недоступен")
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	answer := strings.TrimSpace(string(body))
+
+	if !strings.HasPrefix(answer, "ok:") {
+		fmt.Printf("⏳ Нельзя: %s\n", itemErrorText(answer))
+		return
+	}
+
+	fields := strings.Split(strings.TrimPrefix(answer, "ok:"), "|")
+	if len(fields) < 3 {
+		return
+	}
+	hp, _ := strconv.Atoi(fields[0])
+	maxHP, _ := strconv.Atoi(fields[1])
+	left, _ := strconv.Atoi(fields[2])
+
+	p.TakeItem(idx - 1)
+	fmt.Printf("\n%s✨ Используется: %s\033[0m\n", item.GetRarityColor(), item.Name)
+	if hp > p.HP {
+		fmt.Printf("❤️ Восстановлено %d здоровья! (%d/%d)\n", hp-p.HP, hp, maxHP)
+	}
+	if item.Effect.StunRounds > 0 {
+		fmt.Printf("💫 Противник оглушён на %d раунд(а)\n", item.Effect.StunRounds)
+	}
+	fmt.Printf("🎒 Предметов на этот бой осталось: %d\n", left)
+	p.HP = hp
+}
+
+// itemErrorText - понятное сообщение по коду отказа сервера
+func itemErrorText(answer string) string {
+	parts := strings.Split(strings.TrimPrefix(answer, "error:"), "|")
+	switch parts[0] {
+	case "move_done":
+		return "вы уже сделали ход в этом раунде"
+	case "finished":
+		return "бой уже закончен"
+	case "items_disabled":
+		return "по правилам этого боя предметы запрещены"
+	case "session":
+		return "сервер не узнал сессию этого боя"
+	case "not_owned":
+		return "этого предмета нет в серверном инвентаре: в PvP тратятся купленные в серверной лавке или на рынке"
+	}
+	rounds := 0
+	if len(parts) > 1 {
+		rounds, _ = strconv.Atoi(parts[1])
+	}
+	return (&combat.ConsumableError{Code: parts[0], Rounds: rounds}).Error()
+}
+
+func (c *PvPClient) startInputListener() {
+	go func() {
+		reader := bufio.NewReader(os.Stdin)
+		for c.running {
+			text, err := reader.ReadString('\n')
+			if err != nil {
+				continue
+			}
+			c.inputCh <- strings.TrimSpace(text)
+		}
+	}()
+}
+
+func (c *PvPClient) handleInput(input string, isMyTurn bool, p *player.Player) {
+	if !c.running {
+		fmt.Println("❌ Бой завершён, нельзя вводить команды")
+		return
+	}
+
+	switch input {
+	case "1":
+		c.openPvPChat()
+	case "2":
+		if !isMyTurn {
+			fmt.Println("❌ Сейчас не ваш ход!")
+			return
+		}
+		attack := c.chooseHit()
+		if attack == -1 {
+			return
+		}
+		block := c.chooseBlock()
+		if block == -1 {
+			return
+		}
+		moveData := fmt.Sprintf("%s|%s|%d|%d|%s", c.matchID, c.playerName, attack, block, c.token)
+		c.httpClient.Post(fmt.Sprintf("%s/pvp/move", c.serverURL), "text/plain", strings.NewReader(moveData))
+	case "3":
+		if !isMyTurn {
+			fmt.Println("❌ Сейчас не ваш ход!")
+			return
+		}
+		c.useItemInBattle(p)
+	case "4":
+		p.ShowInventory()
+	default:
+		fmt.Println("❌ Неверная команда")
+	}
+}
+
+func (c *PvPClient) startChatListener() {
+	go func() {
+		for c.running {
+			resp, err := c.httpClient.Get(fmt.Sprintf(
+				"%s/pvp/chat/history?matchID=%s",
+				c.serverURL, c.matchID,
+			))
+			if err != nil {
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+
+			raw := strings.TrimSpace(string(body))
+			if raw == "" {
+				time.Sleep(500 * time.Millisecond)
+				continue
+			}
+
+			lines := strings.Split(raw, "\n")
+
+			c.chatMu.Lock()
+
+			if len(lines) > c.chatLastCount {
+				for i := c.chatLastCount; i < len(lines); i++ {
+					line := strings.TrimSpace(lines[i])
+					if line == "" {
+						continue
+					}
+
+					if c.chatOpen {
+						fmt.Printf("\r\033[K%s\n> ", line)
+					} else {
+						fmt.Printf("\n💬 %s\n> ", line)
+					}
+				}
+				c.chatLastCount = len(lines)
+			}
+
+			c.chatMu.Unlock()
+
+			time.Sleep(500 * time.Millisecond)
+		}
+	}()
+}
+
+func (c *PvPClient) StopBattle() {
+	if c.running {
+		c.running = false
+		close(c.done)
+		fmt.Println("\n❌ Вы покинули бой PvP.")
+	}
+}
@@ -0,0 +1,215 @@
+package pvp
+
+import (
+	"bufio"
+	"fmt"
+	"game/combat"
+	"game/player"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Пауза между событиями при перемотке
+const replayFastDelay = 250 * time.Millisecond
+
+// replayEvent - строка журнала боя с сервера
+type replayEvent struct {
+	round  int
+	kind   string
+	player string
+	data   []string
+}
+
+// Replays - записи прошедших PvP-боёв игрока с пошаговым просмотром
+func (c *PvPClient) Replays(p *player.Player) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		list, err := c.get("/pvp/replays?player=" + url.QueryEscape(p.Name))
+		if err != nil {
+			fmt.Println("⚠️ Сервер недоступен:", err)
+			return
+		}
+
+		fmt.Println("\n=== 🎞️ ЗАПИСИ PvP БОЁВ ===")
+		ids := make([]string, 0)
+		for _, line := range strings.Split(strings.TrimSpace(list), "\n") {
+			fields := strings.Split(line, "|")
+			if len(fields) < 5 {
+				continue
+			}
+			ids = append(ids, fields[0])
+			ended, _ := strconv.ParseInt(fields[3], 10, 64)
+			fmt.Printf("%d. vs %-16s %s, раундов: %s (%s)\n", len(ids), fields[1], outcomeText(fields[2]),
+				fields[4], time.Unix(ended, 0).Format("02.01.2006 15:04"))
+		}
+		if len(ids) == 0 {
+			fmt.Println("Записанных боёв пока нет")
+			return
+		}
+
+		fmt.Print("Номер боя для просмотра (0 - назад): ")
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || choice < 0 || choice > len(ids) {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+		c.playReplay(ids[choice-1], reader)
+	}
+}
+
+func outcomeText(outcome string) string {
+	switch outcome {
+	case "win":
+		return "🏆 победа"
+	case "loss":
+		return "💀 поражение"
+	case "draw":
+		return "🤝 ничья"
+	case "abandoned":
+		return "⌛ брошен"
+	case "shutdown":
+		return "⛔ прерван сервером"
+	default:
+		return outcome
+	}
+}
+
+// playReplay - скачивает журнал боя и показывает его по событию, по раунду или перемоткой до конца
+func (c *PvPClient) playReplay(id string, reader *bufio.Reader) {
+	answer, err := c.get("/pvp/replay?id=" + url.QueryEscape(id))
+	if err != nil {
+		fmt.Println("⚠️ Сервер недоступен:", err)
+		return
+	}
+	if strings.HasPrefix(answer, "error:") {
+		fmt.Println("❌ Запись боя не найдена")
+		return
+	}
+
+	lines := strings.Split(strings.TrimSpace(answer), "\n")
+	header := strings.Split(strings.TrimPrefix(lines[0], "replay:"), "|")
+	if len(header) < 7 {
+		fmt.Println("❌ Запись боя повреждена")
+		return
+	}
+	events := make([]replayEvent, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		if event, ok := parseEvent(line); ok {
+			events = append(events, event)
+		}
+	}
+
+	started, _ := strconv.ParseInt(header[5], 10, 64)
+	fmt.Printf("\n=== 🎞️ %s: %s против %s (%s) ===\n", header[0], header[1], header[2],
+		time.Unix(started, 0).Format("02.01.2006 15:04"))
+	fmt.Println("Enter - следующее событие, 1 - до конца раунда, 2 - перемотать до конца, 0 - выход")
+
+	players := [2]string{header[1], header[2]}
+	fastUntilRound := 0
+	fastToEnd := false
+	for i, event := range events {
+		printReplayEvent(event, players)
+		if i == len(events)-1 {
+			break
+		}
+		next := events[i+1]
+		if fastToEnd || (fastUntilRound > 0 && next.round == fastUntilRound) {
+			time.Sleep(replayFastDelay)
+			continue
+		}
+		fastUntilRound = 0
+
+		fmt.Print("▶ ")
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			fastUntilRound = event.round
+		case "2":
+			fastToEnd = true
+		case "0":
+			return
+		}
+	}
+	fmt.Println("=== Конец записи ===")
+}
+
+// parseEvent - разбирает строку журнала: мс от начала|раунд|вид|игрок|данные...
+func parseEvent(line string) (replayEvent, bool) {
+	fields := strings.Split(line, "|")
+	if len(fields) < 4 {
+		return replayEvent{}, false
+	}
+	round, _ := strconv.Atoi(fields[1])
+	return replayEvent{round: round, kind: fields[2], player: fields[3], data: fields[4:]}, true
+}
+
+func printReplayEvent(event replayEvent, players [2]string) {
+	arg := func(i int) string {
+		if i < len(event.data) {
+			return event.data[i]
+		}
+		return "?"
+	}
+	part := func(i int) string {
+		n, err := strconv.Atoi(arg(i))
+		if err != nil {
+			return "?"
+		}
+		return combat.BodyPart(n).String()
+	}
+
+	switch event.kind {
+	case "join":
+		fmt.Printf("👤 %s выходит на арену: ❤️ %s/%s, ⚔️ %s, 🏅 %s\n", event.player, arg(0), arg(1), arg(2), arg(3))
+	case "move":
+		if arg(0) == "-1" {
+			fmt.Printf("[Раунд %d] 🛡️ %s закрывает %s\n", event.round, event.player, part(1))
+		} else {
+			fmt.Printf("[Раунд %d] ⚔️ %s бьёт в %s, закрывает %s\n", event.round, event.player, part(0), part(1))
+		}
+	case "item":
+		fmt.Printf("[Раунд %d] 🧪 %s использует %s (❤️ %s → %s)\n", event.round, event.player, arg(0), arg(1), arg(2))
+	case "stun":
+		fmt.Printf("[Раунд %d] 💫 %s оглушён и пропускает атаку\n", event.round, event.player)
+	case "round":
+		fmt.Printf("[Раунд %d] 💥 %s получает %s урона, %s получает %s урона\n", event.round, players[0], arg(0), players[1], arg(1))
+		fmt.Printf("           ❤️ %s: %s, %s: %s\n", players[0], arg(2), players[1], arg(3))
+	case "chat":
+		fmt.Printf("[Раунд %d] 💬 %s: %s\n", event.round, event.player, strings.Join(event.data, "|"))
+	case "resume":
+		fmt.Printf("[Раунд %d] 🔌 %s вернулся в бой\n", event.round, event.player)
+	case "forfeit":
+		fmt.Printf("[Раунд %d] 🏳️ %s сдаётся\n", event.round, event.player)
+	case "timeout":
+		fmt.Printf("[Раунд %d] ⌛ %s не вернулся в бой - техническое поражение\n", event.round, event.player)
+	case "end":
+		switch {
+		case arg(0) != "finished":
+			fmt.Printf("🏁 Бой остановлен без результата (%s)\n", outcomeText(arg(0)))
+		case arg(1) == "":
+			fmt.Println("🏁 Ничья!")
+		default:
+			fmt.Printf("🏁 Победитель: %s (❤️ %s: %s, %s: %s)\n", arg(1), players[0], arg(2), players[1], arg(3))
+		}
+	default:
+		fmt.Printf("[Раунд %d] %s %s %s\n", event.round, event.kind, event.player, strings.Join(event.data, " "))
+	}
+}
+
+func (c *PvPClient) get(path string) (string, error) {
+	resp, err := c.httpClient.Get(c.serverURL + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
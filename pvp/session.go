@@ -0,0 +1,166 @@
+package pvp
+
+import (
+	"bufio"
+	"fmt"
+	"game/player"
+	"game/save"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Сессия боя хранится рядом с сохранением, чтобы после сбоя клиента вернуться в матч
+
+func sessionPath(name string) string {
+	return filepath.Join(save.Dir, name+".pvp")
+}
+
+func saveSession(name, matchID, token string) {
+	if err := os.MkdirAll(save.Dir, 0755); err != nil {
+		return
+	}
+	os.WriteFile(sessionPath(name), []byte(matchID+"|"+token), 0644)
+}
+
+func loadSession(name string) (string, string, bool) {
+	raw, err := os.ReadFile(sessionPath(name))
+	if err != nil {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimSpace(string(raw)), "|")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func clearSession(name string) {
+	os.Remove(sessionPath(name))
+}
+
+// Токен турнира выдаётся один раз при записи и нужен во всех боях турнира,
+// поэтому он хранится отдельно от сессии боя: строки ID турнира|токен
+
+func cupTokensPath(name string) string {
+	return filepath.Join(save.Dir, name+".cup")
+}
+
+func saveCupToken(name, cupID, token string) {
+	if err := os.MkdirAll(save.Dir, 0755); err != nil {
+		return
+	}
+	lines := []string{cupID + "|" + token}
+	if raw, err := os.ReadFile(cupTokensPath(name)); err == nil {
+		for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+			if line != "" && !strings.HasPrefix(line, cupID+"|") {
+				lines = append(lines, line)
+			}
+		}
+	}
+	// Старые турниры давно закончились - хватает последних записей
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+	os.WriteFile(cupTokensPath(name), []byte(strings.Join(lines, "\n")), 0644)
+}
+
+func loadCupToken(name, cupID string) string {
+	raw, err := os.ReadFile(cupTokensPath(name))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(raw)), "\n") {
+		if token, ok := strings.CutPrefix(line, cupID+"|"); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// rememberMatch - запоминает матч из строки match:, а вместе с ним токен, выданный при записи на бой
+func (c *PvPClient) rememberMatch(matchParts []string) {
+	c.matchID = matchParts[0]
+	if c.token != "" {
+		saveSession(c.playerName, c.matchID, c.token)
+	}
+}
+
+// Resume - если прошлый бой прервался, предлагает в него вернуться.
+// Возвращает результат боя или пустую строку, если возвращаться некуда, и признак награды:
+// награду дают только рейтинговые бои и бои с ботом, товарищеские и турнирные доигрываются без неё
+func (c *PvPClient) Resume(p *player.Player) (string, bool) {
+	_, token, ok := loadSession(p.Name)
+	if !ok {
+		return "", false
+	}
+
+	c.playerName = p.Name
+	c.token = token
+	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/resume", c.serverURL), "text/plain",
+		strings.NewReader(p.Name+"|"+token))
+	if err != nil {
+		// Сервер недоступен - сессию не трогаем, попробуем при следующем запуске
+		return "", false
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	response := strings.TrimSpace(string(body))
+
+	matchParts := strings.Split(strings.TrimPrefix(response, "match:"), "|")
+	if !strings.HasPrefix(response, "match:") || len(matchParts) < 10 {
+		clearSession(p.Name)
+		return "", false
+	}
+	rewarded := matchParts[7] == "ranked" || matchParts[7] == "bot"
+
+	fmt.Println("\n⚔️ У вас есть незаконченный PvP-бой!")
+	fmt.Printf("👤 Соперник: %s, раунд %s, ваше здоровье: %s\n", matchParts[1], matchParts[8], matchParts[9])
+	fmt.Print("Вернуться в бой? Отказ засчитывается как поражение (да/нет): ")
+	reader := bufio.NewReader(os.Stdin)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	c.rememberMatch(matchParts)
+	if answer != "да" && answer != "д" && answer != "yes" {
+		c.forfeit()
+		clearSession(p.Name)
+		fmt.Println("🏳️ Бой засчитан как поражение")
+		return "loss", rewarded
+	}
+
+	if hp, err := strconv.Atoi(matchParts[9]); err == nil {
+		p.HP = hp
+	}
+	c.running = true
+	result := c.startBattle(p)
+	clearSession(p.Name)
+	c.running = false
+	if !rewarded {
+		fmt.Println("📜 Это был товарищеский или турнирный бой: награда за него не начисляется")
+		p.HP = p.GetMaxHP()
+		p.WearEquipment()
+	}
+	return result, rewarded
+}
+
+// forfeit - сдаться в текущем бою, чтобы соперник не ждал
+func (c *PvPClient) forfeit() {
+	if c.token == "" {
+		return
+	}
+	resp, err := c.httpClient.Post(fmt.Sprintf("%s/pvp/forfeit", c.serverURL), "text/plain",
+		strings.NewReader(c.playerName+"|"+c.token))
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// leaveBattle - уход из боя, из меню или из чата, - поражение: сервер засчитывает его сразу,
+// соперник не ждёт окончания льготного времени
+func (c *PvPClient) leaveBattle() {
+	c.forfeit()
+	c.StopBattle()
+}
@@ -0,0 +1,124 @@
+package pvp
+
+import (
+	"bufio"
+	"fmt"
+	"game/player"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Spectate - список идущих боёв и трансляция выбранного, только для чтения
+func (c *PvPClient) Spectate(p *player.Player) {
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		list, err := c.get("/pvp/live")
+		if err != nil {
+			fmt.Println("⚠️ Сервер недоступен:", err)
+			return
+		}
+
+		fmt.Println("\n=== 👁️ ИДУЩИЕ PvP БОИ ===")
+		ids := make([]string, 0)
+		for _, line := range strings.Split(strings.TrimSpace(list), "\n") {
+			fields := strings.Split(line, "|")
+			if len(fields) < 7 {
+				continue
+			}
+			ids = append(ids, fields[0])
+			fmt.Printf("%d. %s (🏅 %s) против %s (🏅 %s), раунд %s, зрителей: %s\n",
+				len(ids), fields[1], fields[4], fields[2], fields[5], fields[3], fields[6])
+		}
+		if len(ids) == 0 {
+			fmt.Println("Сейчас никто не сражается")
+			return
+		}
+
+		fmt.Print("Номер боя (0 - назад, Enter - обновить): ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 0 || choice > len(ids) {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+		c.watch(ids[choice-1], p.Name)
+	}
+}
+
+// watch - опрашивает трансляцию боя и печатает новые события, пока бой не кончится или зритель не нажмёт Enter
+func (c *PvPClient) watch(id, viewer string) {
+	cursor := 0
+	var players [2]string
+	cancelCh := make(chan bool)
+	started := false
+
+	for {
+		answer, err := c.get(fmt.Sprintf("/pvp/watch?id=%s&viewer=%s&from=%d", url.QueryEscape(id), url.QueryEscape(viewer), cursor))
+		switch {
+		case err != nil:
+			fmt.Println("⚠️ Связь с сервером потеряна:", err)
+		case answer == "error:participant":
+			fmt.Println("❌ Это ваш бой - зрителем в нём быть нельзя")
+			return
+		case answer == "error:not_found":
+			if !started {
+				fmt.Println("❌ Бой уже закончился")
+				return
+			}
+			fmt.Println("\n🏁 Бой закончился. Запись можно посмотреть в разделе «Записи PvP боёв»")
+		default:
+			lines := strings.Split(strings.TrimSpace(answer), "\n")
+			header := strings.Split(strings.TrimPrefix(lines[0], "watch:"), "|")
+			if !strings.HasPrefix(lines[0], "watch:") || len(header) < 6 {
+				fmt.Println("❌ Неожиданный ответ сервера:", lines[0])
+				return
+			}
+			if !started {
+				started = true
+				players = [2]string{header[1], header[2]}
+				fmt.Printf("\n=== 👁️ %s: %s против %s ===\n", header[0], players[0], players[1])
+				if header[4] != "0" {
+					fmt.Printf("⏱️ Трансляция идёт с задержкой %s сек.\n", header[4])
+				}
+				fmt.Println("Вы зритель: писать в чат боя нельзя. Enter - выйти из трансляции")
+				go c.waitForCancel(cancelCh)
+			}
+			cursor, _ = strconv.Atoi(header[3])
+
+			ended := false
+			for _, line := range lines[1:] {
+				event, ok := parseEvent(line)
+				if !ok {
+					continue
+				}
+				printReplayEvent(event, players)
+				ended = ended || event.kind == "end"
+			}
+			if !ended {
+				select {
+				case <-cancelCh:
+					return
+				case <-time.After(1 * time.Second):
+				}
+				continue
+			}
+		}
+
+		// Трансляция окончена, ждём Enter, чтобы не оставлять чтение ввода висеть
+		if started {
+			fmt.Println("Нажмите Enter, чтобы вернуться")
+			<-cancelCh
+		}
+		return
+	}
+}
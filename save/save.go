@@ -0,0 +1,88 @@
+package save
+
+import (
+	"encoding/json"
+	"errors"
+	"game/player"
+	"game/shop"
+	"game/tournament"
+	"os"
+	"path/filepath"
+)
+
+// Dir - папка с сохранениями рядом с игрой
+const Dir = "saves"
+
+// Version - версия формата сохранения. 2: MaxHP игрока больше не включает надетые предметы,
+// 3: одинаковые расходники лежат в рюкзаке стопками
+const Version = 3
+
+type SaveData struct {
+	Version    int
+	Player     *player.Player
+	Tournament tournament.Progress
+	Shop       shop.State
+}
+
+func Path(name string) string {
+	return filepath.Join(Dir, name+".json")
+}
+
+func Exists(name string) bool {
+	_, err := os.Stat(Path(name))
+	return err == nil
+}
+
+func Save(p *player.Player, t *tournament.Tournament, s *shop.Shop) error {
+	data := SaveData{
+		Version:    Version,
+		Player:     p,
+		Tournament: t.Progress(),
+		Shop:       s.State(),
+	}
+
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(Dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(Path(p.Name), raw, 0644)
+}
+
+func Load(name string) (*player.Player, *tournament.Tournament, *shop.Shop, error) {
+	raw, err := os.ReadFile(Path(name))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var data SaveData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, nil, nil, err
+	}
+	if data.Player == nil {
+		return nil, nil, nil, errors.New("сохранение повреждено: нет данных игрока")
+	}
+	if data.Player.ActiveEffects == nil {
+		data.Player.ActiveEffects = make(map[string]int)
+	}
+	if data.Version < 2 {
+		// Раньше экипировка прибавлялась к MaxHP дважды: при надевании и в GetMaxHP
+		for _, item := range data.Player.Equipped {
+			data.Player.MaxHP -= item.Effect.MaxHP
+		}
+	}
+	if data.Version < 3 {
+		data.Player.StackInventory()
+	}
+
+	s := shop.NewShop()
+	s.Restore(data.Shop)
+	return data.Player, tournament.Restore(data.Player, data.Tournament), s, nil
+}
+
+// Delete - стирает сохранение (хардкор-поражение)
+func Delete(name string) error {
+	return os.Remove(Path(name))
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	minAuctionMinutes = 1
+	maxAuctionMinutes = 24 * 60
+	minBidStepPercent = 5 // Новая ставка должна быть выше текущей хотя бы на столько процентов
+)
+
+const (
+	AuctionActive    = "active"
+	AuctionSold      = "sold"
+	AuctionExpired   = "expired"
+	AuctionCancelled = "cancelled"
+)
+
+// Auction - лот аукциона. Предмет продавца и ставка лидера находятся в залоге у сервера
+type Auction struct {
+	ID         string
+	Seller     string
+	Item       string // номер экземпляра снаряжения или название расходника
+	StartPrice int
+	Buyout     int // 0 - без выкупа
+	HighBid    int
+	HighBidder string
+	ExpiresAt  time.Time
+	Status     string
+}
+
+// minBid - наименьшая допустимая ставка
+func (a *Auction) minBid() int {
+	if a.HighBidder == "" {
+		return a.StartPrice
+	}
+	step := a.HighBid * minBidStepPercent / 100
+	if step < 1 {
+		step = 1
+	}
+	return a.HighBid + step
+}
+
+// settleAuction - отдаёт предмет победителю, а деньги продавцу. Вызывать под mutex
+func (ss *ServerShop) settleAuction(a *Auction, winner string, price int) {
+	ss.giveToAccount(winner, []string{a.Item}, 0)
+	ss.giveToAccount(a.Seller, nil, price)
+	a.HighBid = price
+	a.HighBidder = winner
+	a.Status = AuctionSold
+	ss.record(a.Seller, "auction", "sold "+ss.itemLabel(a.Item), price, ss.account(a.Seller).Balance)
+	// Деньги победителя списаны раньше - при ставке или выкупе
+	ss.record(winner, "auction", "won "+ss.itemLabel(a.Item), 0, ss.account(winner).Balance)
+}
+
+// expireAuctions - закрывает лоты с истёкшим временем. Вызывать под mutex
+func (ss *ServerShop) expireAuctions(now time.Time) {
+	for _, a := range ss.auctions {
+		if a.Status != AuctionActive || now.Before(a.ExpiresAt) {
+			continue
+		}
+		if a.HighBidder != "" {
+			// Ставка лидера уже списана при ставке
+			ss.settleAuction(a, a.HighBidder, a.HighBid)
+			continue
+		}
+		ss.giveToAccount(a.Seller, []string{a.Item}, 0)
+		a.Status = AuctionExpired
+		ss.record(a.Seller, "auction", "expired "+ss.itemLabel(a.Item), 0, ss.account(a.Seller).Balance)
+	}
+}
+
+// handleAuctionList - GET /auction/list
+// Строки: ID|Продавец|Предмет|Текущая цена|Минимальная ставка|Выкуп|Лидер|Осталось секунд
+func (ss *ServerShop) handleAuctionList(w http.ResponseWriter, r *http.Request) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	now := time.Now()
+	ss.expireAuctions(now)
+
+	active := make([]*Auction, 0, len(ss.auctions))
+	for _, a := range ss.auctions {
+		if a.Status == AuctionActive {
+			active = append(active, a)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ExpiresAt.Before(active[j].ExpiresAt) })
+
+	for _, a := range active {
+		current := a.HighBid
+		if a.HighBidder == "" {
+			current = a.StartPrice
+		}
+		fmt.Fprintf(w, "%s|%s|%s|%d|%d|%d|%s|%d\n",
+			a.ID, a.Seller, ss.itemLabel(a.Item), current, a.minBid(), a.Buyout, a.HighBidder,
+			int(a.ExpiresAt.Sub(now).Seconds()))
+	}
+}
+
+// handleAuctionCreate - POST /auction/create
+// Тело: Продавец|номер экземпляра снаряжения или название расходника|Стартовая цена|Выкуп|Минут
+func (ss *ServerShop) handleAuctionCreate(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 5)
+	if !ok {
+		return
+	}
+
+	seller, ref := parts[0], parts[1]
+	start, err1 := strconv.Atoi(parts[2])
+	buyout, err2 := strconv.Atoi(parts[3])
+	minutes, err3 := strconv.Atoi(parts[4])
+	if err1 != nil || err2 != nil || err3 != nil || start <= 0 || buyout < 0 || (buyout > 0 && buyout < start) {
+		http.Error(w, "Invalid price", http.StatusBadRequest)
+		return
+	}
+	if minutes < minAuctionMinutes || minutes > maxAuctionMinutes {
+		http.Error(w, "Invalid duration", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, seller) {
+		return
+	}
+
+	if err := ss.takeFromAccount(seller, []string{ref}, 0); err != nil {
+		fmt.Fprintf(w, "error:%s", err)
+		return
+	}
+
+	a := &Auction{
+		ID:         ss.newID("lot"),
+		Seller:     seller,
+		Item:       ref,
+		StartPrice: start,
+		Buyout:     buyout,
+		ExpiresAt:  time.Now().Add(time.Duration(minutes) * time.Minute),
+		Status:     AuctionActive,
+	}
+	ss.auctions[a.ID] = a
+	ss.record(seller, "auction", "listed "+ss.itemLabel(ref), 0, ss.account(seller).Balance)
+
+	fmt.Fprintf(w, "ok|%s", a.ID)
+}
+
+// handleAuctionBid - POST /auction/bid, тело: Имя|ID лота|Ставка
+// Ставка сразу списывается, а перебитая ставка возвращается прежнему лидеру
+func (ss *ServerShop) handleAuctionBid(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 3)
+	if !ok {
+		return
+	}
+
+	player, id := parts[0], parts[1]
+	amount, err := strconv.Atoi(parts[2])
+	if err != nil {
+		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireAuctions(time.Now())
+
+	a, exists := ss.auctions[id]
+	if !exists {
+		http.Error(w, "Lot not found", http.StatusNotFound)
+		return
+	}
+	if a.Status != AuctionActive {
+		fmt.Fprintf(w, "error:%s", a.Status)
+		return
+	}
+	if a.Seller == player {
+		fmt.Fprint(w, "error:own_lot")
+		return
+	}
+	if amount < a.minBid() {
+		fmt.Fprintf(w, "error:low_bid|%d", a.minBid())
+		return
+	}
+	if a.Buyout > 0 && amount >= a.Buyout {
+		fmt.Fprint(w, "error:use_buyout")
+		return
+	}
+
+	// Лидер, перебивающий сам себя, доплачивает только разницу
+	refund := 0
+	if a.HighBidder == player {
+		refund = a.HighBid
+	}
+	if err := ss.takeFromAccount(player, nil, amount-refund); err != nil {
+		fmt.Fprintf(w, "error:%s", err)
+		return
+	}
+	if a.HighBidder != "" && a.HighBidder != player {
+		ss.giveToAccount(a.HighBidder, nil, a.HighBid)
+		ss.record(a.HighBidder, "auction", "outbid "+ss.itemLabel(a.Item), a.HighBid, ss.account(a.HighBidder).Balance)
+	}
+
+	a.HighBid = amount
+	a.HighBidder = player
+	ss.record(player, "auction", "bid "+ss.itemLabel(a.Item), -(amount - refund), ss.account(player).Balance)
+
+	fmt.Fprintf(w, "ok|%d", ss.account(player).Balance)
+}
+
+// handleAuctionBuyout - POST /auction/buyout, тело: Имя|ID лота
+func (ss *ServerShop) handleAuctionBuyout(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player, id := parts[0], parts[1]
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireAuctions(time.Now())
+
+	a, exists := ss.auctions[id]
+	if !exists {
+		http.Error(w, "Lot not found", http.StatusNotFound)
+		return
+	}
+	if a.Status != AuctionActive {
+		fmt.Fprintf(w, "error:%s", a.Status)
+		return
+	}
+	if a.Buyout == 0 {
+		fmt.Fprint(w, "error:no_buyout")
+		return
+	}
+	if a.Seller == player {
+		fmt.Fprint(w, "error:own_lot")
+		return
+	}
+
+	refund := 0
+	if a.HighBidder == player {
+		refund = a.HighBid
+	}
+	if err := ss.takeFromAccount(player, nil, a.Buyout-refund); err != nil {
+		fmt.Fprintf(w, "error:%s", err)
+		return
+	}
+	if a.HighBidder != "" && a.HighBidder != player {
+		ss.giveToAccount(a.HighBidder, nil, a.HighBid)
+		ss.record(a.HighBidder, "auction", "outbid "+ss.itemLabel(a.Item), a.HighBid, ss.account(a.HighBidder).Balance)
+	}
+	ss.record(player, "auction", "buyout "+ss.itemLabel(a.Item), -(a.Buyout - refund), ss.account(player).Balance)
+	ss.settleAuction(a, player, a.Buyout)
+
+	fmt.Fprintf(w, "ok|%d", ss.account(player).Balance)
+}
+
+// handleAuctionCancel - POST /auction/cancel, тело: Продавец|ID лота. Снять можно только лот без ставок
+func (ss *ServerShop) handleAuctionCancel(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player, id := parts[0], parts[1]
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireAuctions(time.Now())
+
+	a, exists := ss.auctions[id]
+	if !exists || a.Seller != player {
+		http.Error(w, "Lot not found", http.StatusNotFound)
+		return
+	}
+	if a.Status != AuctionActive {
+		fmt.Fprintf(w, "error:%s", a.Status)
+		return
+	}
+	if a.HighBidder != "" {
+		fmt.Fprint(w, "error:has_bids")
+		return
+	}
+
+	ss.giveToAccount(a.Seller, []string{a.Item}, 0)
+	a.Status = AuctionCancelled
+	ss.record(a.Seller, "auction", "cancelled "+ss.itemLabel(a.Item), 0, ss.account(a.Seller).Balance)
+
+	fmt.Fprint(w, "ok")
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"fmt"
+	"game/boss"
+	"game/combat"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Как играют боты (PVP_BOT_STYLE)
+const (
+	BotAdaptive = "adaptive" // учится на ходах соперника
+	BotBoss     = "boss"     // случайные удары и блоки, как у боссов кампании
+	BotOff      = "off"      // ботов нет, ждём живого соперника
+)
+
+// botPrefix - с него начинается имя любого бота, чтобы его нельзя было спутать с игроком
+const botPrefix = "🤖 "
+
+var botNames = []string{"Страж арены", "Тень дуэлянта", "Железный ученик", "Хранитель ринга", "Заводной рыцарь"}
+
+// botBrain - выбор ударов и блоков бота
+type botBrain interface {
+	ChooseAttack() combat.BodyPart
+	ChooseBlock() combat.BodyPart
+	Observe(attack, block combat.BodyPart)
+}
+
+// bossBrain - ИИ босса кампании: ходит наугад и соперника не изучает
+type bossBrain struct {
+	boss *boss.Boss
+}
+
+func (b bossBrain) ChooseAttack() combat.BodyPart {
+	part, _ := b.boss.ChooseAttack()
+	return part
+}
+
+func (b bossBrain) ChooseBlock() combat.BodyPart {
+	return b.boss.ChooseBlock()
+}
+
+func (b bossBrain) Observe(attack, block combat.BodyPart) {}
+
+func isBotName(name string) bool {
+	return strings.HasPrefix(name, botPrefix)
+}
+
+// newBotBrain - ИИ бота по настройке сервера
+func (s *ChatServer) newBotBrain(bot *PvPPlayer) botBrain {
+	if s.config.BotStyle == BotBoss {
+		b := boss.NewGuildBoss(bot.Name, bot.MaxHP, bot.Strength)
+		// Особые приёмы боссов в PvP не работают: урон считает сервер
+		b.Aggression = 0
+		return bossBrain{boss: b}
+	}
+	return boss.NewAdaptive()
+}
+
+// matchBots - тем, кто ждёт соперника дольше BotWait, выставляет бота. Вызывать под queueMutex после matchQueue
+func (s *ChatServer) matchBots() {
+	if s.config.BotStyle == BotOff {
+		return
+	}
+	now := time.Now()
+	waiting := s.pvpQueue[:0]
+	for _, queued := range s.pvpQueue {
+		if now.Sub(queued.QueuedAt) < s.config.BotWait {
+			waiting = append(waiting, queued)
+			continue
+		}
+		s.startBotMatch(queued)
+	}
+	s.pvpQueue = waiting
+}
+
+// startBotMatch - бой игрока с ботом его же силы. Бот всегда второй игрок. Вызывать под queueMutex
+func (s *ChatServer) startBotMatch(human *PvPPlayer) {
+	s.botCounter++
+	bot := &PvPPlayer{
+		Name:     fmt.Sprintf("%s%s #%d", botPrefix, botNames[rand.Intn(len(botNames))], s.botCounter),
+		HP:       human.MaxHP,
+		MaxHP:    human.MaxHP,
+		Strength: human.Strength,
+		Rating:   human.Rating,
+		QueuedAt: time.Now(),
+		LastSeen: time.Now(),
+	}
+	brain := s.newBotBrain(bot)
+	match := s.createMatch(human, bot, func(m *PvPMatch) {
+		m.Bot = brain
+	})
+	s.metrics.botMatches.Add(1)
+	s.logCh <- fmt.Sprintf("PvP: %s не дождался соперника - в матче %s против него бот %s (%s)",
+		human.Name, match.ID, bot.Name, s.config.BotStyle)
+}
+
+// botTurn - бот отвечает на ход соперника, не подглядывая в него. Вызывать под match.mutex
+func (s *ChatServer) botTurn(match *PvPMatch) {
+	if match.Bot == nil || match.State != MatchActive {
+		return
+	}
+	// Бот всегда на связи, пока бой кто-то опрашивает
+	match.Seen2 = time.Now()
+	if match.Move1 == nil || match.Move2 != nil || match.Over() {
+		return
+	}
+	attack, block := int(match.Bot.ChooseAttack()), int(match.Bot.ChooseBlock())
+	match.Move2 = &MoveData{Attack: attack, Block: block}
+	match.record(EventMove, match.Player2.Name, attack, block)
+}
+
+// botCollect - бот забирает свой итог раунда и запоминает ход соперника. Вызывать под match.mutex
+func botCollect(match *PvPMatch) {
+	if match.Bot == nil || match.ResultForPlayer2 == "" {
+		return
+	}
+	match.Bot.Observe(combat.BodyPart(match.Move1.Attack), combat.BodyPart(match.Move1.Block))
+	match.ResultForPlayer2 = ""
+}
+
+// botWaitLeft - сколько ещё ждать до выхода бота, или -1, если ботов нет
+func (s *ChatServer) botWaitLeft(queued *PvPPlayer) time.Duration {
+	if s.config.BotStyle == BotOff {
+		return -1
+	}
+	return max(s.config.BotWait-time.Since(queued.QueuedAt), 0)
+}
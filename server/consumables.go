@@ -0,0 +1,152 @@
+package server
+
+import (
+	"fmt"
+	"game/combat"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// handlePvPItem - использование расходника в PvP: matchID|player|itemName|block|token.
+// Эффект берётся из каталога и применяется здесь же, предмет занимает ход игрока в раунде.
+// Тратятся расходники из серверного инвентаря игрока, купленные в серверной лавке или полученные на рынке.
+// Ответ: ok:hp|maxHP|осталось_предметов или error:код
+func (s *ChatServer) handlePvPItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	parts := strings.Split(string(body), "|")
+	if len(parts) < 5 {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	matchID, playerName, itemName := parts[0], parts[1], parts[2]
+	block, ok := parseZone(parts[3])
+	if !ok {
+		http.Error(w, "Invalid block", http.StatusBadRequest)
+		return
+	}
+
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[matchID]
+	s.pvpMutex.RUnlock()
+	if !exists {
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	}
+	if !match.owns(playerName, parts[4]) {
+		fmt.Fprint(w, "error:session")
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+
+	if match.State != MatchActive || match.Over() {
+		fmt.Fprint(w, "error:finished")
+		return
+	}
+	if match.Rules.NoItems {
+		fmt.Fprint(w, "error:items_disabled")
+		return
+	}
+
+	var me *PvPPlayer
+	var hp *int
+	var move **MoveData
+	var tracker *combat.ConsumableTracker
+	var enemyStun *int
+	switch playerName {
+	case match.Player1.Name:
+		me, hp, move, tracker, enemyStun = match.Player1, &match.Player1HP, &match.Move1, match.Items1, &match.Stun2
+	case match.Player2.Name:
+		me, hp, move, tracker, enemyStun = match.Player2, &match.Player2HP, &match.Move2, match.Items2, &match.Stun1
+	default:
+		http.Error(w, "Invalid player", http.StatusBadRequest)
+		return
+	}
+
+	if *move != nil {
+		fmt.Fprint(w, "error:move_done")
+		return
+	}
+
+	// Печать мира в PvP не работает: бой с живым соперником миром не заканчивается
+	item := findCatalogItem(itemName)
+	if item != nil && !item.IsConsumable() {
+		item = nil
+	}
+	if item == nil || item.Effect.SpecialEffect == "instant_peace" {
+		fmt.Fprint(w, "error:"+combat.ItemErrUnusable)
+		return
+	}
+	if err := tracker.Check(item, match.Round); err != nil {
+		if ce, ok := err.(*combat.ConsumableError); ok && ce.Code == combat.ItemErrCooldown {
+			fmt.Fprintf(w, "error:%s|%d", ce.Code, ce.Rounds)
+		} else if ok {
+			fmt.Fprint(w, "error:"+ce.Code)
+		}
+		return
+	}
+	if !s.shop.spendConsumable(playerName, item.Name) {
+		fmt.Fprint(w, "error:not_owned")
+		return
+	}
+
+	tracker.Use(item, match.Round)
+	oldHP := *hp
+	if item.Effect.Heal > 0 {
+		*hp = combat.HealTo(*hp, me.MaxHP, item.Effect.Heal)
+	}
+	if item.Effect.StunRounds > *enemyStun {
+		*enemyStun = item.Effect.StunRounds
+	}
+	*move = &MoveData{Attack: int(combat.ItemUsed), Block: block}
+	match.record(EventItem, playerName, item.Name, oldHP, *hp)
+
+	s.logCh <- fmt.Sprintf("PvP: %s использовал %s в раунде %d (HP %d→%d)", playerName, item.Name, match.Round, oldHP, *hp)
+	fmt.Fprintf(w, "ok:%d|%d|%d", *hp, me.MaxHP, tracker.Left())
+}
+
+// applyStuns - оглушённый игрок не наносит урона; каждый рассчитанный раунд снимает одно оглушение
+func (s *ChatServer) applyStuns(match *PvPMatch, damageToPlayer1, damageToPlayer2 int) (int, int) {
+	if match.Stun1 > 0 {
+		damageToPlayer2 = 0
+		match.Stun1--
+		match.record(EventStun, match.Player1.Name)
+		s.logCh <- fmt.Sprintf("PvP: %s оглушён и пропускает атаку", match.Player1.Name)
+	}
+	if match.Stun2 > 0 {
+		damageToPlayer1 = 0
+		match.Stun2--
+		match.record(EventStun, match.Player2.Name)
+		s.logCh <- fmt.Sprintf("PvP: %s оглушён и пропускает атаку", match.Player2.Name)
+	}
+	return damageToPlayer1, damageToPlayer2
+}
+
+// spendConsumable - списывает расходник из серверного инвентаря игрока; false, если его там нет
+func (ss *ServerShop) spendConsumable(player, name string) bool {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	acc, ok := ss.accounts[player]
+	if !ok || acc.Inventory[name] <= 0 {
+		return false
+	}
+	acc.Inventory[name]--
+	if acc.Inventory[name] == 0 {
+		delete(acc.Inventory, name)
+	}
+	ss.record(player, "pvp_item", name, 0, acc.Balance)
+	return true
+}
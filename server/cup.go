@@ -0,0 +1,827 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	cupMaxPlayers    = 32
+	cupDefaultSignup = 5 * time.Minute
+	cupMaxSignup     = time.Hour
+	cupEntryFee      = 50 // Взнос списывается с серверного кошелька, из взносов собирается призовой фонд
+)
+
+// Доли призового фонда за первые места, в процентах
+var cupPrizeShares = []int{50, 30, 20}
+
+// CupFormat - система проведения турнира
+type CupFormat string
+
+const (
+	CupSingle CupFormat = "single" // олимпийская: одно поражение - вылет
+	CupDouble CupFormat = "double" // до двух поражений, с нижней сеткой
+	CupSwiss  CupFormat = "swiss"  // швейцарская: все играют заданное число туров
+)
+
+type CupState string
+
+const (
+	CupSignup    CupState = "signup"
+	CupRunning   CupState = "running"
+	CupFinished  CupState = "finished"
+	CupCancelled CupState = "cancelled"
+)
+
+// Ветки сетки в парах
+const (
+	groupWinners = "W" // верхняя сетка (и вся сетка олимпийской системы)
+	groupLosers  = "L" // нижняя сетка: одно поражение
+	groupFinal   = "F" // финал победителя верхней сетки с победителем нижней
+	groupSwiss   = "S"
+)
+
+// CupEntrant - участник турнира
+type CupEntrant struct {
+	Player    *PvPPlayer
+	Seed      int // посев по рейтингу на старте, 1 - сильнейший
+	Wins      int
+	Losses    int
+	Draws     int
+	Byes      int
+	Points    float64 // очки швейцарской системы: победа и пропуск тура - 1, ничья - 0.5
+	OutRound  int     // тур, в котором участник выбыл; 0 - ещё в турнире
+	Played    int     // сыгранные бои с результатом; пары, где бой брошен, не в счёт
+	Opponents []string
+}
+
+// CupPairing - пара тура. Пустой Player2 - пропуск тура
+type CupPairing struct {
+	Round   int
+	Group   string
+	Player1 string
+	Player2 string
+	MatchID string
+	Winner  string // пусто - ничья
+	Done    bool
+}
+
+// Cup - PvP-турнир, который проводит сервер
+type Cup struct {
+	ID           string
+	Title        string
+	Organizer    string
+	Format       CupFormat
+	State        CupState
+	MaxPlayers   int
+	SignupEndsAt time.Time
+	Round        int
+	SwissRounds  int
+	Entrants     []*CupEntrant
+	Pairings     []*CupPairing
+	Order        []string // порядок участников в сетке выбывания
+	Places       []string
+	Prizes       []int
+	Pool         int // призовой фонд - сумма взносов
+}
+
+func (c *Cup) entrant(name string) *CupEntrant {
+	for _, e := range c.Entrants {
+		if e.Player.Name == name {
+			return e
+		}
+	}
+	return nil
+}
+
+// maxLosses - после скольких поражений участник выбывает
+func (c *Cup) maxLosses() int {
+	if c.Format == CupDouble {
+		return 2
+	}
+	return 1
+}
+
+// roundPairings - пары текущего тура
+func (c *Cup) roundPairings() []*CupPairing {
+	pairings := make([]*CupPairing, 0)
+	for _, p := range c.Pairings {
+		if p.Round == c.Round {
+			pairings = append(pairings, p)
+		}
+	}
+	return pairings
+}
+
+// alive - участники сетки выбывания, ещё не выбывшие, в порядке сетки
+func (c *Cup) alive(losses int) []string {
+	names := make([]string, 0)
+	for _, name := range c.Order {
+		if e := c.entrant(name); e.OutRound == 0 && (losses < 0 || e.Losses == losses) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Cup) complete() bool {
+	if c.Format == CupSwiss {
+		return c.Round >= c.SwissRounds
+	}
+	return len(c.alive(-1)) <= 1
+}
+
+// tickCups - продвигает все турниры: закрывает запись, собирает итоги боёв, начинает туры.
+// Вызывается уборщиком и при каждом запросе к турнирам
+func (s *ChatServer) tickCups() {
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+	for _, cup := range s.cups {
+		s.tickCup(cup)
+	}
+}
+
+// tickCup - вызывать под cupsMutex
+func (s *ChatServer) tickCup(cup *Cup) {
+	switch cup.State {
+	case CupSignup:
+		if time.Now().After(cup.SignupEndsAt) || len(cup.Entrants) >= cup.MaxPlayers {
+			s.startCup(cup)
+		}
+	case CupRunning:
+		pending := false
+		for _, pairing := range cup.roundPairings() {
+			if pairing.Done {
+				continue
+			}
+			if pairing.MatchID == "" {
+				s.startCupMatch(cup, pairing)
+			} else {
+				s.collectCupMatch(cup, pairing)
+			}
+			pending = pending || !pairing.Done
+		}
+		if pending {
+			return
+		}
+		if cup.Format != CupSwiss {
+			cup.rebuildOrder()
+		}
+		if cup.complete() {
+			s.finishCup(cup)
+			return
+		}
+		s.nextCupRound(cup)
+	}
+}
+
+func (s *ChatServer) startCup(cup *Cup) {
+	if len(cup.Entrants) < 2 {
+		for _, e := range cup.Entrants {
+			s.shop.Credit(e.Player.Name, cupEntryFee, "cup refund")
+		}
+		cup.Pool = 0
+		cup.State = CupCancelled
+		s.logCh <- fmt.Sprintf("Турнир %s «%s» отменён: не набралось участников", cup.ID, cup.Title)
+		return
+	}
+
+	sort.SliceStable(cup.Entrants, func(i, j int) bool {
+		return cup.Entrants[i].Player.Rating > cup.Entrants[j].Player.Rating
+	})
+	cup.Order = make([]string, len(cup.Entrants))
+	for i, e := range cup.Entrants {
+		e.Seed = i + 1
+		cup.Order[i] = e.Player.Name
+	}
+	cup.SwissRounds = int(math.Ceil(math.Log2(float64(len(cup.Entrants)))))
+	cup.State = CupRunning
+	s.logCh <- fmt.Sprintf("Турнир %s «%s» начался: участников %d, система %s", cup.ID, cup.Title, len(cup.Entrants), cup.Format)
+	s.nextCupRound(cup)
+}
+
+// nextCupRound - составляет пары нового тура, пропуски засчитываются сразу, бои создаются
+func (s *ChatServer) nextCupRound(cup *Cup) {
+	cup.Round++
+	var pairings []*CupPairing
+	switch {
+	case cup.Format == CupSwiss:
+		pairings = cup.pairSwiss()
+	case cup.Round == 1:
+		pairings = cup.pairFirstRound()
+	default:
+		pairings = cup.pairElimination()
+	}
+	cup.Pairings = append(cup.Pairings, pairings...)
+
+	for _, pairing := range pairings {
+		if pairing.Player2 == "" {
+			e := cup.entrant(pairing.Player1)
+			e.Byes++
+			e.Points++
+			pairing.Winner = pairing.Player1
+			pairing.Done = true
+			continue
+		}
+		s.startCupMatch(cup, pairing)
+	}
+	s.logCh <- fmt.Sprintf("Турнир %s: тур %d, пар: %d", cup.ID, cup.Round, len(pairings))
+}
+
+// bracketOrder - расстановка посева в сетке на size мест: 1, 8, 4, 5, 2, 7, 3, 6 и т.д.,
+// чтобы сильнейшие встречались как можно позже
+func bracketOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, seed := range order {
+			next = append(next, seed, len(order)*2+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// pairFirstRound - первый тур сетки выбывания: сильные посевы получают пропуски
+func (c *Cup) pairFirstRound() []*CupPairing {
+	size := 1
+	for size < len(c.Order) {
+		size *= 2
+	}
+	slot := func(seed int) string {
+		if seed > len(c.Order) {
+			return ""
+		}
+		return c.Order[seed-1]
+	}
+
+	order := bracketOrder(size)
+	pairings := make([]*CupPairing, 0, size/2)
+	for i := 0; i < size; i += 2 {
+		pairings = append(pairings, &CupPairing{Round: c.Round, Group: groupWinners, Player1: slot(order[i]), Player2: slot(order[i+1])})
+	}
+	return pairings
+}
+
+// pairElimination - следующий тур сетки выбывания. В двойной системе верхняя и нижняя сетки
+// играют отдельно, а когда в каждой остаётся по одному, их победители встречаются в финале.
+// Если в финале проиграет непобеждённый, у обоих станет по поражению и финал переиграется
+func (c *Cup) pairElimination() []*CupPairing {
+	winners := c.alive(0)
+	if c.Format != CupDouble {
+		return c.pairGroup(winners, groupWinners)
+	}
+	losers := c.alive(1)
+	if len(winners) == 1 && len(losers) == 1 {
+		return []*CupPairing{{Round: c.Round, Group: groupFinal, Player1: winners[0], Player2: losers[0]}}
+	}
+	if len(winners) == 0 && len(losers) == 2 {
+		return []*CupPairing{{Round: c.Round, Group: groupFinal, Player1: losers[0], Player2: losers[1]}}
+	}
+	return append(c.pairGroup(winners, groupWinners), c.pairGroup(losers, groupLosers)...)
+}
+
+// pairGroup - соседние по сетке играют друг с другом, лишний получает пропуск тура
+func (c *Cup) pairGroup(names []string, group string) []*CupPairing {
+	pairings := make([]*CupPairing, 0)
+	if len(names)%2 == 1 {
+		bye := 0
+		for i, name := range names {
+			if c.entrant(name).Byes < c.entrant(names[bye]).Byes {
+				bye = i
+			}
+		}
+		pairings = append(pairings, &CupPairing{Round: c.Round, Group: group, Player1: names[bye]})
+		names = append(append([]string{}, names[:bye]...), names[bye+1:]...)
+	}
+	for i := 0; i+1 < len(names); i += 2 {
+		pairings = append(pairings, &CupPairing{Round: c.Round, Group: group, Player1: names[i], Player2: names[i+1]})
+	}
+	return pairings
+}
+
+// rebuildOrder - после тура победители пар встают по порядку, проигравшие (если не выбыли) - в конец
+func (c *Cup) rebuildOrder() {
+	order := make([]string, 0, len(c.Order))
+	dropped := make([]string, 0)
+	for _, pairing := range c.roundPairings() {
+		loser := pairing.Player1
+		if pairing.Winner == pairing.Player1 {
+			loser = pairing.Player2
+		}
+		order = append(order, pairing.Winner)
+		if loser != "" && c.entrant(loser).OutRound == 0 {
+			dropped = append(dropped, loser)
+		}
+	}
+	c.Order = append(order, dropped...)
+}
+
+// standings - участники по очкам, затем по сумме очков соперников (Бухгольц), затем по посеву
+func (c *Cup) standings() []*CupEntrant {
+	buchholz := make(map[string]float64)
+	for _, e := range c.Entrants {
+		for _, opponent := range e.Opponents {
+			buchholz[e.Player.Name] += c.entrant(opponent).Points
+		}
+	}
+	list := append([]*CupEntrant{}, c.Entrants...)
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if buchholz[a.Player.Name] != buchholz[b.Player.Name] {
+			return buchholz[a.Player.Name] > buchholz[b.Player.Name]
+		}
+		return a.Seed < b.Seed
+	})
+	return list
+}
+
+// pairSwiss - пары швейцарской системы: соседи по таблице, без повторных встреч, если это возможно
+func (c *Cup) pairSwiss() []*CupPairing {
+	table := c.standings()
+	pairings := make([]*CupPairing, 0)
+	if len(table)%2 == 1 {
+		// Пропуск тура получает самый слабый из тех, кто ещё не пропускал
+		bye := len(table) - 1
+		for i := len(table) - 1; i >= 0; i-- {
+			if table[i].Byes == 0 {
+				bye = i
+				break
+			}
+		}
+		pairings = append(pairings, &CupPairing{Round: c.Round, Group: groupSwiss, Player1: table[bye].Player.Name})
+		table = append(table[:bye], table[bye+1:]...)
+	}
+
+	if pairs, ok := swissPairs(table, make([]bool, len(table))); ok {
+		for _, pair := range pairs {
+			pairings = append(pairings, &CupPairing{Round: c.Round, Group: groupSwiss, Player1: table[pair[0]].Player.Name, Player2: table[pair[1]].Player.Name})
+		}
+		return pairings
+	}
+
+	// Без повторов разбить не удалось - соседи по таблице, повторы только там, где иначе нельзя
+	paired := make([]bool, len(table))
+	for i := range table {
+		if paired[i] {
+			continue
+		}
+		partner := -1
+		for j := i + 1; j < len(table); j++ {
+			if paired[j] {
+				continue
+			}
+			if partner < 0 {
+				partner = j
+			}
+			if !contains(table[i].Opponents, table[j].Player.Name) {
+				partner = j
+				break
+			}
+		}
+		if partner < 0 {
+			continue
+		}
+		paired[i], paired[partner] = true, true
+		pairings = append(pairings, &CupPairing{Round: c.Round, Group: groupSwiss, Player1: table[i].Player.Name, Player2: table[partner].Player.Name})
+	}
+	return pairings
+}
+
+// swissPairs - разбиение таблицы на пары без повторных встреч: сверху вниз, с перебором,
+// если жадный выбор соседа оставляет кого-то без нового соперника
+func swissPairs(table []*CupEntrant, paired []bool) ([][2]int, bool) {
+	i := 0
+	for i < len(table) && paired[i] {
+		i++
+	}
+	if i == len(table) {
+		return nil, true
+	}
+	for j := i + 1; j < len(table); j++ {
+		if paired[j] || contains(table[i].Opponents, table[j].Player.Name) {
+			continue
+		}
+		paired[i], paired[j] = true, true
+		if rest, ok := swissPairs(table, paired); ok {
+			return append([][2]int{{i, j}}, rest...), true
+		}
+		paired[i], paired[j] = false, false
+	}
+	return nil, false
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+// startCupMatch - создаёт бой пары через обычную PvP-механику. Если кто-то из пары ещё в другом бою,
+// пара подождёт следующей проверки
+func (s *ChatServer) startCupMatch(cup *Cup, pairing *CupPairing) {
+	if s.shuttingDown.Load() || s.findMatch(pairing.Player1) != nil || s.findMatch(pairing.Player2) != nil {
+		return
+	}
+
+	s.queueMutex.Lock()
+	for _, name := range []string{pairing.Player1, pairing.Player2} {
+		if i := s.queueIndex(name); i >= 0 {
+			s.pvpQueue = append(s.pvpQueue[:i], s.pvpQueue[i+1:]...)
+		}
+	}
+	s.queueMutex.Unlock()
+
+	// Каждый бой турнира начинается с полным здоровьем
+	fighters := make([]*PvPPlayer, 2)
+	for i, name := range []string{pairing.Player1, pairing.Player2} {
+		fighter := *cup.entrant(name).Player
+		fighter.HP = fighter.MaxHP
+		fighter.Rating = s.ratings.Get(name)
+		fighters[i] = &fighter
+	}
+	match := s.createMatch(fighters[0], fighters[1], func(match *PvPMatch) {
+		match.CupID = cup.ID
+	})
+	pairing.MatchID = match.ID
+}
+
+// collectCupMatch - переносит итог закончившегося боя в турнир
+func (s *ChatServer) collectCupMatch(cup *Cup, pairing *CupPairing) {
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[pairing.MatchID]
+	s.pvpMutex.RUnlock()
+
+	winner := ""
+	played := false
+	if exists {
+		match.mutex.RLock()
+		state := match.State
+		if state == MatchFinished {
+			winner = match.Winner()
+			played = true
+		}
+		match.mutex.RUnlock()
+		if state == MatchActive {
+			return
+		}
+	}
+	if played {
+		cup.entrant(pairing.Player1).Played++
+		cup.entrant(pairing.Player2).Played++
+	}
+	s.resolvePairing(cup, pairing, winner)
+}
+
+// resolvePairing - записывает итог пары. Пустой winner - ничья или бой без результата;
+// в сетке выбывания ничьих нет, и дальше проходит участник с более высоким посевом
+func (s *ChatServer) resolvePairing(cup *Cup, pairing *CupPairing, winner string) {
+	first, second := cup.entrant(pairing.Player1), cup.entrant(pairing.Player2)
+	first.Opponents = append(first.Opponents, second.Player.Name)
+	second.Opponents = append(second.Opponents, first.Player.Name)
+
+	if winner == "" && cup.Format == CupSwiss {
+		first.Draws++
+		second.Draws++
+		first.Points += 0.5
+		second.Points += 0.5
+		pairing.Done = true
+		s.logCh <- fmt.Sprintf("Турнир %s: %s и %s сыграли вничью", cup.ID, first.Player.Name, second.Player.Name)
+		return
+	}
+	if winner == "" {
+		winner = first.Player.Name
+		if second.Seed < first.Seed {
+			winner = second.Player.Name
+		}
+	}
+
+	won, lost := first, second
+	if winner == second.Player.Name {
+		won, lost = second, first
+	}
+	won.Wins++
+	won.Points++
+	lost.Losses++
+	if cup.Format != CupSwiss && lost.Losses >= cup.maxLosses() {
+		lost.OutRound = cup.Round
+	}
+	pairing.Winner = winner
+	pairing.Done = true
+	s.logCh <- fmt.Sprintf("Турнир %s: %s победил %s", cup.ID, won.Player.Name, lost.Player.Name)
+}
+
+// finishCup - итоговые места и призы в серверные кошельки. Призовые доли получают только те,
+// кто сыграл хотя бы один бой с результатом: место, добытое лишь брошенными боями, не оплачивается
+// и его доля переходит следующему по таблице
+func (s *ChatServer) finishCup(cup *Cup) {
+	var table []*CupEntrant
+	if cup.Format == CupSwiss {
+		table = cup.standings()
+	} else {
+		// Чем позже выбыл, тем выше место; победитель не выбывал вовсе
+		table = append([]*CupEntrant{}, cup.Entrants...)
+		sort.SliceStable(table, func(i, j int) bool {
+			a, b := table[i], table[j]
+			if (a.OutRound == 0) != (b.OutRound == 0) {
+				return a.OutRound == 0
+			}
+			if a.OutRound != b.OutRound {
+				return a.OutRound > b.OutRound
+			}
+			if a.Wins != b.Wins {
+				return a.Wins > b.Wins
+			}
+			return a.Seed < b.Seed
+		})
+	}
+
+	pool := cup.Pool
+	cup.Places = make([]string, len(table))
+	cup.Prizes = make([]int, len(table))
+	paid, shares, first := 0, 0, -1
+	for i, e := range table {
+		cup.Places[i] = e.Player.Name
+		if e.Played == 0 || shares >= len(cupPrizeShares) {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		cup.Prizes[i] = pool * cupPrizeShares[shares] / 100
+		paid += cup.Prizes[i]
+		shares++
+	}
+	if first < 0 {
+		// Ни одного сыгранного боя - турнир не состоялся, взносы возвращаются
+		for i, e := range table {
+			cup.Prizes[i] = 0
+			s.shop.Credit(e.Player.Name, cupEntryFee, "cup refund")
+		}
+		cup.State = CupFinished
+		s.logCh <- fmt.Sprintf("Турнир %s «%s» завершён без сыгранных боёв, взносы возвращены", cup.ID, cup.Title)
+		return
+	}
+	// Доли незанятых призовых мест и остаток от округления достаются лучшему из сыгравших
+	cup.Prizes[first] += pool - paid
+
+	for i, name := range cup.Places {
+		if cup.Prizes[i] > 0 {
+			s.shop.Credit(name, cup.Prizes[i], "cup")
+		}
+	}
+	cup.State = CupFinished
+	s.logCh <- fmt.Sprintf("Турнир %s «%s» завершён, победитель %s, лучший приз %s (%d)", cup.ID, cup.Title, cup.Places[0], cup.Places[first], cup.Prizes[first])
+}
+
+// refundOpenCups - турниры живут только в памяти сервера, поэтому при остановке недоигранные
+// отменяются, а взносы возвращаются в кошельки участников
+func (s *ChatServer) refundOpenCups() {
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+	for _, cup := range s.cups {
+		if cup.State != CupSignup && cup.State != CupRunning {
+			continue
+		}
+		for _, e := range cup.Entrants {
+			s.shop.Credit(e.Player.Name, cupEntryFee, "cup refund")
+		}
+		cup.Pool = 0
+		cup.State = CupCancelled
+		s.logCh <- fmt.Sprintf("Турнир %s «%s» отменён остановкой сервера, взносы возвращены", cup.ID, cup.Title)
+	}
+}
+
+// handleCupList - турниры: строки id|название|система|состояние|участников|максимум|конец записи (unix)|организатор
+func (s *ChatServer) handleCupList(w http.ResponseWriter, r *http.Request) {
+	s.tickCups()
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+
+	ids := make([]string, 0, len(s.cups))
+	for id := range s.cups {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return s.cups[ids[i]].SignupEndsAt.After(s.cups[ids[j]].SignupEndsAt)
+	})
+	for _, id := range ids {
+		cup := s.cups[id]
+		fmt.Fprintf(w, "%s|%s|%s|%s|%d|%d|%d|%s\n", cup.ID, cup.Title, cup.Format, cup.State,
+			len(cup.Entrants), cup.MaxPlayers, cup.SignupEndsAt.Unix(), cup.Organizer)
+	}
+}
+
+// handleCupCreate - новый турнир: организатор|название|система|секунд на запись|максимум участников.
+// Ответ: ok:id или error:format|signup|players|shutdown
+func (s *ChatServer) handleCupCreate(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 5)
+	if !ok {
+		return
+	}
+	if s.shuttingDown.Load() {
+		fmt.Fprint(w, "error:shutdown")
+		return
+	}
+
+	format := CupFormat(parts[2])
+	if format != CupSingle && format != CupDouble && format != CupSwiss {
+		fmt.Fprint(w, "error:format")
+		return
+	}
+	signup := cupDefaultSignup
+	if seconds, err := strconv.Atoi(parts[3]); err == nil && seconds > 0 {
+		signup = time.Duration(seconds) * time.Second
+	}
+	if signup > cupMaxSignup {
+		fmt.Fprint(w, "error:signup")
+		return
+	}
+	maxPlayers, err := strconv.Atoi(parts[4])
+	if err != nil || maxPlayers < 2 || maxPlayers > cupMaxPlayers {
+		fmt.Fprint(w, "error:players")
+		return
+	}
+	title := parts[1]
+	if title == "" {
+		title = "Кубок " + parts[0]
+	}
+
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+	s.cupCounter++
+	cup := &Cup{
+		ID:           fmt.Sprintf("cup_%d", s.cupCounter),
+		Title:        title,
+		Organizer:    parts[0],
+		Format:       format,
+		State:        CupSignup,
+		MaxPlayers:   maxPlayers,
+		SignupEndsAt: time.Now().Add(signup),
+	}
+	s.cups[cup.ID] = cup
+	s.logCh <- fmt.Sprintf("Турнир %s «%s» (%s) открыт для записи организатором %s", cup.ID, cup.Title, cup.Format, cup.Organizer)
+	fmt.Fprint(w, "ok:"+cup.ID)
+}
+
+// handleCupJoin - запись: id|Имя|HP|MaxHP|Сила|номера надетого снаряжения. Взнос списывается с серверного кошелька.
+// Ответ: ok|взнос|токен сессии для боёв турнира или error:not_found|closed|full|exists|bot_name|not_enough
+func (s *ChatServer) handleCupJoin(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 5)
+	if !ok {
+		return
+	}
+	player, err := s.parsePvPPlayer(parts[1:])
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+	cup, exists := s.cups[parts[0]]
+	switch {
+	case !exists:
+		fmt.Fprint(w, "error:not_found")
+	case cup.State != CupSignup:
+		fmt.Fprint(w, "error:closed")
+	case cup.entrant(player.Name) != nil:
+		fmt.Fprint(w, "error:exists")
+	case len(cup.Entrants) >= cup.MaxPlayers:
+		fmt.Fprint(w, "error:full")
+	default:
+		if err := s.shop.Charge(player.Name, cupEntryFee, "cup entry"); err != nil {
+			fmt.Fprint(w, "error:"+err.Error())
+			return
+		}
+		cup.Pool += cupEntryFee
+		cup.Entrants = append(cup.Entrants, &CupEntrant{Player: player})
+		s.logCh <- fmt.Sprintf("Турнир %s: записался %s (%d/%d)", cup.ID, player.Name, len(cup.Entrants), cup.MaxPlayers)
+		fmt.Fprintf(w, "ok|%d|%s", cupEntryFee, player.Token)
+		// Полный состав - можно начинать, не дожидаясь конца записи
+		s.tickCup(cup)
+	}
+}
+
+// handleCupLeave - отказ от участия до начала, взнос возвращается: id|игрок
+func (s *ChatServer) handleCupLeave(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+	cup, exists := s.cups[parts[0]]
+	if !exists {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+	if cup.State != CupSignup {
+		fmt.Fprint(w, "error:closed")
+		return
+	}
+	for i, e := range cup.Entrants {
+		if e.Player.Name == parts[1] {
+			cup.Entrants = append(cup.Entrants[:i], cup.Entrants[i+1:]...)
+			cup.Pool -= cupEntryFee
+			s.shop.Credit(e.Player.Name, cupEntryFee, "cup refund")
+			fmt.Fprint(w, "ok")
+			return
+		}
+	}
+	fmt.Fprint(w, "error:not_entrant")
+}
+
+// handleCupBracket - сетка турнира. Строки:
+// cup:id|название|система|состояние|тур|туров (швейцарская)|конец записи|призовой фонд
+// entrant:посев|имя|рейтинг|победы|поражения|ничьи|очки|тур вылета
+// pair:тур|ветка|игрок1|игрок2|победитель|матч|сыграна (1/0)
+// place:место|имя|приз
+func (s *ChatServer) handleCupBracket(w http.ResponseWriter, r *http.Request) {
+	s.tickCups()
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+
+	cup, exists := s.cups[r.URL.Query().Get("id")]
+	if !exists {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+
+	fmt.Fprintf(w, "cup:%s|%s|%s|%s|%d|%d|%d|%d\n", cup.ID, cup.Title, cup.Format, cup.State,
+		cup.Round, cup.SwissRounds, cup.SignupEndsAt.Unix(), cup.Pool)
+	for _, e := range cup.Entrants {
+		fmt.Fprintf(w, "entrant:%d|%s|%d|%d|%d|%d|%g|%d\n", e.Seed, e.Player.Name, e.Player.Rating,
+			e.Wins, e.Losses, e.Draws, e.Points, e.OutRound)
+	}
+	for _, p := range cup.Pairings {
+		done := 0
+		if p.Done {
+			done = 1
+		}
+		fmt.Fprintf(w, "pair:%d|%s|%s|%s|%s|%s|%d\n", p.Round, p.Group, p.Player1, p.Player2, p.Winner, p.MatchID, done)
+	}
+	for i, name := range cup.Places {
+		fmt.Fprintf(w, "place:%d|%s|%d\n", i+1, name, cup.Prizes[i])
+	}
+}
+
+// handleCupStatus - что сейчас делать участнику: ?id=&player=. Ответ:
+// signup:участников|максимум|секунд до старта, match:... (как у /pvp/status), wait:тур,
+// eliminated:тур, finished:место|приз, cancelled или error:not_found|not_entrant
+func (s *ChatServer) handleCupStatus(w http.ResponseWriter, r *http.Request) {
+	s.tickCups()
+	s.cupsMutex.Lock()
+	defer s.cupsMutex.Unlock()
+
+	playerName := r.URL.Query().Get("player")
+	cup, exists := s.cups[r.URL.Query().Get("id")]
+	if !exists {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+	me := cup.entrant(playerName)
+	if me == nil {
+		fmt.Fprint(w, "error:not_entrant")
+		return
+	}
+
+	switch cup.State {
+	case CupSignup:
+		fmt.Fprintf(w, "signup:%d|%d|%d", len(cup.Entrants), cup.MaxPlayers, int(time.Until(cup.SignupEndsAt).Seconds()))
+	case CupCancelled:
+		fmt.Fprint(w, "cancelled")
+	case CupFinished:
+		for i, name := range cup.Places {
+			if name == playerName {
+				fmt.Fprintf(w, "finished:%d|%d", i+1, cup.Prizes[i])
+			}
+		}
+	default:
+		if me.OutRound > 0 {
+			fmt.Fprintf(w, "eliminated:%d", me.OutRound)
+			return
+		}
+		for _, pairing := range cup.roundPairings() {
+			if pairing.Done || pairing.MatchID == "" || (pairing.Player1 != playerName && pairing.Player2 != playerName) {
+				continue
+			}
+			if match := s.findMatch(playerName); match != nil && match.ID == pairing.MatchID {
+				fmt.Fprint(w, matchInfo(match, playerName))
+				return
+			}
+		}
+		fmt.Fprintf(w, "wait:%d", cup.Round)
+	}
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newTestCup - турнир после закрытия записи: участники p1..pn, посев по номеру
+func newTestCup(format CupFormat, n int) *Cup {
+	cup := &Cup{ID: "cup_test", Format: format, State: CupRunning}
+	for i := 1; i <= n; i++ {
+		name := fmt.Sprintf("p%d", i)
+		cup.Entrants = append(cup.Entrants, &CupEntrant{Player: &PvPPlayer{Name: name, MaxHP: 100}, Seed: i})
+		cup.Order = append(cup.Order, name)
+	}
+	cup.SwissRounds = int(math.Ceil(math.Log2(float64(n))))
+	return cup
+}
+
+// newTestServer - сервер без HTTP и лавки: журнал вычитывается и выбрасывается,
+// записи боёв уходят во временную папку теста
+func newTestServer(t *testing.T) *ChatServer {
+	s := &ChatServer{
+		logCh:      make(chan string, 1),
+		pvpMatches: make(map[string]*PvPMatch),
+		ratings:    &Ratings{players: make(map[string]*PlayerRating)},
+		replays:    NewReplayArchive(t.TempDir()),
+		metrics:    newMatchMetrics(),
+	}
+	go func() {
+		for range s.logCh {
+		}
+	}()
+	return s
+}
+
+// playRound - начинает тур настоящим nextCupRound и доигрывает его бои: в каждом побеждает
+// участник с более высоким посевом, итог забирает collectCupMatch, как это делает уборщик
+func playRound(t *testing.T, s *ChatServer, cup *Cup) []*CupPairing {
+	t.Helper()
+	s.nextCupRound(cup)
+	pairings := cup.roundPairings()
+
+	seen := make(map[string]bool)
+	for _, pairing := range pairings {
+		for _, name := range []string{pairing.Player1, pairing.Player2} {
+			if name == "" {
+				continue
+			}
+			if seen[name] {
+				t.Fatalf("тур %d: %s попал в две пары", cup.Round, name)
+			}
+			seen[name] = true
+		}
+		if pairing.Player1 == "" {
+			t.Fatalf("тур %d: пара без первого участника", cup.Round)
+		}
+		if pairing.Player2 == "" {
+			if !pairing.Done || pairing.Winner != pairing.Player1 {
+				t.Fatalf("тур %d: пропуск %s не засчитан", cup.Round, pairing.Player1)
+			}
+			continue
+		}
+		finishCupMatch(t, s, cup, pairing)
+	}
+	if cup.Format != CupSwiss {
+		cup.rebuildOrder()
+	}
+	return pairings
+}
+
+// finishCupMatch - заканчивает бой пары победой более высокого посева и переносит итог в турнир
+func finishCupMatch(t *testing.T, s *ChatServer, cup *Cup, pairing *CupPairing) {
+	t.Helper()
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[pairing.MatchID]
+	s.pvpMutex.RUnlock()
+	if !exists {
+		t.Fatalf("тур %d: бой пары %s - %s не создан", cup.Round, pairing.Player1, pairing.Player2)
+	}
+
+	match.mutex.Lock()
+	if cup.entrant(pairing.Player2).Seed < cup.entrant(pairing.Player1).Seed {
+		match.Player1HP = 0
+	} else {
+		match.Player2HP = 0
+	}
+	s.setMatchState(match, MatchFinished)
+	match.mutex.Unlock()
+
+	s.collectCupMatch(cup, pairing)
+	if !pairing.Done {
+		t.Fatalf("тур %d: итог пары %s - %s не засчитан", cup.Round, pairing.Player1, pairing.Player2)
+	}
+}
+
+func TestBracketOrder(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+	}{
+		{1, []int{1}},
+		{2, []int{1, 2}},
+		{4, []int{1, 4, 2, 3}},
+		{8, []int{1, 8, 4, 5, 2, 7, 3, 6}},
+	}
+	for _, tt := range tests {
+		if got := bracketOrder(tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("bracketOrder(%d) = %v, ожидалось %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestPairFirstRound(t *testing.T) {
+	tests := []struct {
+		n    int
+		want [][2]string
+	}{
+		{3, [][2]string{{"p1", ""}, {"p2", "p3"}}},
+		{4, [][2]string{{"p1", "p4"}, {"p2", "p3"}}},
+		{5, [][2]string{{"p1", ""}, {"p4", "p5"}, {"p2", ""}, {"p3", ""}}},
+		{8, [][2]string{{"p1", "p8"}, {"p4", "p5"}, {"p2", "p7"}, {"p3", "p6"}}},
+	}
+	for _, tt := range tests {
+		cup := newTestCup(CupSingle, tt.n)
+		cup.Round = 1
+		got := make([][2]string, 0)
+		for _, pairing := range cup.pairFirstRound() {
+			got = append(got, [2]string{pairing.Player1, pairing.Player2})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%d участников: пары %v, ожидалось %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestRebuildOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   CupFormat
+		pairings [][3]string // игрок1, игрок2, победитель
+		want     []string
+	}{
+		{"олимпийская, 4", CupSingle, [][3]string{{"p1", "p4", "p1"}, {"p2", "p3", "p3"}}, []string{"p1", "p3"}},
+		{"олимпийская, пропуск", CupSingle, [][3]string{{"p1", "", "p1"}, {"p2", "p3", "p2"}}, []string{"p1", "p2"}},
+		{"двойная, 4", CupDouble, [][3]string{{"p1", "p4", "p1"}, {"p2", "p3", "p3"}}, []string{"p1", "p3", "p4", "p2"}},
+		{"двойная, 5", CupDouble, [][3]string{{"p1", "", "p1"}, {"p4", "p5", "p5"}, {"p2", "", "p2"}, {"p3", "", "p3"}}, []string{"p1", "p5", "p2", "p3", "p4"}},
+	}
+	s := newTestServer(t)
+	for _, tt := range tests {
+		cup := newTestCup(tt.format, 5)
+		cup.Round = 1
+		for _, p := range tt.pairings {
+			pairing := &CupPairing{Round: 1, Group: groupWinners, Player1: p[0], Player2: p[1]}
+			cup.Pairings = append(cup.Pairings, pairing)
+			if p[1] == "" {
+				pairing.Winner, pairing.Done = p[0], true
+				continue
+			}
+			s.resolvePairing(cup, pairing, p[2])
+		}
+		cup.rebuildOrder()
+		if !reflect.DeepEqual(cup.Order, tt.want) {
+			t.Errorf("%s: порядок %v, ожидалось %v", tt.name, cup.Order, tt.want)
+		}
+	}
+}
+
+func TestPairElimination(t *testing.T) {
+	tests := []struct {
+		format CupFormat
+		n      int
+		rounds int // сколько туров до победителя, если всегда побеждает сильнейший посев
+	}{
+		{CupSingle, 3, 2},
+		{CupSingle, 4, 2},
+		{CupSingle, 5, 3},
+		{CupSingle, 8, 3},
+		{CupDouble, 3, 4},
+		{CupDouble, 4, 4},
+		{CupDouble, 5, 6},
+		{CupDouble, 8, 6},
+	}
+	s := newTestServer(t)
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", tt.format, tt.n), func(t *testing.T) {
+			cup := newTestCup(tt.format, tt.n)
+			for !cup.complete() {
+				if cup.Round > 2*tt.n {
+					t.Fatalf("турнир не закончился за %d туров", cup.Round)
+				}
+				pairings := playRound(t, s, cup)
+				if len(pairings) == 0 {
+					t.Fatalf("тур %d без пар", cup.Round)
+				}
+				for _, pairing := range pairings {
+					if tt.format == CupSingle && pairing.Group != groupWinners {
+						t.Errorf("тур %d: ветка %s в олимпийской системе", cup.Round, pairing.Group)
+					}
+				}
+			}
+			if cup.Round != tt.rounds {
+				t.Errorf("туров %d, ожидалось %d", cup.Round, tt.rounds)
+			}
+			if alive := cup.alive(-1); len(alive) != 1 || alive[0] != "p1" {
+				t.Errorf("в турнире остались %v, ожидался p1", alive)
+			}
+			for _, e := range cup.Entrants {
+				if e.Player.Name == "p1" {
+					if e.Losses != 0 {
+						t.Errorf("у победителя %d поражений", e.Losses)
+					}
+					continue
+				}
+				if e.Losses != cup.maxLosses() {
+					t.Errorf("%s выбыл с %d поражениями, ожидалось %d", e.Player.Name, e.Losses, cup.maxLosses())
+				}
+			}
+		})
+	}
+}
+
+func TestPairSwiss(t *testing.T) {
+	for _, n := range []int{3, 4, 5, 8} {
+		t.Run(fmt.Sprintf("swiss/%d", n), func(t *testing.T) {
+			s := newTestServer(t)
+			cup := newTestCup(CupSwiss, n)
+			matches, byes := 0, 0
+			for !cup.complete() {
+				for _, pairing := range playRound(t, s, cup) {
+					if pairing.Player2 == "" {
+						byes++
+					} else {
+						matches++
+					}
+				}
+				if got := len(cup.roundPairings()); got != (n+1)/2 {
+					t.Errorf("тур %d: пар %d, ожидалось %d", cup.Round, got, (n+1)/2)
+				}
+			}
+			if cup.Round != cup.SwissRounds {
+				t.Errorf("туров %d, ожидалось %d", cup.Round, cup.SwissRounds)
+			}
+
+			points := 0.0
+			for _, e := range cup.Entrants {
+				points += e.Points
+				if e.Byes > 1 {
+					t.Errorf("%s пропустил %d тура", e.Player.Name, e.Byes)
+				}
+				met := make(map[string]bool)
+				for _, opponent := range e.Opponents {
+					if met[opponent] {
+						t.Errorf("%s дважды встретился с %s", e.Player.Name, opponent)
+					}
+					met[opponent] = true
+				}
+			}
+			if points != float64(matches+byes) {
+				t.Errorf("очков %g, ожидалось %d", points, matches+byes)
+			}
+			if leader := cup.standings()[0].Player.Name; leader != "p1" {
+				t.Errorf("лидер %s, ожидался p1", leader)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const defaultLeaderboardSize = 10
+
+// checkSeason - закрывает истёкший сезон и выдаёт награды лиг в серверные кошельки
+func (s *ChatServer) checkSeason() {
+	for _, reward := range s.ratings.CheckSeason() {
+		s.shop.Credit(reward.Player, reward.League.Reward, "season")
+		s.logCh <- fmt.Sprintf("Сезон %d: %s закончил в лиге %s (%d) и получил %d",
+			reward.Season, reward.Player, reward.League.Name, reward.Rating, reward.League.Reward)
+	}
+}
+
+// writeEntries - строки таблицы: место|имя|рейтинг|лига|бои в сезоне|победы в сезоне|очки недели|цикл НГ+.
+// Цикл берётся из серверного кошелька: его открывает только засчитанный сервером финал
+func (s *ChatServer) writeEntries(w http.ResponseWriter, entries []LeaderboardEntry) {
+	for i, entry := range entries {
+		fmt.Fprintf(w, "%d|%s|%d|%s|%d|%d|%d|%d\n", i+1, entry.Name, entry.Rating.Rating,
+			LeagueFor(entry.Rating.Rating).Name, entry.Rating.SeasonGames, entry.Rating.SeasonWins, entry.Points,
+			s.shop.Cycle(entry.Name))
+	}
+}
+
+func leaderboardLimit(r *http.Request) int {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		return defaultLeaderboardSize
+	}
+	return limit
+}
+
+// writeSeasonHeader - первая строка ответа: season:номер|конец (unix)|рейтинг игрока|лига|место
+func (s *ChatServer) writeSeasonHeader(w http.ResponseWriter, player string) {
+	number, endsAt := s.ratings.Season()
+	rating := s.ratings.Get(player)
+	fmt.Fprintf(w, "season:%d|%d|%d|%s|%d\n", number, endsAt.Unix(), rating, LeagueFor(rating).Name, s.ratings.Place(player))
+}
+
+// handleLeaderboard - сезонная таблица всех игроков: /leaderboard?player=&limit=
+func (s *ChatServer) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	s.checkSeason()
+	s.writeSeasonHeader(w, r.URL.Query().Get("player"))
+	s.writeEntries(w, s.ratings.Top(leaderboardLimit(r), nil))
+}
+
+// handleWeeklyLeaderboard - кто больше всех поднял рейтинг за неделю
+func (s *ChatServer) handleWeeklyLeaderboard(w http.ResponseWriter, r *http.Request) {
+	s.checkSeason()
+	s.writeSeasonHeader(w, r.URL.Query().Get("player"))
+	week, entries := s.ratings.WeeklyTop(leaderboardLimit(r))
+	fmt.Fprintf(w, "week:%s\n", week)
+	s.writeEntries(w, entries)
+}
+
+// handleFriendsLeaderboard - игрок и его друзья
+func (s *ChatServer) handleFriendsLeaderboard(w http.ResponseWriter, r *http.Request) {
+	s.checkSeason()
+	player := r.URL.Query().Get("player")
+	if player == "" {
+		http.Error(w, "Invalid player", http.StatusBadRequest)
+		return
+	}
+
+	circle := map[string]bool{player: true}
+	for _, friend := range s.ratings.Friends(player) {
+		circle[friend] = true
+	}
+	s.writeSeasonHeader(w, player)
+	s.writeEntries(w, s.ratings.Top(0, func(name string) bool { return circle[name] }))
+}
+
+// handleFriends - GET ?player= - список друзей; POST player|friend на /friends/add или /friends/remove
+func (s *ChatServer) handleFriends(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		for _, friend := range s.ratings.Friends(r.URL.Query().Get("player")) {
+			fmt.Fprintln(w, friend)
+		}
+		return
+	}
+
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player, friend := parts[0], strings.TrimSpace(parts[1])
+
+	if strings.HasSuffix(r.URL.Path, "/remove") {
+		if !s.ratings.RemoveFriend(player, friend) {
+			fmt.Fprint(w, "error:not_friend")
+			return
+		}
+		fmt.Fprint(w, "ok")
+		return
+	}
+
+	if code := s.ratings.AddFriend(player, friend); code != "" {
+		fmt.Fprint(w, "error:"+code)
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// MatchState - стадия жизни PvP-боя
+type MatchState string
+
+const (
+	MatchActive    MatchState = "active"    // идёт бой
+	MatchFinished  MatchState = "finished"  // есть результат, игроки забирают его
+	MatchAbandoned MatchState = "abandoned" // оба игрока пропали, бой без результата
+	MatchShutdown  MatchState = "shutdown"  // прерван остановкой сервера
+)
+
+// LifecycleConfig - сроки хранения боёв, задержка трансляции и боты. Задаются переменными окружения
+// PVP_IDLE_TTL, PVP_FINISHED_TTL, PVP_JANITOR_INTERVAL, PVP_SPECTATOR_DELAY и PVP_BOT_WAIT (например, "90s", "5m")
+// и PVP_BOT_STYLE (adaptive, boss или off)
+type LifecycleConfig struct {
+	IdleMatchTTL     time.Duration // бой, который никто не опрашивает, считается брошенным
+	FinishedMatchTTL time.Duration // сколько хранить закончившийся бой, чтобы оба узнали результат
+	JanitorInterval  time.Duration
+	ShutdownNotice   time.Duration // сколько ждать перед остановкой, чтобы клиенты увидели предупреждение
+	SpectatorDelay   time.Duration // зрители видят бой с этим отставанием; 0 - без задержки
+	BotWait          time.Duration // после стольких секунд в очереди без соперника выходит бот
+	BotStyle         string        // как играет бот: adaptive, boss или off - ботов нет
+}
+
+func DefaultLifecycleConfig() LifecycleConfig {
+	return LifecycleConfig{
+		IdleMatchTTL:     2 * disconnectGrace,
+		FinishedMatchTTL: 2 * time.Minute,
+		JanitorInterval:  15 * time.Second,
+		ShutdownNotice:   3 * time.Second,
+		BotWait:          45 * time.Second,
+		BotStyle:         BotAdaptive,
+	}
+}
+
+// LifecycleConfigFromEnv - настройки по умолчанию, переопределённые окружением
+func LifecycleConfigFromEnv() LifecycleConfig {
+	cfg := DefaultLifecycleConfig()
+	envDuration("PVP_IDLE_TTL", &cfg.IdleMatchTTL)
+	envDuration("PVP_FINISHED_TTL", &cfg.FinishedMatchTTL)
+	envDuration("PVP_JANITOR_INTERVAL", &cfg.JanitorInterval)
+	envDuration("PVP_SPECTATOR_DELAY", &cfg.SpectatorDelay)
+	envDuration("PVP_BOT_WAIT", &cfg.BotWait)
+	switch style := os.Getenv("PVP_BOT_STYLE"); style {
+	case "":
+	case BotAdaptive, BotBoss, BotOff:
+		cfg.BotStyle = style
+	default:
+		fmt.Printf("⚠️ PVP_BOT_STYLE=%q не распознано, оставляю %s\n", style, cfg.BotStyle)
+	}
+	return cfg
+}
+
+func envDuration(name string, target *time.Duration) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		fmt.Printf("⚠️ %s=%q не распознано, оставляю %s\n", name, raw, *target)
+		return
+	}
+	*target = d
+}
+
+// Active - идёт ли бой. Берёт блокировку матча
+func (m *PvPMatch) Active() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.State == MatchActive
+}
+
+// setMatchState - переводит бой в конечную стадию и сохраняет его запись. Вызывать под match.mutex
+func (s *ChatServer) setMatchState(match *PvPMatch, state MatchState) {
+	if match.State != MatchActive {
+		return
+	}
+	match.State = state
+	match.FinishedAt = time.Now()
+	s.metrics.ended(state)
+	s.archiveMatch(match)
+}
+
+// runJanitor - периодически закрывает брошенные бои и удаляет старые
+func (s *ChatServer) runJanitor() {
+	ticker := time.NewTicker(s.config.JanitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			// Турниры забирают итоги своих боёв раньше, чем уборщик их удалит
+			s.tickCups()
+			s.cleanupMatches()
+			s.cleanupInvites()
+		}
+	}
+}
+
+func (s *ChatServer) cleanupMatches() {
+	s.queueMutex.Lock()
+	s.pruneQueue()
+	s.queueMutex.Unlock()
+
+	// Список снимается под pvpMutex, а сами бои проверяются под своими блокировками
+	s.pvpMutex.RLock()
+	matches := make([]*PvPMatch, 0, len(s.pvpMatches))
+	for _, match := range s.pvpMatches {
+		matches = append(matches, match)
+	}
+	s.pvpMutex.RUnlock()
+
+	now := time.Now()
+	expired := make([]string, 0)
+	for _, match := range matches {
+		match.mutex.Lock()
+		if match.State == MatchActive && now.Sub(match.Seen1) > s.config.IdleMatchTTL && now.Sub(match.Seen2) > s.config.IdleMatchTTL {
+			s.setMatchState(match, MatchAbandoned)
+			s.logCh <- fmt.Sprintf("PvP: матч %s брошен обоими игроками", match.ID)
+		}
+		if match.State != MatchActive && now.Sub(match.FinishedAt) > s.config.FinishedMatchTTL {
+			expired = append(expired, match.ID)
+		}
+		match.mutex.Unlock()
+	}
+
+	if len(expired) == 0 {
+		return
+	}
+	s.pvpMutex.Lock()
+	for _, id := range expired {
+		delete(s.pvpMatches, id)
+	}
+	s.pvpMutex.Unlock()
+	s.metrics.cleaned(len(expired))
+	s.logCh <- fmt.Sprintf("PvP: уборщик удалил матчей: %d", len(expired))
+}
+
+// matchMetrics - счётчики боёв с момента запуска
+type matchMetrics struct {
+	startedAt  time.Time
+	created    atomic.Int64
+	finished   atomic.Int64
+	abandoned  atomic.Int64
+	shutdown   atomic.Int64
+	removed    atomic.Int64
+	botMatches atomic.Int64
+}
+
+func newMatchMetrics() *matchMetrics {
+	return &matchMetrics{startedAt: time.Now()}
+}
+
+func (m *matchMetrics) ended(state MatchState) {
+	switch state {
+	case MatchFinished:
+		m.finished.Add(1)
+	case MatchAbandoned:
+		m.abandoned.Add(1)
+	case MatchShutdown:
+		m.shutdown.Add(1)
+	}
+}
+
+func (m *matchMetrics) cleaned(n int) {
+	m.removed.Add(int64(n))
+}
+
+func (m *matchMetrics) write(w io.Writer) {
+	fmt.Fprintf(w, "pvp_matches_created_total %d\n", m.created.Load())
+	fmt.Fprintf(w, "pvp_matches_finished_total %d\n", m.finished.Load())
+	fmt.Fprintf(w, "pvp_matches_abandoned_total %d\n", m.abandoned.Load())
+	fmt.Fprintf(w, "pvp_matches_shutdown_total %d\n", m.shutdown.Load())
+	fmt.Fprintf(w, "pvp_matches_removed_total %d\n", m.removed.Load())
+	fmt.Fprintf(w, "pvp_bot_matches_total %d\n", m.botMatches.Load())
+}
+
+// waitForSignal - Ctrl+C или SIGTERM останавливают сервер корректно
+func (s *ChatServer) waitForSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	select {
+	case <-signals:
+		s.Shutdown()
+	case <-s.stop:
+	}
+}
+
+// Shutdown - предупреждает игроков, прерывает идущие бои и останавливает HTTP-сервер
+func (s *ChatServer) Shutdown() {
+	if !s.shuttingDown.CompareAndSwap(false, true) {
+		return
+	}
+	s.logCh <- "Сервер останавливается..."
+	s.addMessage("⚠️ Сервер останавливается. Идущие бои прерваны без потери рейтинга")
+
+	// Турниры отменяются раньше, чем прерываются их бои: иначе уборщик успел бы засчитать прерванные бои в сетку
+	s.refundOpenCups()
+
+	s.pvpMutex.RLock()
+	for _, match := range s.pvpMatches {
+		match.mutex.Lock()
+		s.setMatchState(match, MatchShutdown)
+		match.mutex.Unlock()
+	}
+	s.pvpMutex.RUnlock()
+
+	// Даём клиентам опросить сервер и узнать об остановке
+	time.Sleep(s.config.ShutdownNotice)
+	close(s.stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		fmt.Println("⚠️ Сервер остановлен с ошибкой:", err)
+	}
+	fmt.Println("Сервер остановлен")
+	close(s.stopped)
+}
+
+// handleMetrics - состояние PvP в формате "имя значение", по строке на показатель
+func (s *ChatServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	counts := make(map[MatchState]int)
+	s.pvpMutex.RLock()
+	for _, match := range s.pvpMatches {
+		match.mutex.RLock()
+		counts[match.State]++
+		match.mutex.RUnlock()
+	}
+	s.pvpMutex.RUnlock()
+
+	s.queueMutex.Lock()
+	queued := len(s.pvpQueue)
+	s.queueMutex.Unlock()
+
+	fmt.Fprintf(w, "pvp_queue_size %d\n", queued)
+	for _, state := range []MatchState{MatchActive, MatchFinished, MatchAbandoned, MatchShutdown} {
+		fmt.Fprintf(w, "pvp_matches{state=\"%s\"} %d\n", state, counts[state])
+	}
+	s.metrics.write(w)
+	fmt.Fprintf(w, "uptime_seconds %d\n", int(time.Since(s.metrics.startedAt).Seconds()))
+}
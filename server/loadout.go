@@ -0,0 +1,67 @@
+package server
+
+import (
+	"game/crafting"
+	"game/items"
+	"game/player"
+)
+
+// loadoutLimits - наибольшие сила и здоровье, которые может дать надетое снаряжение игрока:
+// стартовые характеристики, экземпляры из его серверного аккаунта на максимальном уровне улучшения
+// и бонусы комплектов. Чужие, повторные и неизвестные серверу номера ничего не добавляют
+func (ss *ServerShop) loadoutLimits(playerName string, serverIDs []string) (strength, maxHP int) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	strength, maxHP = player.StartStrength, player.StartHP
+	acc, ok := ss.accounts[playerName]
+	if !ok {
+		return strength, maxHP
+	}
+
+	upgrade := 100 + crafting.MaxUpgradePercent()
+	setPieces := make([]string, 0, len(serverIDs))
+	seen := make(map[string]bool)
+	for _, id := range serverIDs {
+		item := ss.items[id]
+		if seen[id] || !acc.Gear[id] || item == nil {
+			continue
+		}
+		seen[id] = true
+		effect := item.Effect
+		if item.BaseEffect != nil {
+			effect = *item.BaseEffect
+		}
+		strength += effect.Strength * upgrade / 100
+		maxHP += effect.MaxHP * upgrade / 100
+		setPieces = append(setPieces, item.Name)
+	}
+
+	sets := items.SetEffect(setPieces)
+	return strength + sets.Strength, maxHP + sets.MaxHP
+}
+
+// findCatalogItem - предмет по названию из каталога или рецептов мастерской.
+// Сервер берёт характеристики отсюда, а не со слов клиента
+func findCatalogItem(name string) *items.Item {
+	if item := items.FindByName(name); item != nil {
+		return item
+	}
+	return crafting.FindResult(name)
+}
+
+// validateLoadout - ограничивает заявленные клиентом характеристики тем, что даёт его снаряжение.
+// Возвращает исправленные значения и false, если их пришлось урезать
+func (ss *ServerShop) validateLoadout(playerName string, serverIDs []string, strength, maxHP int) (int, int, bool) {
+	maxStrength, maxMaxHP := ss.loadoutLimits(playerName, serverIDs)
+	valid := true
+	if strength > maxStrength {
+		strength = maxStrength
+		valid = false
+	}
+	if maxHP > maxMaxHP {
+		maxHP = maxMaxHP
+		valid = false
+	}
+	return strength, maxHP, valid
+}
@@ -0,0 +1,339 @@
+package server
+
+import (
+	"crypto/rand"
+	"fmt"
+	"game/combat"
+	"net/http"
+	"sort"
+	"time"
+)
+
+const (
+	challengeTTL = 2 * time.Minute  // Столько вызов ждёт ответа
+	lobbyTTL     = 10 * time.Minute // Столько лобби ждёт гостя
+	inviteKeep   = 5 * time.Minute  // Сколько хранить отвеченные и истёкшие приглашения
+	lobbyCodeLen = 6
+)
+
+// Буквы кода лобби без похожих друг на друга 0/O и 1/I
+const lobbyAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+type InviteState string
+
+const (
+	InvitePending   InviteState = "pending"
+	InviteAccepted  InviteState = "accepted"
+	InviteDeclined  InviteState = "declined"
+	InviteCancelled InviteState = "cancelled"
+	InviteExpired   InviteState = "expired"
+)
+
+// Invite - вызов на бой конкретному игроку или приватное лобби, куда можно войти по коду
+type Invite struct {
+	ID        string // ID вызова или код лобби
+	From      *PvPPlayer
+	To        string // пусто - лобби, войти может любой, кто знает код
+	Rules     combat.MatchRules
+	State     InviteState
+	ExpiresAt time.Time
+	MatchID   string
+}
+
+func (inv *Invite) isLobby() bool {
+	return inv.To == ""
+}
+
+// expire - помечает просроченное приглашение. Вызывать под invitesMutex
+func (inv *Invite) expire(now time.Time) {
+	if inv.State == InvitePending && now.After(inv.ExpiresAt) {
+		inv.State = InviteExpired
+	}
+}
+
+// newLobbyCode - короткий код лобби. Вызывать под invitesMutex
+func (s *ChatServer) newLobbyCode() string {
+	buf := make([]byte, lobbyCodeLen)
+	for {
+		if _, err := rand.Read(buf); err != nil {
+			return fmt.Sprintf("%06d", time.Now().UnixNano()%1000000)
+		}
+		for i, b := range buf {
+			buf[i] = lobbyAlphabet[int(b)%len(lobbyAlphabet)]
+		}
+		if _, taken := s.invites[string(buf)]; !taken {
+			return string(buf)
+		}
+	}
+}
+
+// cleanupInvites - закрывает просроченные приглашения и забывает старые. Вызывается уборщиком
+func (s *ChatServer) cleanupInvites() {
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	now := time.Now()
+	for id, inv := range s.invites {
+		inv.expire(now)
+		if inv.State != InvitePending && now.Sub(inv.ExpiresAt) > inviteKeep {
+			delete(s.invites, id)
+		}
+	}
+}
+
+// startInviteMatch - бой по принятому приглашению. Вызывать под invitesMutex
+func (s *ChatServer) startInviteMatch(inv *Invite, guest *PvPPlayer) *PvPMatch {
+	s.queueMutex.Lock()
+	for _, name := range []string{inv.From.Name, guest.Name} {
+		if i := s.queueIndex(name); i >= 0 {
+			s.pvpQueue = append(s.pvpQueue[:i], s.pvpQueue[i+1:]...)
+		}
+	}
+	s.queueMutex.Unlock()
+
+	// Товарищеский бой всегда начинается с полным здоровьем
+	host := *inv.From
+	host.HP = host.MaxHP
+	guest.HP = guest.MaxHP
+	match := s.createMatch(&host, guest, withRules(inv.Rules))
+	inv.State = InviteAccepted
+	inv.MatchID = match.ID
+	s.logCh <- fmt.Sprintf("PvP: товарищеский бой %s: %s vs %s (%s)", match.ID, host.Name, guest.Name, inv.Rules.Describe())
+	return match
+}
+
+// inviteBusy - занят ли игрок другим боем
+func (s *ChatServer) inviteBusy(name string) bool {
+	return s.findMatch(name) != nil
+}
+
+// handleChallengeSend - вызов на бой: Имя|HP|MaxHP|Сила|предметы|кого вызываем|правила.
+// Ответ: ok:ID|токен сессии или error:self|busy|shutdown|bot_name
+func (s *ChatServer) handleChallengeSend(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 7)
+	if !ok {
+		return
+	}
+	from, err := s.parsePvPPlayer(parts[:5])
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+	to := parts[5]
+	switch {
+	case s.shuttingDown.Load():
+		fmt.Fprint(w, "error:shutdown")
+		return
+	case to == "" || to == from.Name:
+		fmt.Fprint(w, "error:self")
+		return
+	case s.inviteBusy(from.Name):
+		fmt.Fprint(w, "error:busy")
+		return
+	}
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	s.inviteCounter++
+	inv := &Invite{
+		ID:        fmt.Sprintf("ch_%d", s.inviteCounter),
+		From:      from,
+		To:        to,
+		Rules:     combat.ParseRules(parts[6]),
+		State:     InvitePending,
+		ExpiresAt: time.Now().Add(challengeTTL),
+	}
+	s.invites[inv.ID] = inv
+	s.logCh <- fmt.Sprintf("PvP: %s вызывает %s на бой (%s)", from.Name, to, inv.Rules.Describe())
+	fmt.Fprintf(w, "ok:%s|%s", inv.ID, from.Token)
+}
+
+// handleChallengeList - вызовы игрока: строки in|ID|от кого|правила|секунд осталось
+// и out|ID|кому|состояние|правила|секунд осталось
+func (s *ChatServer) handleChallengeList(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("player")
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	list := make([]*Invite, 0)
+	now := time.Now()
+	for _, inv := range s.invites {
+		inv.expire(now)
+		if !inv.isLobby() && (inv.To == playerName || inv.From.Name == playerName) {
+			list = append(list, inv)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ExpiresAt.Before(list[j].ExpiresAt)
+	})
+
+	for _, inv := range list {
+		left := int(time.Until(inv.ExpiresAt).Seconds())
+		if inv.To == playerName && inv.State == InvitePending {
+			fmt.Fprintf(w, "in|%s|%s|%s|%d\n", inv.ID, inv.From.Name, inv.Rules, left)
+		}
+		if inv.From.Name == playerName {
+			fmt.Fprintf(w, "out|%s|%s|%s|%s|%d\n", inv.ID, inv.To, inv.State, inv.Rules, left)
+		}
+	}
+}
+
+// handleChallengeAccept - принять вызов: ID|Имя|HP|MaxHP|Сила|предметы.
+// Ответ: строка rules:правила, строка матча как у /pvp/status и строка token:токен сессии
+// или error:not_found|expired|answered|busy|bot_name
+func (s *ChatServer) handleChallengeAccept(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 5)
+	if !ok {
+		return
+	}
+	guest, err := s.parsePvPPlayer(parts[1:])
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	inv, exists := s.invites[parts[0]]
+	if !exists || inv.isLobby() || inv.To != guest.Name {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+	inv.expire(time.Now())
+	switch {
+	case inv.State == InviteExpired:
+		fmt.Fprint(w, "error:expired")
+	case inv.State != InvitePending:
+		fmt.Fprint(w, "error:answered")
+	case s.inviteBusy(inv.From.Name) || s.inviteBusy(guest.Name):
+		fmt.Fprint(w, "error:busy")
+	default:
+		fmt.Fprintf(w, "rules:%s\n%s\ntoken:%s", inv.Rules, matchInfo(s.startInviteMatch(inv, guest), guest.Name), guest.Token)
+	}
+}
+
+// handleInviteDecline - отказ от вызова или отмена своего вызова/лобби: ID или код|игрок
+func (s *ChatServer) handleInviteDecline(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	inv, exists := s.invites[parts[0]]
+	if !exists || (inv.From.Name != parts[1] && inv.To != parts[1]) {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+	inv.expire(time.Now())
+	if inv.State != InvitePending {
+		fmt.Fprint(w, "error:answered")
+		return
+	}
+	if inv.From.Name == parts[1] {
+		inv.State = InviteCancelled
+	} else {
+		inv.State = InviteDeclined
+	}
+	fmt.Fprint(w, "ok")
+}
+
+// handleInviteStatus - ожидание ответа на вызов или гостя в лобби: ?id=ID или код&player=.
+// Ответ: pending:секунд осталось, строка матча, declined, cancelled, expired, finished или error:not_found
+func (s *ChatServer) handleInviteStatus(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("player")
+
+	s.invitesMutex.Lock()
+	inv, exists := s.invites[r.URL.Query().Get("id")]
+	if !exists || (inv.From.Name != playerName && inv.To != playerName) {
+		s.invitesMutex.Unlock()
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+	inv.expire(time.Now())
+	state, matchID, left := inv.State, inv.MatchID, int(time.Until(inv.ExpiresAt).Seconds())
+	s.invitesMutex.Unlock()
+
+	switch state {
+	case InvitePending:
+		fmt.Fprintf(w, "pending:%d", left)
+	case InviteAccepted:
+		if match := s.findMatch(playerName); match != nil && match.ID == matchID {
+			fmt.Fprint(w, matchInfo(match, playerName))
+			return
+		}
+		// Бой по приглашению уже закончился
+		fmt.Fprint(w, "finished")
+	default:
+		fmt.Fprint(w, string(state))
+	}
+}
+
+// handleLobbyCreate - приватное лобби: Имя|HP|MaxHP|Сила|предметы|правила.
+// Ответ: ok:код|токен сессии или error:busy|shutdown|bot_name
+func (s *ChatServer) handleLobbyCreate(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 6)
+	if !ok {
+		return
+	}
+	host, err := s.parsePvPPlayer(parts[:5])
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+	if s.shuttingDown.Load() {
+		fmt.Fprint(w, "error:shutdown")
+		return
+	}
+	if s.inviteBusy(host.Name) {
+		fmt.Fprint(w, "error:busy")
+		return
+	}
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	inv := &Invite{
+		ID:        s.newLobbyCode(),
+		From:      host,
+		Rules:     combat.ParseRules(parts[5]),
+		State:     InvitePending,
+		ExpiresAt: time.Now().Add(lobbyTTL),
+	}
+	s.invites[inv.ID] = inv
+	s.logCh <- fmt.Sprintf("PvP: %s открыл лобби %s (%s)", host.Name, inv.ID, inv.Rules.Describe())
+	fmt.Fprintf(w, "ok:%s|%s", inv.ID, host.Token)
+}
+
+// handleLobbyJoin - вход в лобби: код|Имя|HP|MaxHP|Сила|предметы.
+// Ответ как у принятия вызова или error:not_found|self|full|busy|bot_name
+func (s *ChatServer) handleLobbyJoin(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 5)
+	if !ok {
+		return
+	}
+	guest, err := s.parsePvPPlayer(parts[1:])
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+
+	s.invitesMutex.Lock()
+	defer s.invitesMutex.Unlock()
+	inv, exists := s.invites[parts[0]]
+	if exists {
+		inv.expire(time.Now())
+	}
+	switch {
+	case !exists || !inv.isLobby() || inv.State == InviteExpired || inv.State == InviteCancelled:
+		fmt.Fprint(w, "error:not_found")
+	case inv.From.Name == guest.Name:
+		fmt.Fprint(w, "error:self")
+	case inv.State != InvitePending:
+		fmt.Fprint(w, "error:full")
+	case s.inviteBusy(inv.From.Name) || s.inviteBusy(guest.Name):
+		fmt.Fprint(w, "error:busy")
+	default:
+		match := s.startInviteMatch(inv, guest)
+		fmt.Fprintf(w, "rules:%s\n%s\ntoken:%s", inv.Rules, matchInfo(match, guest.Name), guest.Token)
+	}
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"fmt"
+	"game/combat"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	baseRatingGap    = 100 // Допустимая разница рейтингов сразу после входа в очередь
+	ratingGapPerStep = 50  // На сколько она растёт за каждый шаг ожидания
+	ratingGapStep    = 5 * time.Second
+	maxRatingGap     = 600 // После долгого ожидания подойдёт почти любой соперник
+
+	queueTTL         = 15 * time.Second // Без опроса дольше этого место в очереди пропадает
+	defaultQueueWait = 30 * time.Second // Оценка ожидания, пока не сыграно ни одного боя
+)
+
+// allowedGap - допустимая разница рейтингов для того, кто ждёт wait
+func allowedGap(wait time.Duration) int {
+	gap := baseRatingGap + int(wait/ratingGapStep)*ratingGapPerStep
+	if gap > maxRatingGap {
+		gap = maxRatingGap
+	}
+	return gap
+}
+
+// matchQueue - сводит игроков из очереди с близким рейтингом. Дольше всех ждущий выбирает первым,
+// и для пары действует более широкий из двух допусков. Вызывать под queueMutex
+func (s *ChatServer) matchQueue() {
+	now := time.Now()
+	for i := 0; i < len(s.pvpQueue); i++ {
+		first := s.pvpQueue[i]
+		best := -1
+		bestGap := 0
+		for j := i + 1; j < len(s.pvpQueue); j++ {
+			second := s.pvpQueue[j]
+			gap := abs(first.Rating - second.Rating)
+			// Очередь упорядочена по времени входа, так что first ждёт дольше
+			if gap > allowedGap(now.Sub(first.QueuedAt)) {
+				continue
+			}
+			if best < 0 || gap < bestGap {
+				best, bestGap = j, gap
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		second := s.pvpQueue[best]
+		s.pvpQueue = append(s.pvpQueue[:best], s.pvpQueue[best+1:]...)
+		s.pvpQueue = append(s.pvpQueue[:i], s.pvpQueue[i+1:]...)
+		i--
+		s.recordWait(now.Sub(first.QueuedAt))
+		s.createMatch(first, second, nil)
+	}
+}
+
+// pruneQueue - убирает из очереди тех, кто перестал опрашивать сервер. Вызывать под queueMutex
+func (s *ChatServer) pruneQueue() {
+	now := time.Now()
+	alive := s.pvpQueue[:0]
+	for _, queued := range s.pvpQueue {
+		if now.Sub(queued.LastSeen) > queueTTL {
+			s.logCh <- fmt.Sprintf("PvP: %s выбыл из очереди - клиент не отвечает", queued.Name)
+			continue
+		}
+		alive = append(alive, queued)
+	}
+	s.pvpQueue = alive
+}
+
+// queueIndex - место игрока в очереди или -1. Вызывать под queueMutex
+func (s *ChatServer) queueIndex(name string) int {
+	for i, queued := range s.pvpQueue {
+		if queued.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// recordWait - скользящее среднее времени ожидания. Вызывать под queueMutex
+func (s *ChatServer) recordWait(wait time.Duration) {
+	if s.queueWaitAvg == 0 {
+		s.queueWaitAvg = wait
+		return
+	}
+	s.queueWaitAvg = (s.queueWaitAvg*4 + wait) / 5
+}
+
+// estimateWait - сколько ещё примерно ждать игроку из очереди
+func (s *ChatServer) estimateWait(queued *PvPPlayer) time.Duration {
+	average := s.queueWaitAvg
+	if average == 0 {
+		average = defaultQueueWait
+	}
+	left := average - time.Since(queued.QueuedAt)
+	if left < 0 {
+		// Ждёт дольше обычного: допуск по рейтингу уже расширяется, соперник вот-вот найдётся
+		left = ratingGapStep
+	}
+	// Дольше, чем до выхода бота, ждать не придётся
+	if botLeft := s.botWaitLeft(queued); botLeft >= 0 && botLeft < left {
+		left = botLeft
+	}
+	return left.Round(time.Second)
+}
+
+// handlePvPLeave - выход из очереди: тело запроса - имя игрока
+func (s *ChatServer) handlePvPLeave(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	name := strings.TrimSpace(string(body))
+
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+
+	i := s.queueIndex(name)
+	if i < 0 {
+		// Соперник мог найтись за мгновение до отмены
+		if s.findMatch(name) != nil {
+			fmt.Fprint(w, "in_match")
+			return
+		}
+		fmt.Fprint(w, "not_queued")
+		return
+	}
+	s.pvpQueue = append(s.pvpQueue[:i], s.pvpQueue[i+1:]...)
+	s.logCh <- fmt.Sprintf("PvP: %s покинул очередь", name)
+	fmt.Fprint(w, "ok")
+}
+
+// parsePvPPlayer - боец из заявки Имя|HP|MaxHP|Сила|номера надетых экземпляров через запятую,
+// с новым токеном сессии. Ошибка bot_name - имя с пометкой бота, такие имена заняты ботами
+func (s *ChatServer) parsePvPPlayer(parts []string) (*PvPPlayer, error) {
+	if isBotName(parts[0]) {
+		return nil, fmt.Errorf("bot_name")
+	}
+	hp, _ := strconv.Atoi(parts[1])
+	maxHP, _ := strconv.Atoi(parts[2])
+	strength, _ := strconv.Atoi(parts[3])
+
+	// Характеристики проверяются по снаряжению из серверного аккаунта: клиент не может заявить больше, чем оно даёт
+	var loadout []string
+	if len(parts) > 4 && parts[4] != "" {
+		loadout = strings.Split(parts[4], ",")
+	}
+	strength, maxHP, valid := s.shop.validateLoadout(parts[0], loadout, strength, maxHP)
+	if !valid {
+		s.logCh <- fmt.Sprintf("PvP: характеристики %s урезаны по снаряжению (сила %d, HP %d)", parts[0], strength, maxHP)
+	}
+	if hp > maxHP || hp <= 0 {
+		hp = maxHP
+	}
+
+	return &PvPPlayer{
+		Name:     parts[0],
+		HP:       hp,
+		MaxHP:    maxHP,
+		Strength: strength,
+		Rating:   s.ratings.Get(parts[0]),
+		QueuedAt: time.Now(),
+		LastSeen: time.Now(),
+		Token:    newToken(),
+	}, nil
+}
+
+// createMatch - новый бой двух игроков. setup, если задан, настраивает бой до того, как он станет виден другим
+func (s *ChatServer) createMatch(player1, player2 *PvPPlayer, setup func(*PvPMatch)) *PvPMatch {
+	s.pvpMutex.Lock()
+	defer s.pvpMutex.Unlock()
+
+	s.matchCounter++
+	match := &PvPMatch{
+		ID:         fmt.Sprintf("match_%d", s.matchCounter),
+		Player1:    player1,
+		Player2:    player2,
+		Player1HP:  player1.HP,
+		Player2HP:  player2.HP,
+		Round:      1,
+		State:      MatchActive,
+		Chat:       make([]string, 0),
+		Items1:     combat.NewConsumableTracker(),
+		Items2:     combat.NewConsumableTracker(),
+		Seen1:      time.Now(),
+		Seen2:      time.Now(),
+		StartedAt:  time.Now(),
+		Spectators: make(map[string]time.Time),
+	}
+	if setup != nil {
+		setup(match)
+	}
+	match.record(EventJoin, player1.Name, match.Player1HP, player1.MaxHP, player1.Strength, player1.Rating)
+	match.record(EventJoin, player2.Name, match.Player2HP, player2.MaxHP, player2.Strength, player2.Rating)
+	s.pvpMatches[match.ID] = match
+	s.metrics.created.Add(1)
+
+	s.logCh <- fmt.Sprintf("PvP: Создан матч %s: %s (%d) vs %s (%d)",
+		match.ID, player1.Name, player1.Rating, player2.Name, player2.Rating)
+	return match
+}
+
+// matchInfo - описание соперника для игрока:
+// match:ID|соперник|HP|MaxHP|сила|рейтинг соперника|свой рейтинг|вид боя.
+// Токена сессии здесь нет: его получает только сам игрок при записи на бой
+func matchInfo(match *PvPMatch, playerName string) string {
+	me, opponent := match.Player1, match.Player2
+	if match.Player2.Name == playerName {
+		me, opponent = match.Player2, match.Player1
+	}
+	return fmt.Sprintf("match:%s|%s|%d|%d|%d|%d|%d|%s",
+		match.ID, opponent.Name, opponent.HP, opponent.MaxHP, opponent.Strength, opponent.Rating, me.Rating, matchKind(match))
+}
+
+// matchKind - вид боя: ranked (рейтинговый), bot, friendly или cup
+func matchKind(match *PvPMatch) string {
+	switch {
+	case match.CupID != "":
+		return "cup"
+	case match.Bot != nil:
+		return "bot"
+	case match.Casual:
+		return "friendly"
+	}
+	return "ranked"
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+)
+
+const (
+	ratingsFile      = "ratings.json"
+	startRating      = 1200
+	eloK             = 32 // Насколько сильно один бой двигает рейтинг
+	provisionalK     = 48 // Первые бои двигают сильнее, чтобы новичок быстрее нашёл свой уровень
+	provisionalGames = 10
+)
+
+// PlayerRating - рейтинг аккаунта и его боевая статистика
+type PlayerRating struct {
+	Rating int
+	Games  int
+	Wins   int
+	Losses int
+	Draws  int
+	// Бои и победы в текущем сезоне
+	SeasonGames int
+	SeasonWins  int
+}
+
+// Ratings - рейтинги по Эло, переживают перезапуск сервера
+type Ratings struct {
+	players map[string]*PlayerRating
+	season  SeasonState
+	mutex   sync.Mutex
+}
+
+func NewRatings() *Ratings {
+	r := &Ratings{players: make(map[string]*PlayerRating)}
+	raw, err := os.ReadFile(ratingsFile)
+	if err == nil {
+		if err := json.Unmarshal(raw, &r.players); err != nil {
+			fmt.Println("⚠️ Рейтинги повреждены, начинаем с чистого листа:", err)
+			r.players = make(map[string]*PlayerRating)
+		}
+	}
+	r.loadSeason()
+	return r
+}
+
+// lookup - рейтинг игрока без записи: у того, кто ещё не играл, стартовый. Вызывать под mutex
+func (r *Ratings) lookup(name string) PlayerRating {
+	if pr, ok := r.players[name]; ok {
+		return *pr
+	}
+	return PlayerRating{Rating: startRating}
+}
+
+// entry - запись игрока, заводится при первом рейтинговом бое. Вызывать под mutex
+func (r *Ratings) entry(name string) *PlayerRating {
+	pr, ok := r.players[name]
+	if !ok {
+		pr = &PlayerRating{Rating: startRating}
+		r.players[name] = pr
+	}
+	return pr
+}
+
+func (r *Ratings) Get(name string) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.lookup(name).Rating
+}
+
+// expectedScore - ожидаемый результат игрока с рейтингом a против b (от 0 до 1)
+func expectedScore(a, b int) float64 {
+	return 1 / (1 + math.Pow(10, float64(b-a)/400))
+}
+
+// Record - пересчитывает рейтинги после боя. score1: 1 - победил первый, 0 - второй, 0.5 - ничья.
+// Возвращает изменения рейтингов обоих игроков
+func (r *Ratings) Record(name1, name2 string, score1 float64) (int, int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	p1, p2 := r.entry(name1), r.entry(name2)
+	delta1 := ratingDelta(p1, p2.Rating, score1)
+	delta2 := ratingDelta(p2, p1.Rating, 1-score1)
+	p1.apply(delta1, score1)
+	p2.apply(delta2, 1-score1)
+	r.season.Weekly[name1] += delta1
+	r.season.Weekly[name2] += delta2
+
+	r.save()
+	r.saveSeason()
+	return delta1, delta2
+}
+
+func ratingDelta(pr *PlayerRating, opponent int, score float64) int {
+	k := eloK
+	if pr.Games < provisionalGames {
+		k = provisionalK
+	}
+	return int(math.Round(float64(k) * (score - expectedScore(pr.Rating, opponent))))
+}
+
+func (pr *PlayerRating) apply(delta int, score float64) {
+	pr.Rating += delta
+	pr.Games++
+	pr.SeasonGames++
+	switch score {
+	case 1:
+		pr.Wins++
+		pr.SeasonWins++
+	case 0:
+		pr.Losses++
+	default:
+		pr.Draws++
+	}
+}
+
+// save - записывает рейтинги на диск. Вызывать под mutex
+func (r *Ratings) save() {
+	raw, err := json.MarshalIndent(r.players, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(ratingsFile, raw, 0644); err != nil {
+		fmt.Println("⚠️ Не удалось сохранить рейтинги:", err)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	disconnectGrace = 60 * time.Second // Столько ждём пропавшего игрока, потом засчитываем поражение
+	awayNotice      = 5 * time.Second  // После этого сопернику сообщают, что игрок пропал
+)
+
+// newToken - секрет сессии игрока в матче: по нему можно вернуться в бой после сбоя
+func newToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
+
+// touchMatch - отмечает, что игрок на связи, и засчитывает поражение сопернику,
+// пропавшему дольше disconnectGrace. Вызывать под match.mutex
+func (s *ChatServer) touchMatch(match *PvPMatch, playerName string) {
+	now := time.Now()
+	switch playerName {
+	case match.Player1.Name:
+		match.Seen1 = now
+		if match.State != MatchActive {
+			return
+		}
+		if now.Sub(match.Seen2) > disconnectGrace && !match.Over() {
+			match.Player2HP = 0
+			match.record(EventTimeout, match.Player2.Name)
+			s.logCh <- fmt.Sprintf("PvP: %s не вернулся в матч %s - техническое поражение", match.Player2.Name, match.ID)
+		}
+	case match.Player2.Name:
+		match.Seen2 = now
+		if match.State != MatchActive {
+			return
+		}
+		if now.Sub(match.Seen1) > disconnectGrace && !match.Over() {
+			match.Player1HP = 0
+			match.record(EventTimeout, match.Player1.Name)
+			s.logCh <- fmt.Sprintf("PvP: %s не вернулся в матч %s - техническое поражение", match.Player1.Name, match.ID)
+		}
+	}
+}
+
+// opponentAway - сколько секунд осталось пропавшему сопернику до поражения (0 - соперник на связи).
+// Вызывать под match.mutex
+func opponentAway(match *PvPMatch, playerName string) int {
+	seen := match.Seen1
+	if match.Player1.Name == playerName {
+		seen = match.Seen2
+	}
+	away := time.Since(seen)
+	if away < awayNotice {
+		return 0
+	}
+	left := int((disconnectGrace - away).Seconds())
+	if left < 1 {
+		left = 1
+	}
+	return left
+}
+
+// owns - выдан ли токен этому игроку матча. У бота токена нет, от его имени ходить нельзя
+func (match *PvPMatch) owns(playerName, token string) bool {
+	for _, p := range []*PvPPlayer{match.Player1, match.Player2} {
+		if p.Name == playerName && p.Token != "" && subtle.ConstantTimeCompare([]byte(p.Token), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionMatch - незавершённый матч игрока, если токен совпадает
+func (s *ChatServer) sessionMatch(playerName, token string) *PvPMatch {
+	match := s.findMatch(playerName)
+	if match == nil || !match.owns(playerName, token) {
+		return nil
+	}
+	return match
+}
+
+// handlePvPResume - возвращение в бой: player|token.
+// Ответ: строка матча как у /pvp/status, плюс |раунд|своё HP; none - возвращаться некуда
+func (s *ChatServer) handlePvPResume(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	playerName, token := parts[0], parts[1]
+
+	match := s.sessionMatch(playerName, token)
+	if match == nil {
+		fmt.Fprint(w, "none")
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+	if match.Over() {
+		fmt.Fprint(w, "none")
+		return
+	}
+	s.touchMatch(match, playerName)
+	match.record(EventResume, playerName)
+
+	myHP := match.Player1HP
+	if match.Player2.Name == playerName {
+		myHP = match.Player2HP
+	}
+	s.logCh <- fmt.Sprintf("PvP: %s вернулся в матч %s", playerName, match.ID)
+	fmt.Fprintf(w, "%s|%d|%d", matchInfo(match, playerName), match.Round, myHP)
+}
+
+// handlePvPForfeit - сдаться: player|token. Бой заканчивается и засчитывается сразу: в бою с ботом
+// опрашивать матч больше некому, и без этого уборщик счёл бы его брошенным.
+// Ответ: ok или error:not_found|finished
+func (s *ChatServer) handlePvPForfeit(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	playerName, token := parts[0], parts[1]
+
+	match := s.sessionMatch(playerName, token)
+	if match == nil {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+	if match.State != MatchActive || match.Over() {
+		fmt.Fprint(w, "error:finished")
+		return
+	}
+	if match.Player1.Name == playerName {
+		match.Player1HP = 0
+	} else {
+		match.Player2HP = 0
+	}
+	match.record(EventForfeit, playerName)
+	match.Player1.HP = match.Player1.MaxHP
+	match.Player2.HP = match.Player2.MaxHP
+	s.creditPvPResult(match)
+	s.setMatchState(match, MatchFinished)
+	s.logCh <- fmt.Sprintf("PvP: %s сдался в матче %s", playerName, match.ID)
+	fmt.Fprint(w, "ok")
+}
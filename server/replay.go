@@ -0,0 +1,239 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	replaysDir        = "replays"
+	playerReplayLimit = 20 // Сколько последних боёв показывать в списке игрока
+)
+
+// Виды событий в журнале боя и их данные
+const (
+	EventJoin    = "join"    // HP, MaxHP, сила, рейтинг
+	EventMove    = "move"    // атака, блок
+	EventItem    = "item"    // предмет, HP до, HP после
+	EventStun    = "stun"    // оглушённый игрок пропускает атаку
+	EventRound   = "round"   // урон первому, урон второму, HP первого, HP второго
+	EventChat    = "chat"    // текст
+	EventResume  = "resume"  // игрок вернулся после обрыва связи
+	EventForfeit = "forfeit" // игрок сдался
+	EventTimeout = "timeout" // игрок не вернулся - техническое поражение
+	EventEnd     = "end"     // состояние боя, победитель, HP первого, HP второго
+)
+
+// MatchEvent - одна запись журнала боя
+type MatchEvent struct {
+	Time   time.Time
+	Round  int
+	Kind   string
+	Player string   `json:",omitempty"`
+	Data   []string `json:",omitempty"`
+}
+
+// MatchRecord - журнал завершённого боя в архиве
+type MatchRecord struct {
+	ID        string
+	Player1   string
+	Player2   string
+	Winner    string // пусто - ничья или бой без результата
+	State     MatchState
+	StartedAt time.Time
+	EndedAt   time.Time
+	Rounds    int
+	Events    []MatchEvent `json:",omitempty"`
+}
+
+// record - добавляет событие в журнал боя. Вызывать под match.mutex
+func (m *PvPMatch) record(kind, player string, data ...interface{}) {
+	fields := make([]string, len(data))
+	for i, value := range data {
+		// Разделители протокола в тексте чата заменяются, чтобы запись читалась построчно
+		fields[i] = strings.NewReplacer("\n", " ", "\r", " ").Replace(fmt.Sprint(value))
+	}
+	m.Events = append(m.Events, MatchEvent{Time: time.Now(), Round: m.Round, Kind: kind, Player: player, Data: fields})
+}
+
+// ReplayArchive - журналы завершённых боёв на диске, по файлу на бой
+type ReplayArchive struct {
+	dir     string
+	records []MatchRecord // только заголовки, без событий
+	mutex   sync.RWMutex
+}
+
+// NewReplayArchive - открывает архив и читает заголовки уже сохранённых боёв
+func NewReplayArchive(dir string) *ReplayArchive {
+	a := &ReplayArchive{dir: dir, records: make([]MatchRecord, 0)}
+	files, _ := filepath.Glob(filepath.Join(dir, "match_*.json"))
+	for _, file := range files {
+		record, err := readRecord(file)
+		if err != nil {
+			fmt.Println("⚠️ Запись боя повреждена:", file, err)
+			continue
+		}
+		record.Events = nil
+		a.records = append(a.records, *record)
+	}
+	sort.Slice(a.records, func(i, j int) bool {
+		return a.records[i].EndedAt.Before(a.records[j].EndedAt)
+	})
+	return a
+}
+
+func readRecord(file string) (*MatchRecord, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var record MatchRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// LastMatchNumber - наибольший номер боя в архиве, чтобы после перезапуска номера не повторялись
+func (a *ReplayArchive) LastMatchNumber() int {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	last := 0
+	for _, record := range a.records {
+		var n int
+		if _, err := fmt.Sscanf(record.ID, "match_%d", &n); err == nil && n > last {
+			last = n
+		}
+	}
+	return last
+}
+
+func (a *ReplayArchive) Save(record *MatchRecord) error {
+	raw, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(a.dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(a.dir, record.ID+".json"), raw, 0644); err != nil {
+		return err
+	}
+
+	header := *record
+	header.Events = nil
+	a.mutex.Lock()
+	a.records = append(a.records, header)
+	a.mutex.Unlock()
+	return nil
+}
+
+// Load - полный журнал боя; nil, если такого боя в архиве нет
+func (a *ReplayArchive) Load(id string) *MatchRecord {
+	// ID приходит от клиента и становится именем файла
+	if !strings.HasPrefix(id, "match_") || filepath.Base(id) != id {
+		return nil
+	}
+	record, err := readRecord(filepath.Join(a.dir, id+".json"))
+	if err != nil {
+		return nil
+	}
+	return record
+}
+
+// ForPlayer - последние бои игрока, новые первыми
+func (a *ReplayArchive) ForPlayer(name string, limit int) []MatchRecord {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+	found := make([]MatchRecord, 0)
+	for i := len(a.records) - 1; i >= 0 && len(found) < limit; i-- {
+		if a.records[i].Player1 == name || a.records[i].Player2 == name {
+			found = append(found, a.records[i])
+		}
+	}
+	return found
+}
+
+// archiveMatch - дописывает итог в журнал и сохраняет бой в архив. Вызывать под match.mutex
+func (s *ChatServer) archiveMatch(match *PvPMatch) {
+	winner := ""
+	if match.State == MatchFinished {
+		winner = match.Winner()
+	}
+	match.record(EventEnd, "", match.State, winner, match.Player1HP, match.Player2HP)
+
+	record := &MatchRecord{
+		ID:        match.ID,
+		Player1:   match.Player1.Name,
+		Player2:   match.Player2.Name,
+		Winner:    winner,
+		State:     match.State,
+		StartedAt: match.StartedAt,
+		EndedAt:   match.FinishedAt,
+		Rounds:    match.Round,
+		Events:    match.Events,
+	}
+	if err := s.replays.Save(record); err != nil {
+		s.logCh <- fmt.Sprintf("PvP: не удалось сохранить запись матча %s: %v", match.ID, err)
+	}
+}
+
+// outcomeFor - итог боя глазами игрока: win, loss, draw, abandoned или shutdown
+func outcomeFor(record *MatchRecord, playerName string) string {
+	switch {
+	case record.State != MatchFinished:
+		return string(record.State)
+	case record.Winner == "":
+		return "draw"
+	case record.Winner == playerName:
+		return "win"
+	default:
+		return "loss"
+	}
+}
+
+// handleReplays - список записанных боёв игрока: строки id|соперник|итог|конец (unix)|раундов
+func (s *ChatServer) handleReplays(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("player")
+	for _, record := range s.replays.ForPlayer(playerName, playerReplayLimit) {
+		opponent := record.Player2
+		if record.Player2 == playerName {
+			opponent = record.Player1
+		}
+		fmt.Fprintf(w, "%s|%s|%s|%d|%d\n", record.ID, opponent, outcomeFor(&record, playerName), record.EndedAt.Unix(), record.Rounds)
+	}
+}
+
+// handleReplay - журнал боя целиком. Первая строка: replay:id|игрок1|игрок2|победитель|состояние|начало|конец,
+// дальше по событию в строке: мс от начала|раунд|вид|игрок|данные...
+func (s *ChatServer) handleReplay(w http.ResponseWriter, r *http.Request) {
+	record := s.replays.Load(r.URL.Query().Get("id"))
+	if record == nil {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+
+	fmt.Fprintf(w, "replay:%s|%s|%s|%s|%s|%d|%d\n", record.ID, record.Player1, record.Player2,
+		record.Winner, record.State, record.StartedAt.Unix(), record.EndedAt.Unix())
+	for _, event := range record.Events {
+		fmt.Fprintln(w, formatEvent(event, record.StartedAt))
+	}
+}
+
+// formatEvent - строка события для клиента: мс от начала боя|раунд|вид|игрок|данные...
+func formatEvent(event MatchEvent, startedAt time.Time) string {
+	fields := append([]string{
+		fmt.Sprint(event.Time.Sub(startedAt).Milliseconds()),
+		fmt.Sprint(event.Round),
+		event.Kind,
+		event.Player,
+	}, event.Data...)
+	return strings.Join(fields, "|")
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"fmt"
+	"game/arena"
+	"game/dungeon"
+	"game/tournament"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Бои одиночной игры идут на клиенте, поэтому сумме от клиента сервер не верит:
+// награду он считает сам по правилам игры, следит за порядком гильдий и циклами Новой игры+
+// и ограничивает доход одиночной игры за сутки
+const (
+	dailyRewardCap = 2000
+	maxArenaWave   = 100
+)
+
+// handleReward - POST /shop/reward?key=, тело: Имя|Вид|Подробности
+// Виды: start|сложность|цикл, guild|номер, final, arena|start, arena|волна, dungeon|событие|этаж.
+// start и arena|start ничего не начисляют, а начинают новое прохождение или забег.
+// Ответ ok|баланс или error:код|баланс (bad_claim, out_of_order, daily_cap)
+func (ss *ServerShop) handleReward(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player := parts[0]
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	acc := ss.account(player)
+	amount, err := acc.claimReward(parts[1], parts[2:])
+	if err != nil {
+		fmt.Fprintf(w, "error:%s|%d", err, acc.Balance)
+		return
+	}
+	if amount == 0 {
+		ss.record(player, "restart", strings.Join(parts[1:], " "), 0, acc.Balance)
+		fmt.Fprintf(w, "ok|%d", acc.Balance)
+		return
+	}
+
+	amount = acc.dailyAllowance(amount, time.Now())
+	if amount == 0 {
+		fmt.Fprintf(w, "error:daily_cap|%d", acc.Balance)
+		return
+	}
+	acc.Balance += amount
+	ss.record(player, "reward", strings.Join(parts[1:], " "), amount, acc.Balance)
+
+	fmt.Fprintf(w, "ok|%d", acc.Balance)
+}
+
+// claimReward - проверяет заявку и двигает прогресс турнира, возвращает полную награду
+func (acc *ShopAccount) claimReward(kind string, args []string) (int, error) {
+	switch kind {
+	case "start":
+		if len(args) < 2 {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		cycle, err := strconv.Atoi(args[1])
+		difficulty, ok := claimDifficulty(args[0])
+		if err != nil || !ok || cycle < 0 {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		// Новое прохождение начинается с первой гильдии и только в уже открытом цикле
+		if cycle > acc.Cycle {
+			return 0, fmt.Errorf("out_of_order")
+		}
+		acc.Difficulty = difficulty
+		acc.GuildCycle = cycle
+		acc.Guilds = 0
+		return 0, nil
+
+	case "guild":
+		if len(args) < 1 {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		guild, err := strconv.Atoi(args[0])
+		if err != nil || guild < 0 || guild >= tournament.GuildCount {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		// Гильдии засчитываются строго по порядку в начатом прохождении
+		if acc.Difficulty == "" || guild != acc.Guilds {
+			return 0, fmt.Errorf("out_of_order")
+		}
+		acc.Guilds = guild + 1
+		return tournament.GuildReward(guild, acc.Difficulty, acc.GuildCycle), nil
+
+	case "final":
+		if acc.Difficulty == "" || acc.Guilds != tournament.GuildCount {
+			return 0, fmt.Errorf("out_of_order")
+		}
+		amount := tournament.FinalReward(acc.Difficulty, acc.GuildCycle)
+		acc.Difficulty = ""
+		acc.Guilds = 0
+		acc.Cycle = max(acc.Cycle, acc.GuildCycle+1)
+		return amount, nil
+
+	case "arena":
+		if len(args) < 1 {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		if args[0] == "start" {
+			acc.ArenaWave = 0
+			acc.ArenaKills = 0
+			return 0, nil
+		}
+		wave, err := strconv.Atoi(args[0])
+		if err != nil || wave < 1 || wave > maxArenaWave {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		// Победы засчитываются по одной: в текущей волне, пока в ней остались противники,
+		// или в следующей, когда текущая пройдена целиком
+		switch {
+		case wave == acc.ArenaWave && acc.ArenaKills < arena.WaveSize(wave):
+			acc.ArenaKills++
+		case wave == acc.ArenaWave+1 && (acc.ArenaWave == 0 || acc.ArenaKills == arena.WaveSize(acc.ArenaWave)):
+			acc.ArenaWave = wave
+			acc.ArenaKills = 1
+		default:
+			return 0, fmt.Errorf("out_of_order")
+		}
+		return arena.KillReward(wave), nil
+
+	case "dungeon":
+		if len(args) < 2 {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		floor, err := strconv.Atoi(args[1])
+		if err != nil {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		amount, ok := dungeon.Reward(args[0], floor)
+		if !ok {
+			return 0, fmt.Errorf("bad_claim")
+		}
+		return amount, nil
+	}
+	return 0, fmt.Errorf("bad_claim")
+}
+
+// claimDifficulty - сложность из заявки; в старых сохранениях она пустая и означает обычную
+func claimDifficulty(raw string) (tournament.Difficulty, bool) {
+	difficulty := tournament.Difficulty(raw)
+	if difficulty == "" {
+		difficulty = tournament.Normal
+	}
+	return difficulty, difficulty.Valid()
+}
+
+// dailyAllowance - сколько из награды ещё можно начислить сегодня
+func (acc *ShopAccount) dailyAllowance(amount int, now time.Time) int {
+	day := now.Format("2006-01-02")
+	if acc.RewardDay != day {
+		acc.RewardDay = day
+		acc.RewardToday = 0
+	}
+	amount = min(amount, dailyRewardCap-acc.RewardToday)
+	acc.RewardToday += amount
+	return amount
+}
@@ -0,0 +1,47 @@
+package server
+
+import "game/combat"
+
+// Over - бой решён: кто-то остался без здоровья или вышел лимит раундов. Вызывать под match.mutex
+func (m *PvPMatch) Over() bool {
+	if m.Player1HP <= 0 || m.Player2HP <= 0 {
+		return true
+	}
+	return m.Rules.RoundLimit > 0 && m.Round > m.Rules.RoundLimit
+}
+
+// Winner - имя победителя решённого боя, пусто - ничья. По лимиту раундов побеждает тот,
+// у кого осталась большая доля здоровья. Вызывать под match.mutex
+func (m *PvPMatch) Winner() string {
+	switch {
+	case m.Player1HP <= 0 && m.Player2HP <= 0:
+		return ""
+	case m.Player1HP <= 0:
+		return m.Player2.Name
+	case m.Player2HP <= 0:
+		return m.Player1.Name
+	}
+	share1, share2 := m.Player1HP*m.Player2.MaxHP, m.Player2HP*m.Player1.MaxHP
+	switch {
+	case share1 > share2:
+		return m.Player1.Name
+	case share2 > share1:
+		return m.Player2.Name
+	}
+	return ""
+}
+
+// withRules - настройка нового боя под особые правила: товарищеский бой не влияет на рейтинг и награды
+func withRules(rules combat.MatchRules) func(*PvPMatch) {
+	return func(match *PvPMatch) {
+		match.Rules = rules
+		match.Casual = true
+		if !rules.NormalizeHP {
+			return
+		}
+		for _, fighter := range []*PvPPlayer{match.Player1, match.Player2} {
+			fighter.HP, fighter.MaxHP = combat.NormalizedHP, combat.NormalizedHP
+		}
+		match.Player1HP, match.Player2HP = combat.NormalizedHP, combat.NormalizedHP
+	}
+}
@@ -0,0 +1,252 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	seasonFile   = "season.json"
+	seasonLength = 28 * 24 * time.Hour
+	maxFriends   = 50
+)
+
+// League - лига по рейтингу и награда за то, чтобы закончить в ней сезон
+type League struct {
+	Name      string
+	MinRating int
+	Reward    int
+}
+
+// От высшей лиги к низшей
+var leagues = []League{
+	{Name: "💎 Алмазная", MinRating: 1700, Reward: 500},
+	{Name: "🏆 Платиновая", MinRating: 1500, Reward: 350},
+	{Name: "🥇 Золотая", MinRating: 1350, Reward: 200},
+	{Name: "🥈 Серебряная", MinRating: 1200, Reward: 100},
+	{Name: "🥉 Бронзовая", MinRating: 0, Reward: 50},
+}
+
+func LeagueFor(rating int) League {
+	for _, league := range leagues {
+		if rating >= league.MinRating {
+			return league
+		}
+	}
+	return leagues[len(leagues)-1]
+}
+
+// SeasonState - текущий сезон, недельные очки и списки друзей
+type SeasonState struct {
+	Number    int
+	StartedAt time.Time
+	EndsAt    time.Time
+	Week      string              // неделя, за которую копятся очки
+	Weekly    map[string]int      // изменение рейтинга за неделю
+	Friends   map[string][]string // игрок -> друзья
+}
+
+// SeasonReward - награда игроку за закрытый сезон
+type SeasonReward struct {
+	Player string
+	Season int
+	League League
+	Rating int
+}
+
+// LeaderboardEntry - строка таблицы лидеров
+type LeaderboardEntry struct {
+	Name   string
+	Rating PlayerRating
+	Points int // для недельной таблицы - изменение рейтинга за неделю
+}
+
+func weekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// loadSeason - читает сезон с диска или открывает первый
+func (r *Ratings) loadSeason() {
+	raw, err := os.ReadFile(seasonFile)
+	if err == nil {
+		if err := json.Unmarshal(raw, &r.season); err != nil {
+			fmt.Println("⚠️ Данные сезона повреждены, открываем новый сезон:", err)
+			r.season = SeasonState{}
+		}
+	}
+	if r.season.Number == 0 {
+		now := time.Now()
+		r.season = SeasonState{Number: 1, StartedAt: now, EndsAt: now.Add(seasonLength), Week: weekKey(now)}
+	}
+	if r.season.Weekly == nil {
+		r.season.Weekly = make(map[string]int)
+	}
+	if r.season.Friends == nil {
+		r.season.Friends = make(map[string][]string)
+	}
+}
+
+// saveSeason - вызывать под mutex
+func (r *Ratings) saveSeason() {
+	raw, err := json.MarshalIndent(r.season, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(seasonFile, raw, 0644); err != nil {
+		fmt.Println("⚠️ Не удалось сохранить сезон:", err)
+	}
+}
+
+// CheckSeason - закрывает истёкшие сезон и неделю. Возвращает награды, которые нужно выдать
+func (r *Ratings) CheckSeason() []SeasonReward {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	rewards := make([]SeasonReward, 0)
+	changed := false
+
+	if week := weekKey(now); week != r.season.Week {
+		r.season.Week = week
+		r.season.Weekly = make(map[string]int)
+		changed = true
+	}
+
+	// Пока сервер был выключен, могло пройти несколько сезонов: награды только за тот, где были бои
+	for !now.Before(r.season.EndsAt) {
+		for name, pr := range r.players {
+			if pr.SeasonGames > 0 {
+				rewards = append(rewards, SeasonReward{Player: name, Season: r.season.Number, League: LeagueFor(pr.Rating), Rating: pr.Rating})
+			}
+			// Мягкий сброс: рейтинг наполовину возвращается к стартовому
+			pr.Rating = startRating + (pr.Rating-startRating)/2
+			pr.SeasonGames = 0
+			pr.SeasonWins = 0
+		}
+		r.season.Number++
+		r.season.StartedAt = r.season.EndsAt
+		r.season.EndsAt = r.season.EndsAt.Add(seasonLength)
+		changed = true
+	}
+
+	if changed {
+		r.save()
+		r.saveSeason()
+	}
+	return rewards
+}
+
+// Season - номер текущего сезона и время его окончания
+func (r *Ratings) Season() (int, time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.season.Number, r.season.EndsAt
+}
+
+// Top - игроки сезона по рейтингу; include отбирает игроков (nil - все)
+func (r *Ratings) Top(limit int, include func(name string) bool) []LeaderboardEntry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := make([]LeaderboardEntry, 0)
+	for name, pr := range r.players {
+		if include == nil && pr.SeasonGames == 0 {
+			continue
+		}
+		if include != nil && !include(name) {
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{Name: name, Rating: *pr})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Rating.Rating != entries[j].Rating.Rating {
+			return entries[i].Rating.Rating > entries[j].Rating.Rating
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+// WeeklyTop - кто больше всех поднял рейтинг за неделю
+func (r *Ratings) WeeklyTop(limit int) (string, []LeaderboardEntry) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	entries := make([]LeaderboardEntry, 0, len(r.season.Weekly))
+	for name, points := range r.season.Weekly {
+		entries = append(entries, LeaderboardEntry{Name: name, Rating: r.lookup(name), Points: points})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Points != entries[j].Points {
+			return entries[i].Points > entries[j].Points
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return r.season.Week, entries
+}
+
+// Place - место игрока в сезонной таблице (0 - ещё не играл в этом сезоне)
+func (r *Ratings) Place(name string) int {
+	for i, entry := range r.Top(0, nil) {
+		if entry.Name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func (r *Ratings) Friends(player string) []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string{}, r.season.Friends[player]...)
+}
+
+// AddFriend - коды ошибок: self, unknown (игрок не сыграл ни одного рейтингового боя), exists, limit
+func (r *Ratings) AddFriend(player, friend string) string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if player == friend {
+		return "self"
+	}
+	if _, ok := r.players[friend]; !ok {
+		return "unknown"
+	}
+	friends := r.season.Friends[player]
+	for _, name := range friends {
+		if name == friend {
+			return "exists"
+		}
+	}
+	if len(friends) >= maxFriends {
+		return "limit"
+	}
+	r.season.Friends[player] = append(friends, friend)
+	r.saveSeason()
+	return ""
+}
+
+func (r *Ratings) RemoveFriend(player, friend string) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	friends := r.season.Friends[player]
+	for i, name := range friends {
+		if name == friend {
+			r.season.Friends[player] = append(friends[:i], friends[i+1:]...)
+			r.saveSeason()
+			return true
+		}
+	}
+	return false
+}
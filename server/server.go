@@ -1,591 +1,743 @@
-package server
-
-import (
-	"bufio"
-	"fmt"
-	"io"
-	"net/http"
-	"os"
-	"strconv"
-	"strings"
-	"sync"
-	"time"
-)
-
-type ChatServer struct {
-	history []string
-	mutex   sync.RWMutex
-	logCh   chan string
-
-	// PvP данные
-	pvpQueue        []*PvPPlayer
-	pvpMatches      map[string]*PvPMatch
-	pvpMutex        sync.RWMutex
-	queueMutex      sync.Mutex
-	matchCounter    int
-	registeredNicks map[string]bool
-	nickMutex       sync.Mutex
-}
-
-type PvPPlayer struct {
-	Name     string
-	HP       int
-	MaxHP    int
-	Strength int
-}
-
-type PvPMatch struct {
-	ID               string
-	Player1          *PvPPlayer
-	Player2          *PvPPlayer
-	Player1HP        int
-	Player2HP        int
-	Round            int
-	Move1            *MoveData
-	Move2            *MoveData
-	Result           string
-	mutex            sync.RWMutex
-	ResultForPlayer1 string
-	ResultForPlayer2 string
-	Chat             []string
-	chatMutex        sync.Mutex
-	Finished bool
-	FinishedAt time.Time
-}
-
-type MoveData struct {
-	Attack int
-	Block  int
-}
-
-func NewChatServer() *ChatServer {
-	return &ChatServer{
-		registeredNicks: make(map[string]bool),
-		history:         make([]string, 0),
-		logCh:           make(chan string, 20),
-		pvpQueue:        make([]*PvPPlayer, 0),
-		pvpMatches:      make(map[string]*PvPMatch),
-		matchCounter:    0,
-	}
-}
-
-func (s *ChatServer) Start(port string) {
-	go s.printLogs()
-	go s.readServerInput()
-
-	// Чат
-	http.HandleFunc("/", s.handleRequests)
-	http.HandleFunc("/check-nick", s.handleCheckNick)
-	http.HandleFunc("/register-nick", s.handleRegisterNick)
-	// Чат для пвп
-	http.HandleFunc("/pvp/chat", s.HandlePvPChat)
-	http.HandleFunc("/pvp/chat/history", s.HandlePvPChatHistory)
-
-	// PvP
-	http.HandleFunc("/pvp/join", s.handlePvPJoin)
-	http.HandleFunc("/pvp/status", s.handlePvPStatus)
-	http.HandleFunc("/pvp/battle", s.handlePvPBattle)
-	http.HandleFunc("/pvp/move", s.handlePvPMove)
-
-	s.logCh <- "Сервер запущен на порту " + port
-	http.ListenAndServe(":"+port, nil)
-}
-// Печатаем логи на сервере (все запросы)
-func (s *ChatServer) printLogs() {
-	for msg := range s.logCh {
-		fmt.Println(msg)
-	}
-}
-
-func (s *ChatServer) readServerInput() {
-	scanner := bufio.NewScanner(os.Stdin)
-
-	for scanner.Scan() {
-		text := scanner.Text()
-
-		s.addMessage(text)
-		s.logCh <- "Вы: " + text
-	}
-}
-
-func (s *ChatServer) addMessage(msg string) {
-	s.mutex.Lock()
-	defer s.mutex.Unlock()
-	s.history = append(s.history, msg)
-}
-
-func (s *ChatServer) getHistory() []string {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
-
-	copyHistory := make([]string, len(s.history))
-	copy(copyHistory, s.history)
-	return copyHistory
-}
-
-func (s *ChatServer) handleRequests(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodPost:
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Ошибка чтения", http.StatusBadRequest)
-			return
-		}
-
-		message := strings.TrimSpace(string(body))
-		if message != "" {
-			s.addMessage(message)
-			s.logCh <- "Клиент: " + message
-		}
-
-		fmt.Fprint(w, "получено")
-
-	case http.MethodGet:
-		history := s.getHistory()
-		for _, msg := range history {
-			if msg != "" {
-				fmt.Fprintln(w, msg)
-			}
-		}
-
-	default:
-		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
-	}
-}
-
-// ========== PvP ОБРАБОТЧИКИ ==========
-
-func (s *ChatServer) handlePvPJoin(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-
-	// Формат: Имя|HP|MaxHP|Сила
-	parts := strings.Split(string(body), "|")
-	if len(parts) < 4 {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
-	}
-
-	hp, _ := strconv.Atoi(parts[1])
-	maxHP, _ := strconv.Atoi(parts[2])
-	strength, _ := strconv.Atoi(parts[3])
-
-	player := &PvPPlayer{
-		Name:     parts[0],
-		HP:       hp,
-		MaxHP:    maxHP,
-		Strength: strength,
-	}
-
-    s.pvpMutex.Lock()
-    for id, match := range s.pvpMatches {
-        if match.Finished {
-            delete(s.pvpMatches, id)
-        }
-    }
-    s.pvpMutex.Unlock()
-
-	s.pvpMutex.RLock()
-	for _, m := range s.pvpMatches {
-		if m.Player1.Name == player.Name || m.Player2.Name == player.Name {
-			s.pvpMutex.RUnlock()
-			fmt.Fprint(w, "already_in_match")
-			return
-		}
-	}
-	s.pvpMutex.RUnlock()
-
-	s.queueMutex.Lock()
-	defer s.queueMutex.Unlock()
-
-	// Если есть игрок в очереди, создаем матч
-	if len(s.pvpQueue) > 0 {
-
-		player1 := s.pvpQueue[0]
-		s.pvpQueue = s.pvpQueue[1:]
-
-		s.matchCounter++
-		matchID := fmt.Sprintf("match_%d", s.matchCounter)
-
-		match := &PvPMatch{
-			ID:        matchID,
-			Player1:   player1,
-			Player2:   player,
-			Player1HP: player1.HP,
-			Player2HP: player.HP,
-			Round:     1,
-			Finished:  false,
-    		Chat:      make([]string, 0), // Инициализируем чат
-		}
-
-		s.pvpMutex.Lock()
-		s.pvpMatches[matchID] = match
-		s.pvpMutex.Unlock()
-
-		// Отвечаем текущему игроку
-		response := fmt.Sprintf("match:%s|%s|%d|%d|%d",
-			matchID, player1.Name, player1.HP, player1.MaxHP, player1.Strength)
-		fmt.Fprint(w, response)
-
-		s.logCh <- fmt.Sprintf("PvP: Создан матч %s: %s vs %s", matchID, player1.Name, player.Name)
-	} else {
-		// Добавляем в очередь
-		s.pvpQueue = append(s.pvpQueue, player)
-		fmt.Fprint(w, "queued")
-		s.logCh <- fmt.Sprintf("PvP: %s в очереди", player.Name)
-	}
-}
-
-func (s *ChatServer) handlePvPStatus(w http.ResponseWriter, r *http.Request) {
-	playerName := r.URL.Query().Get("player")
-
-	// Проверяем, есть ли матч для игрока
-	s.pvpMutex.RLock()
-	for _, match := range s.pvpMatches {
-		if (match.Player1.Name == playerName || match.Player2.Name == playerName) && match.Result == "" {
-			// Определяем противника
-			var opponent *PvPPlayer
-			if match.Player1.Name == playerName {
-				opponent = match.Player2
-			} else {
-				opponent = match.Player1
-			}
-
-			response := fmt.Sprintf("match:%s|%s|%d|%d|%d",
-				match.ID, opponent.Name, opponent.HP, opponent.MaxHP, opponent.Strength)
-			s.pvpMutex.RUnlock()
-			fmt.Fprint(w, response)
-			return
-		}
-	}
-	s.pvpMutex.RUnlock()
-
-	fmt.Fprint(w, "waiting")
-}
-
-func (s *ChatServer) handlePvPBattle(w http.ResponseWriter, r *http.Request) {
-
-	
-
-	matchID := r.URL.Query().Get("matchId")
-	playerName := r.URL.Query().Get("player")
-
-	s.pvpMutex.RLock()
-	match, exists := s.pvpMatches[matchID]
-	s.pvpMutex.RUnlock()
-
-	if !exists {
-		http.Error(w, "Match not found", http.StatusNotFound)
-		return
-	}
-
-	match.mutex.Lock()
-	defer match.mutex.Unlock()
-
-	// Проверка завершения боя
-	if match.Player1HP <= 0 || match.Player2HP <= 0 {
-		if match.Player1HP <= 0 && match.Player2HP <= 0 {
-			fmt.Fprint(w, "finished:draw")
-		} else if match.Player1HP <= 0 {
-			if match.Player1.Name == playerName {
-				fmt.Fprint(w, "finished:loss")
-			} else {
-				fmt.Fprint(w, "finished:win")
-			}
-		} else {
-			if match.Player2.Name == playerName {
-				fmt.Fprint(w, "finished:loss")
-			} else {
-				fmt.Fprint(w, "finished:win")
-			}
-		}
-		match.Player1.HP = match.Player1.MaxHP
-		match.Player2.HP = match.Player2.MaxHP
-		s.pvpMutex.Lock()
-		match.Finished = true
-		match.FinishedAt = time.Now()
-		s.pvpMutex.Unlock()
-		return
-	}
-
-	if match.Finished {
-		if match.Player1.Name == playerName {
-			if match.Player1HP <= 0 {
-				fmt.Fprint(w, "finished:loss")
-			} else {
-				fmt.Fprint(w, "finished:win")
-			}
-		} else {
-			if match.Player2HP <= 0 {
-				fmt.Fprint(w, "finished:loss")
-			} else {
-				fmt.Fprint(w, "finished:win")
-			}
-		}
-		return
-	}
-
-	// Если оба хода сделаны и результаты ещё не отправлены, рассчитываем урон
-	if match.Move1 != nil && match.Move2 != nil && (match.ResultForPlayer1 == "" && match.ResultForPlayer2 == "") {
-		oldPlayer1HP := match.Player1HP
-		oldPlayer2HP := match.Player2HP
-
-		// Расчет урона
-		damageToPlayer1 := calculatePvPDamage(match.Player2.Strength, match.Move2.Attack, match.Move1.Block)
-		damageToPlayer2 := calculatePvPDamage(match.Player1.Strength, match.Move1.Attack, match.Move2.Block)
-
-		match.Player1HP -= damageToPlayer1
-		if match.Player1HP < 0 {
-			match.Player1HP = 0
-		}
-		match.Player2HP -= damageToPlayer2
-		if match.Player2HP < 0 {
-			match.Player2HP = 0
-		}
-
-		s.logCh <- fmt.Sprintf("PvP Раунд %d: %s нанес %d (%d→%d), %s нанес %d (%d→%d)",
-			match.Round,
-			match.Player1.Name, damageToPlayer2, oldPlayer1HP, match.Player1HP,
-			match.Player2.Name, damageToPlayer1, oldPlayer2HP, match.Player2HP,
-		)
-
-		// Формируем результаты для обоих игроков
-		match.ResultForPlayer1 = fmt.Sprintf("round_result:%d|%d|%d|%d|%d|%d|%d",
-			match.Round, damageToPlayer2, oldPlayer1HP, match.Player1HP, damageToPlayer1, oldPlayer2HP, match.Player2HP)
-		match.ResultForPlayer2 = fmt.Sprintf("round_result:%d|%d|%d|%d|%d|%d|%d",
-			match.Round, damageToPlayer1, oldPlayer2HP, match.Player2HP, damageToPlayer2, oldPlayer1HP, match.Player1HP)
-	}
-
-	// Отправка результата текущему игроку
-	if match.Player1.Name == playerName && match.ResultForPlayer1 != "" {
-		fmt.Fprint(w, match.ResultForPlayer1)
-		match.ResultForPlayer1 = ""
-	} else if match.Player2.Name == playerName && match.ResultForPlayer2 != "" {
-		fmt.Fprint(w, match.ResultForPlayer2)
-		match.ResultForPlayer2 = ""
-	}
-
-	// Сбрасываем ходы и увеличиваем раунд, если оба игрока получили результаты
-	if match.ResultForPlayer1 == "" && match.ResultForPlayer2 == "" && match.Move1 != nil && match.Move2 != nil {
-		match.Move1 = nil
-		match.Move2 = nil
-		match.Round++
-		s.logCh <- fmt.Sprintf("PvP: Раунд %d готов, ждём новые ходы", match.Round)
-		return
-	}
-
-	// Определяем, чей ход сейчас, если ходы не завершены
-	if match.Move1 == nil || match.Move2 == nil {
-		currentTurn := match.Player1.Name
-		if match.Move1 != nil {
-			currentTurn = match.Player2.Name
-		}
-		fmt.Fprintf(w, "wait_turn:%s", currentTurn)
-		return
-	}
-}
-
-func (s *ChatServer) handlePvPMove(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "Bad request", http.StatusBadRequest)
-		return
-	}
-
-	// Формат: matchID|playerName|attack|block
-	parts := strings.Split(string(body), "|")
-	if len(parts) < 4 {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
-	}
-
-	matchID := parts[0]
-	playerName := parts[1]
-	attack, _ := strconv.Atoi(parts[2])
-	block, _ := strconv.Atoi(parts[3])
-
-	s.pvpMutex.RLock()
-	match, exists := s.pvpMatches[matchID]
-	s.pvpMutex.RUnlock()
-
-	if !exists {
-		http.Error(w, "Match not found", http.StatusNotFound)
-		return
-	}
-
-	match.mutex.Lock()
-	defer match.mutex.Unlock()
-
-	// Сохраняем ход
-	if playerName == match.Player1.Name {
-		if match.Move1 != nil {
-			http.Error(w, "Move already submitted", http.StatusBadRequest)
-			return
-		}
-		match.Move1 = &MoveData{Attack: attack, Block: block}
-		s.logCh <- fmt.Sprintf("PvP: %s сделал ход (атака: %d, блок: %d)", playerName, attack, block)
-	} else if playerName == match.Player2.Name {
-		if match.Move2 != nil {
-			http.Error(w, "Move already submitted", http.StatusBadRequest)
-			return
-		}
-		match.Move2 = &MoveData{Attack: attack, Block: block}
-		s.logCh <- fmt.Sprintf("PvP: %s сделал ход (атака: %d, блок: %d)", playerName, attack, block)
-	} else {
-		http.Error(w, "Invalid player", http.StatusBadRequest)
-		return
-	}
-
-	// Если оба хода получены, просто логируем это
-	if match.Move1 != nil && match.Move2 != nil {
-		s.logCh <- fmt.Sprintf("PvP: Оба игрока сделали ход в раунде %d матча %s", match.Round, match.ID)
-	}
-
-	w.WriteHeader(http.StatusOK)
-}
-
-func (s *ChatServer) processPvPRound(match *PvPMatch) {
-	// Сохраняем здоровье ДО для логирования
-	oldPlayer1HP := match.Player1HP
-	oldPlayer2HP := match.Player2HP
-
-	// Расчет урона для игрока 1 (от атаки игрока 2)
-	damage1 := calculatePvPDamage(match.Player2.Strength, match.Move2.Attack, match.Move1.Block)
-	match.Player1HP -= damage1
-	if match.Player1HP < 0 {
-		match.Player1HP = 0
-	}
-
-	// Расчет урона для игрока 2 (от атаки игрока 1)
-	damage2 := calculatePvPDamage(match.Player1.Strength, match.Move1.Attack, match.Move2.Block)
-	match.Player2HP -= damage2
-	if match.Player2HP < 0 {
-		match.Player2HP = 0
-	}
-
-	// Логирование с отображением изменения здоровья
-	s.logCh <- fmt.Sprintf("PvP Раунд %d: %s нанес %d (%d❤️ → %d❤️), %s нанес %d (%d❤️ → %d❤️)",
-		match.Round,
-		match.Player1.Name, damage2, oldPlayer1HP, match.Player1HP,
-		match.Player2.Name, damage1, oldPlayer2HP, match.Player2HP)
-
-	// Сбрасываем ходы для следующего раунда
-	match.Move1 = nil
-	match.Move2 = nil
-	match.Round++
-}
-
-func calculatePvPDamage(strength, attack, block int) int {
-	// База: сила + случайный разброс
-	damage := strength + 5
-
-	// Модификаторы атаки
-	switch attack {
-	case 0: // Head
-		damage = int(float64(damage) * 1.3)
-	case 2: // Legs
-		damage = int(float64(damage) * 0.8)
-	}
-
-	// Блок
-	if attack == block {
-		damage = damage / 2
-	}
-
-	// Минимальный урон
-	if damage < 5 {
-		damage = 5
-	}
-
-	return damage
-}
-
-func (s *ChatServer) HandlePvPChat(w http.ResponseWriter, r *http.Request) {
-    matchID := r.URL.Query().Get("matchID")
-    player := r.URL.Query().Get("player")
-    msg := r.URL.Query().Get("msg")
-
-    // Берём pvpMutex, чтобы безопасно получить матч
-    s.pvpMutex.Lock()
-    match, exists := s.pvpMatches[matchID]
-    if !exists {
-        s.pvpMutex.Unlock()
-        http.Error(w, "Match not found", http.StatusNotFound)
-        return
-    }
-
-    // Блокируем матч и отпускаем глобальный pvpMutex
-    match.mutex.Lock()
-    s.pvpMutex.Unlock()
-    defer match.mutex.Unlock()
-
-    // Работа с чатом
-    match.chatMutex.Lock()
-    match.Chat = append(match.Chat, fmt.Sprintf("[%s]: %s", player, msg))
-    if len(match.Chat) > 10 {
-        match.Chat = match.Chat[1:]
-    }
-    match.chatMutex.Unlock()
-
-    w.Write([]byte("ok"))
-}
-func (s *ChatServer) handleCheckNick(w http.ResponseWriter, r *http.Request) {
-	name := strings.ToLower(r.URL.Query().Get("name"))
-
-	s.nickMutex.Lock()
-	defer s.nickMutex.Unlock()
-
-	if s.registeredNicks[name] {
-		fmt.Fprint(w, "exists")
-		return
-	}
-	fmt.Fprint(w, "ok")
-}
-
-func (s *ChatServer) HandlePvPChatHistory(w http.ResponseWriter, r *http.Request) {
-	matchID := r.URL.Query().Get("matchID")
-
-	s.pvpMutex.RLock()
-	match, ok := s.pvpMatches[matchID]
-	s.pvpMutex.RUnlock()
-
-	if !ok {
-		return
-	}
-
-	match.chatMutex.Lock()
-	defer match.chatMutex.Unlock()
-
-	for _, msg := range match.Chat {
-		fmt.Fprintln(w, msg)
-	}
-}
-
-func (s *ChatServer) handleRegisterNick(w http.ResponseWriter, r *http.Request) {
-	body, _ := io.ReadAll(r.Body)
-	name := strings.ToLower(strings.TrimSpace(string(body)))
-
-	s.nickMutex.Lock()
-	s.registeredNicks[name] = true
-	s.nickMutex.Unlock()
-
-	fmt.Fprint(w, "registered")
-}
-
-func (p *PvPMatch) SetHpFull(){
-	p.Player1.HP = p.Player1.MaxHP
-	p.Player2.HP = p.Player2.MaxHP
-} 
\ No newline at end of file
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"game/combat"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ChatServer struct {
+	history []string
+	mutex   sync.RWMutex
+	logCh   chan string
+
+	// PvP данные
+	pvpQueue        []*PvPPlayer
+	pvpMatches      map[string]*PvPMatch
+	pvpMutex        sync.RWMutex
+	queueMutex      sync.Mutex
+	// Среднее время ожидания в очереди (для оценки на экране поиска)
+	queueWaitAvg    time.Duration
+	matchCounter    int
+	botCounter      int // под queueMutex
+	registeredNicks map[string]bool
+	nickMutex       sync.Mutex
+
+	// Серверная лавка и кошельки игроков
+	shop *ServerShop
+	// Рейтинги PvP по аккаунтам
+	ratings *Ratings
+	// Записи завершённых боёв
+	replays *ReplayArchive
+
+	// Вызовы на бой и приватные лобби
+	invites       map[string]*Invite
+	inviteCounter int
+	invitesMutex  sync.Mutex
+
+	// PvP-турниры
+	cups       map[string]*Cup
+	cupCounter int
+	cupsMutex  sync.Mutex
+
+	// Жизненный цикл боёв и остановка сервера
+	config       LifecycleConfig
+	metrics      *matchMetrics
+	httpServer   *http.Server
+	shuttingDown atomic.Bool
+	stop         chan struct{}
+	stopped      chan struct{}
+}
+
+type PvPPlayer struct {
+	Name     string
+	HP       int
+	MaxHP    int
+	Strength int
+	Rating   int
+	QueuedAt time.Time
+	LastSeen time.Time // последний опрос из очереди; молчащие игроки выбывают
+	Token    string    // секрет сессии: выдаётся один раз при записи на бой, нужен для ходов и возвращения
+}
+
+type PvPMatch struct {
+	ID               string
+	Player1          *PvPPlayer
+	Player2          *PvPPlayer
+	Player1HP        int
+	Player2HP        int
+	Round            int
+	Move1            *MoveData
+	Move2            *MoveData
+	mutex            sync.RWMutex
+	ResultForPlayer1 string
+	ResultForPlayer2 string
+	Chat             []string
+	chatMutex        sync.Mutex
+	State      MatchState
+	FinishedAt time.Time // когда бой вышел из состояния active
+	StartedAt  time.Time
+	Events     []MatchEvent // журнал боя для записи в архив
+	Spectators map[string]time.Time // зрители и время их последнего опроса
+	CupID      string // бой турнира: вместо наград за бой - призы турнира
+	Rules      combat.MatchRules
+	Casual     bool // товарищеский бой: без рейтинга и наград
+	Bot        botBrain // ИИ второго игрока, если против человека вышел бот
+
+	// Расходники в бою: учёт лимитов и оставшиеся раунды оглушения каждого игрока
+	Items1  *combat.ConsumableTracker
+	Items2  *combat.ConsumableTracker
+	Stun1   int
+	Stun2   int
+
+	// Время последнего опроса каждого игрока (токены сессий - у самих игроков)
+	Seen1   time.Time
+	Seen2   time.Time
+}
+
+type MoveData struct {
+	Attack int
+	Block  int
+}
+
+func NewChatServer() *ChatServer {
+	logCh := make(chan string, 20)
+	replays := NewReplayArchive(replaysDir)
+	return &ChatServer{
+		registeredNicks: make(map[string]bool),
+		history:         make([]string, 0),
+		logCh:           logCh,
+		pvpQueue:        make([]*PvPPlayer, 0),
+		pvpMatches:      make(map[string]*PvPMatch),
+		matchCounter:    replays.LastMatchNumber(),
+		shop:            NewServerShop(logCh),
+		ratings:         NewRatings(),
+		replays:         replays,
+		cups:            make(map[string]*Cup),
+		invites:         make(map[string]*Invite),
+		config:          LifecycleConfigFromEnv(),
+		metrics:         newMatchMetrics(),
+		stop:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+	}
+}
+
+func (s *ChatServer) Start(port string) {
+	go s.printLogs()
+	go s.readServerInput()
+	go s.runJanitor()
+	go s.waitForSignal()
+
+	// Чат
+	http.HandleFunc("/", s.handleRequests)
+	http.HandleFunc("/check-nick", s.handleCheckNick)
+	http.HandleFunc("/register-nick", s.handleRegisterNick)
+	// Чат для пвп
+	http.HandleFunc("/pvp/chat", s.HandlePvPChat)
+	http.HandleFunc("/pvp/chat/history", s.HandlePvPChatHistory)
+
+	// PvP
+	http.HandleFunc("/pvp/join", s.handlePvPJoin)
+	http.HandleFunc("/pvp/leave", s.handlePvPLeave)
+	http.HandleFunc("/pvp/status", s.handlePvPStatus)
+	http.HandleFunc("/pvp/battle", s.handlePvPBattle)
+	http.HandleFunc("/pvp/move", s.handlePvPMove)
+	http.HandleFunc("/pvp/item", s.handlePvPItem)
+	http.HandleFunc("/pvp/resume", s.handlePvPResume)
+	http.HandleFunc("/pvp/forfeit", s.handlePvPForfeit)
+	http.HandleFunc("/pvp/replays", s.handleReplays)
+	http.HandleFunc("/pvp/replay", s.handleReplay)
+	http.HandleFunc("/pvp/live", s.handleLiveMatches)
+	http.HandleFunc("/pvp/watch", s.handleWatch)
+	http.HandleFunc("/metrics", s.handleMetrics)
+
+	// Вызовы друзей и приватные лобби
+	http.HandleFunc("/challenge/send", s.handleChallengeSend)
+	http.HandleFunc("/challenge/list", s.handleChallengeList)
+	http.HandleFunc("/challenge/accept", s.handleChallengeAccept)
+	http.HandleFunc("/challenge/decline", s.handleInviteDecline)
+	http.HandleFunc("/challenge/status", s.handleInviteStatus)
+	http.HandleFunc("/lobby/create", s.handleLobbyCreate)
+	http.HandleFunc("/lobby/join", s.handleLobbyJoin)
+	http.HandleFunc("/lobby/close", s.handleInviteDecline)
+	http.HandleFunc("/lobby/status", s.handleInviteStatus)
+
+	// PvP-турниры
+	http.HandleFunc("/cup/list", s.handleCupList)
+	http.HandleFunc("/cup/create", s.handleCupCreate)
+	http.HandleFunc("/cup/join", s.handleCupJoin)
+	http.HandleFunc("/cup/leave", s.handleCupLeave)
+	http.HandleFunc("/cup/bracket", s.handleCupBracket)
+	http.HandleFunc("/cup/status", s.handleCupStatus)
+
+	// Рейтинговые сезоны и таблицы лидеров
+	http.HandleFunc("/leaderboard", s.handleLeaderboard)
+	http.HandleFunc("/leaderboard/weekly", s.handleWeeklyLeaderboard)
+	http.HandleFunc("/leaderboard/friends", s.handleFriendsLeaderboard)
+	http.HandleFunc("/friends", s.handleFriends)
+	http.HandleFunc("/friends/add", s.handleFriends)
+	http.HandleFunc("/friends/remove", s.handleFriends)
+
+	// Лавка: покупка, продажа, сверка, награды и история - с ключом аккаунта в параметре key
+	http.HandleFunc("/shop/register", s.shop.handleRegister)
+	http.HandleFunc("/shop/offers", s.shop.handleOffers)
+	http.HandleFunc("/shop/balance", s.shop.handleBalance)
+	http.HandleFunc("/shop/buy", s.shop.handleBuy)
+	http.HandleFunc("/shop/sell", s.shop.handleSell)
+	http.HandleFunc("/shop/sync", s.shop.handleSync)
+	http.HandleFunc("/shop/reward", s.shop.handleReward)
+	http.HandleFunc("/shop/transactions", s.shop.handleTransactions)
+
+	// Обмен между игроками и аукцион: все действия от имени игрока - с ключом аккаунта в параметре key
+	http.HandleFunc("/trade/offer", s.shop.handleTradeOffer)
+	http.HandleFunc("/trade/accept", s.shop.handleTradeAccept)
+	http.HandleFunc("/trade/cancel", s.shop.handleTradeCancel)
+	http.HandleFunc("/trade/list", s.shop.handleTradeList)
+	http.HandleFunc("/auction/list", s.shop.handleAuctionList)
+	http.HandleFunc("/auction/create", s.shop.handleAuctionCreate)
+	http.HandleFunc("/auction/bid", s.shop.handleAuctionBid)
+	http.HandleFunc("/auction/buyout", s.shop.handleAuctionBuyout)
+	http.HandleFunc("/auction/cancel", s.shop.handleAuctionCancel)
+	http.HandleFunc("/market/inventory", s.shop.handleInventory)
+	http.HandleFunc("/market/claim", s.shop.handleClaim)
+
+	s.logCh <- "Сервер запущен на порту " + port
+	s.httpServer = &http.Server{Addr: ":" + port}
+	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+		fmt.Println("⚠️ Сервер не запустился:", err)
+		return
+	}
+	// Ждём, пока Shutdown договорит с клиентами
+	<-s.stopped
+}
+// Печатаем логи на сервере (все запросы)
+func (s *ChatServer) printLogs() {
+	for msg := range s.logCh {
+		fmt.Println(msg)
+	}
+}
+
+func (s *ChatServer) readServerInput() {
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for scanner.Scan() {
+		text := scanner.Text()
+		if text == "/stop" {
+			go s.Shutdown()
+			continue
+		}
+
+		s.addMessage(text)
+		s.logCh <- "Вы: " + text
+	}
+}
+
+func (s *ChatServer) addMessage(msg string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.history = append(s.history, msg)
+}
+
+func (s *ChatServer) getHistory() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	copyHistory := make([]string, len(s.history))
+	copy(copyHistory, s.history)
+	return copyHistory
+}
+
+func (s *ChatServer) handleRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Ошибка чтения", http.StatusBadRequest)
+			return
+		}
+
+		message := strings.TrimSpace(string(body))
+		if message != "" {
+			s.addMessage(message)
+			s.logCh <- "Клиент: " + message
+		}
+
+		fmt.Fprint(w, "получено")
+
+	case http.MethodGet:
+		history := s.getHistory()
+		for _, msg := range history {
+			if msg != "" {
+				fmt.Fprintln(w, msg)
+			}
+		}
+
+	default:
+		http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+	}
+}
+
+// ========== PvP ОБРАБОТЧИКИ ==========
+
+func (s *ChatServer) handlePvPJoin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Формат: Имя|HP|MaxHP|Сила|номера надетого снаряжения. Ответ: queued:рейтинг|токен сессии
+	parts := strings.Split(string(body), "|")
+	if len(parts) < 4 {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	player, err := s.parsePvPPlayer(parts)
+	if err != nil {
+		fmt.Fprint(w, "error:"+err.Error())
+		return
+	}
+
+	if s.shuttingDown.Load() {
+		fmt.Fprint(w, "shutdown")
+		return
+	}
+
+	// Завершённые бои убирает уборщик (см. runJanitor), здесь важны только идущие
+	if s.findMatch(player.Name) != nil {
+		fmt.Fprint(w, "already_in_match")
+		return
+	}
+
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+
+	s.pruneQueue()
+	if s.queueIndex(player.Name) >= 0 {
+		fmt.Fprint(w, "already_queued")
+		return
+	}
+
+	// Встаём в очередь и сразу ищем соперника с близким рейтингом. Токен сессии выдаётся
+	// только здесь, бой (даже найденный сразу) клиент узнаёт из /pvp/status
+	s.pvpQueue = append(s.pvpQueue, player)
+	s.matchQueue()
+
+	fmt.Fprintf(w, "queued:%d|%s", player.Rating, player.Token)
+	s.logCh <- fmt.Sprintf("PvP: %s в очереди (рейтинг %d)", player.Name, player.Rating)
+}
+
+// findMatch - идущий бой игрока
+func (s *ChatServer) findMatch(playerName string) *PvPMatch {
+	s.pvpMutex.RLock()
+	defer s.pvpMutex.RUnlock()
+	for _, match := range s.pvpMatches {
+		if (match.Player1.Name == playerName || match.Player2.Name == playerName) && match.Active() {
+			return match
+		}
+	}
+	return nil
+}
+
+func (s *ChatServer) handlePvPStatus(w http.ResponseWriter, r *http.Request) {
+	playerName := r.URL.Query().Get("player")
+	if s.shuttingDown.Load() {
+		fmt.Fprint(w, "shutdown")
+		return
+	}
+
+	// Допуск по рейтингу растёт со временем, поэтому очередь пересматривается при каждом опросе.
+	// Сам опрос продлевает место в очереди
+	s.queueMutex.Lock()
+	defer s.queueMutex.Unlock()
+	s.pruneQueue()
+	if i := s.queueIndex(playerName); i >= 0 {
+		s.pvpQueue[i].LastSeen = time.Now()
+	}
+	s.matchQueue()
+	s.matchBots()
+
+	if match := s.findMatch(playerName); match != nil {
+		fmt.Fprint(w, matchInfo(match, playerName))
+		return
+	}
+
+	i := s.queueIndex(playerName)
+	if i < 0 {
+		fmt.Fprint(w, "not_queued")
+		return
+	}
+	fmt.Fprintf(w, "queued:%d|%d|%d|%d", i+1, len(s.pvpQueue), int(s.estimateWait(s.pvpQueue[i]).Seconds()), s.pvpQueue[i].Rating)
+}
+
+func (s *ChatServer) handlePvPBattle(w http.ResponseWriter, r *http.Request) {
+
+	
+
+	matchID := r.URL.Query().Get("matchId")
+	playerName := r.URL.Query().Get("player")
+
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[matchID]
+	s.pvpMutex.RUnlock()
+
+	if !exists {
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	}
+	if !match.owns(playerName, r.URL.Query().Get("token")) {
+		http.Error(w, "Invalid session", http.StatusForbidden)
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+
+	s.botTurn(match)
+	s.touchMatch(match, playerName)
+
+	// Бой прерван остановкой сервера или брошен обоими игроками
+	switch match.State {
+	case MatchShutdown:
+		fmt.Fprint(w, "shutdown")
+		return
+	case MatchAbandoned:
+		fmt.Fprint(w, "finished:abandoned")
+		return
+	}
+
+	// Проверка завершения боя
+	if match.Over() {
+		switch match.Winner() {
+		case "":
+			fmt.Fprint(w, "finished:draw")
+		case playerName:
+			fmt.Fprint(w, "finished:win")
+		default:
+			fmt.Fprint(w, "finished:loss")
+		}
+		match.Player1.HP = match.Player1.MaxHP
+		match.Player2.HP = match.Player2.MaxHP
+		if match.State == MatchActive {
+			s.creditPvPResult(match)
+			s.setMatchState(match, MatchFinished)
+		}
+		return
+	}
+
+	// Если оба хода сделаны и результаты ещё не отправлены, рассчитываем урон
+	if match.Move1 != nil && match.Move2 != nil && (match.ResultForPlayer1 == "" && match.ResultForPlayer2 == "") {
+		oldPlayer1HP := match.Player1HP
+		oldPlayer2HP := match.Player2HP
+
+		// Расчет урона
+		damageToPlayer1 := calculatePvPDamage(match.Player2.Strength, match.Move2.Attack, match.Move1.Block)
+		damageToPlayer2 := calculatePvPDamage(match.Player1.Strength, match.Move1.Attack, match.Move2.Block)
+		damageToPlayer1, damageToPlayer2 = s.applyStuns(match, damageToPlayer1, damageToPlayer2)
+
+		match.Player1HP -= damageToPlayer1
+		if match.Player1HP < 0 {
+			match.Player1HP = 0
+		}
+		match.Player2HP -= damageToPlayer2
+		if match.Player2HP < 0 {
+			match.Player2HP = 0
+		}
+
+		match.record(EventRound, "", damageToPlayer1, damageToPlayer2, match.Player1HP, match.Player2HP)
+		s.logCh <- fmt.Sprintf("PvP Раунд %d: %s нанес %d (%d→%d), %s нанес %d (%d→%d)",
+			match.Round,
+			match.Player1.Name, damageToPlayer2, oldPlayer1HP, match.Player1HP,
+			match.Player2.Name, damageToPlayer1, oldPlayer2HP, match.Player2HP,
+		)
+
+		// Формируем результаты для обоих игроков
+		match.ResultForPlayer1 = fmt.Sprintf("round_result:%d|%d|%d|%d|%d|%d|%d",
+			match.Round, damageToPlayer2, oldPlayer1HP, match.Player1HP, damageToPlayer1, oldPlayer2HP, match.Player2HP)
+		match.ResultForPlayer2 = fmt.Sprintf("round_result:%d|%d|%d|%d|%d|%d|%d",
+			match.Round, damageToPlayer1, oldPlayer2HP, match.Player2HP, damageToPlayer2, oldPlayer1HP, match.Player1HP)
+		botCollect(match)
+	}
+
+	// Отправка результата текущему игроку
+	if match.Player1.Name == playerName && match.ResultForPlayer1 != "" {
+		fmt.Fprint(w, match.ResultForPlayer1)
+		match.ResultForPlayer1 = ""
+	} else if match.Player2.Name == playerName && match.ResultForPlayer2 != "" {
+		fmt.Fprint(w, match.ResultForPlayer2)
+		match.ResultForPlayer2 = ""
+	}
+
+	// Сбрасываем ходы и увеличиваем раунд, если оба игрока получили результаты
+	if match.ResultForPlayer1 == "" && match.ResultForPlayer2 == "" && match.Move1 != nil && match.Move2 != nil {
+		match.Move1 = nil
+		match.Move2 = nil
+		match.Round++
+		s.logCh <- fmt.Sprintf("PvP: Раунд %d готов, ждём новые ходы", match.Round)
+		return
+	}
+
+	// Определяем, чей ход сейчас, если ходы не завершены
+	if match.Move1 == nil || match.Move2 == nil {
+		currentTurn := match.Player1.Name
+		if match.Move1 != nil {
+			currentTurn = match.Player2.Name
+		}
+		if left := opponentAway(match, playerName); left > 0 && currentTurn != playerName {
+			fmt.Fprintf(w, "opponent_away:%d", left)
+			return
+		}
+		fmt.Fprintf(w, "wait_turn:%s", currentTurn)
+		return
+	}
+}
+
+func (s *ChatServer) handlePvPMove(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	// Формат: matchID|playerName|attack|block|token
+	parts := strings.Split(string(body), "|")
+	if len(parts) < 5 {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	matchID := parts[0]
+	playerName := parts[1]
+	attack, ok1 := parseZone(parts[2])
+	block, ok2 := parseZone(parts[3])
+	if !ok1 || !ok2 {
+		http.Error(w, "Invalid attack or block", http.StatusBadRequest)
+		return
+	}
+
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[matchID]
+	s.pvpMutex.RUnlock()
+
+	if !exists {
+		http.Error(w, "Match not found", http.StatusNotFound)
+		return
+	}
+	if !match.owns(playerName, parts[4]) {
+		http.Error(w, "Invalid session", http.StatusForbidden)
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+
+	if match.State != MatchActive {
+		http.Error(w, "Match is over", http.StatusConflict)
+		return
+	}
+
+	// Сохраняем ход
+	if playerName == match.Player1.Name {
+		if match.Move1 != nil {
+			http.Error(w, "Move already submitted", http.StatusBadRequest)
+			return
+		}
+		match.Move1 = &MoveData{Attack: attack, Block: block}
+		match.record(EventMove, playerName, attack, block)
+		s.logCh <- fmt.Sprintf("PvP: %s сделал ход (атака: %d, блок: %d)", playerName, attack, block)
+	} else if playerName == match.Player2.Name {
+		if match.Move2 != nil {
+			http.Error(w, "Move already submitted", http.StatusBadRequest)
+			return
+		}
+		match.Move2 = &MoveData{Attack: attack, Block: block}
+		match.record(EventMove, playerName, attack, block)
+		s.logCh <- fmt.Sprintf("PvP: %s сделал ход (атака: %d, блок: %d)", playerName, attack, block)
+	} else {
+		http.Error(w, "Invalid player", http.StatusBadRequest)
+		return
+	}
+
+	// Если оба хода получены, просто логируем это
+	if match.Move1 != nil && match.Move2 != nil {
+		s.logCh <- fmt.Sprintf("PvP: Оба игрока сделали ход в раунде %d матча %s", match.Round, match.ID)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// creditPvPResult - награда в серверный кошелёк: 100 за победу, 50 за участие.
+// За бои турнира платят призами в конце турнира, рейтинг меняется как обычно.
+// Товарищеские бои не дают ни наград, ни рейтинга, бой с ботом даёт награду, но не рейтинг
+func (s *ChatServer) creditPvPResult(match *PvPMatch) {
+	if match.Casual {
+		return
+	}
+	reward1, reward2 := 50, 50
+	switch match.Winner() {
+	case match.Player1.Name:
+		reward1 = 100
+	case match.Player2.Name:
+		reward2 = 100
+	}
+	if match.Bot != nil {
+		s.shop.Credit(match.Player1.Name, reward1, "pvp")
+		return
+	}
+	if match.CupID == "" {
+		s.shop.Credit(match.Player1.Name, reward1, "pvp")
+		s.shop.Credit(match.Player2.Name, reward2, "pvp")
+	}
+
+	// Бой, закончившийся после конца сезона, идёт в зачёт уже нового
+	s.checkSeason()
+	score1 := 0.5
+	if reward1 > reward2 {
+		score1 = 1
+	} else if reward2 > reward1 {
+		score1 = 0
+	}
+	delta1, delta2 := s.ratings.Record(match.Player1.Name, match.Player2.Name, score1)
+	s.logCh <- fmt.Sprintf("PvP: рейтинг %s %+d, %s %+d", match.Player1.Name, delta1, match.Player2.Name, delta2)
+}
+
+// parseZone - зона удара или блока из хода: голова, тело или ноги (combat.Head..combat.Legs)
+func parseZone(raw string) (int, bool) {
+	zone, err := strconv.Atoi(raw)
+	if err != nil || zone < int(combat.Head) || zone > int(combat.Legs) {
+		return 0, false
+	}
+	return zone, true
+}
+
+func calculatePvPDamage(strength, attack, block int) int {
+	// Ход потрачен на предмет
+	if attack == int(combat.ItemUsed) {
+		return 0
+	}
+
+	// База: сила + случайный разброс
+	damage := strength + 5
+
+	// Модификаторы атаки
+	switch attack {
+	case 0: // Head
+		damage = int(float64(damage) * 1.3)
+	case 2: // Legs
+		damage = int(float64(damage) * 0.8)
+	}
+
+	// Блок
+	if attack == block {
+		damage = damage / 2
+	}
+
+	// Минимальный урон
+	if damage < 5 {
+		damage = 5
+	}
+
+	return damage
+}
+
+func (s *ChatServer) HandlePvPChat(w http.ResponseWriter, r *http.Request) {
+    matchID := r.URL.Query().Get("matchID")
+    player := r.URL.Query().Get("player")
+    msg := r.URL.Query().Get("msg")
+
+    // Берём pvpMutex, чтобы безопасно получить матч
+    s.pvpMutex.Lock()
+    match, exists := s.pvpMatches[matchID]
+    if !exists {
+        s.pvpMutex.Unlock()
+        http.Error(w, "Match not found", http.StatusNotFound)
+        return
+    }
+
+    // Блокируем матч и отпускаем глобальный pvpMutex
+    match.mutex.Lock()
+    s.pvpMutex.Unlock()
+    defer match.mutex.Unlock()
+
+    // Зрители смотрят бой только для чтения, а писать от имени игрока можно только с его токеном сессии
+    if player != match.Player1.Name && player != match.Player2.Name {
+        http.Error(w, "Spectators cannot chat", http.StatusForbidden)
+        return
+    }
+    if !match.owns(player, r.URL.Query().Get("token")) {
+        http.Error(w, "Invalid session", http.StatusForbidden)
+        return
+    }
+
+    // Работа с чатом
+    match.chatMutex.Lock()
+    match.Chat = append(match.Chat, fmt.Sprintf("[%s]: %s", player, msg))
+    if len(match.Chat) > 10 {
+        match.Chat = match.Chat[1:]
+    }
+    match.chatMutex.Unlock()
+    match.record(EventChat, player, msg)
+
+    w.Write([]byte("ok"))
+}
+func (s *ChatServer) handleCheckNick(w http.ResponseWriter, r *http.Request) {
+	name := strings.ToLower(r.URL.Query().Get("name"))
+
+	s.nickMutex.Lock()
+	defer s.nickMutex.Unlock()
+
+	if s.registeredNicks[name] {
+		fmt.Fprint(w, "exists")
+		return
+	}
+	fmt.Fprint(w, "ok")
+}
+
+func (s *ChatServer) HandlePvPChatHistory(w http.ResponseWriter, r *http.Request) {
+	matchID := r.URL.Query().Get("matchID")
+
+	s.pvpMutex.RLock()
+	match, ok := s.pvpMatches[matchID]
+	s.pvpMutex.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	match.chatMutex.Lock()
+	defer match.chatMutex.Unlock()
+
+	for _, msg := range match.Chat {
+		fmt.Fprintln(w, msg)
+	}
+}
+
+func (s *ChatServer) handleRegisterNick(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	name := strings.ToLower(strings.TrimSpace(string(body)))
+
+	s.nickMutex.Lock()
+	s.registeredNicks[name] = true
+	s.nickMutex.Unlock()
+
+	fmt.Fprint(w, "registered")
+}
@@ -0,0 +1,494 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"game/items"
+	"game/tournament"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	startingBalance   = 150 // Столько же выдаёт player.NewPlayer
+	serverSellPercent = 40
+	dailyDealsCount   = 3
+	dailyDealDiscount = 30 // % скидки на товар дня
+	transactionsFile  = "transactions.log"
+	accountsFile      = "accounts.json"
+)
+
+// ShopAccount - кошелёк и инвентарь игрока, которые хранит сервер
+type ShopAccount struct {
+	KeyHash   string // SHA-256 ключа аккаунта: сам ключ знает только клиент, получивший его при регистрации
+	Balance   int
+	Inventory map[string]int  // Расходники: название -> количество
+	Gear      map[string]bool // Снаряжение: номера экземпляров из реестра лавки
+	Mailbox   []string        // Предметы (номера или названия), полученные от других игроков и ещё не забранные клиентом
+
+	// Прогресс одиночной игры, по которому сервер принимает заявки на награды
+	Cycle       int                   // Открытый цикл Новой игры+ (0 - финал ещё не пройден)
+	GuildCycle  int                   // Цикл, в котором идёт текущее прохождение гильдий
+	Difficulty  tournament.Difficulty // Сложность текущего прохождения (пусто - прохождение не начато)
+	Guilds      int                   // Сколько гильдий подряд засчитано в текущем прохождении
+	ArenaWave   int                   // Последняя волна текущего забега на арене, за которую заявлена награда
+	ArenaKills  int                   // Сколько побед в волне ArenaWave уже засчитано
+	RewardDay   string
+	RewardToday int // Начислено за одиночную игру в день RewardDay
+}
+
+type Transaction struct {
+	Time    time.Time
+	Player  string
+	Kind    string // register, buy, sell, withdraw, reward, fee, trade, auction
+	Item    string
+	Amount  int
+	Balance int
+}
+
+type ServerShop struct {
+	accounts     map[string]*ShopAccount
+	items        map[string]*items.Item // экземпляры снаряжения, выданные лавкой: номер -> предмет
+	transactions []Transaction
+	trades       map[string]*TradeOffer
+	auctions     map[string]*Auction
+	nextID       int
+	mutex        sync.Mutex
+	logCh        chan string
+}
+
+// shopState - то, что лавка сохраняет на диск: кошельки, а также обмены и лоты с залогом
+type shopState struct {
+	Accounts map[string]*ShopAccount
+	Items    map[string]*items.Item
+	Trades   map[string]*TradeOffer
+	Auctions map[string]*Auction
+	NextID   int
+}
+
+func NewServerShop(logCh chan string) *ServerShop {
+	ss := &ServerShop{
+		accounts: make(map[string]*ShopAccount),
+		items:    make(map[string]*items.Item),
+		trades:   make(map[string]*TradeOffer),
+		auctions: make(map[string]*Auction),
+		logCh:    logCh,
+	}
+	ss.load()
+	return ss
+}
+
+// load - читает кошельки, обмены и лоты, сохранённые до перезапуска
+func (ss *ServerShop) load() {
+	raw, err := os.ReadFile(accountsFile)
+	if err != nil {
+		return
+	}
+	var state shopState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		fmt.Println("⚠️ Кошельки лавки повреждены, начинаем с чистого листа:", err)
+		return
+	}
+	for name, acc := range state.Accounts {
+		if acc.Inventory == nil {
+			acc.Inventory = make(map[string]int)
+		}
+		if acc.Gear == nil {
+			acc.Gear = make(map[string]bool)
+		}
+		ss.accounts[name] = acc
+	}
+	for id, item := range state.Items {
+		ss.items[id] = item
+	}
+	for id, trade := range state.Trades {
+		ss.trades[id] = trade
+	}
+	for id, a := range state.Auctions {
+		ss.auctions[id] = a
+	}
+	ss.nextID = state.NextID
+}
+
+// save - записывает кошельки, обмены и лоты на диск. Вызывать под mutex
+func (ss *ServerShop) save() {
+	state := shopState{
+		Accounts: ss.accounts,
+		Items:    ss.items,
+		Trades:   ss.trades,
+		Auctions: ss.auctions,
+		NextID:   ss.nextID,
+	}
+	raw, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(accountsFile, raw, 0644); err != nil {
+		fmt.Println("⚠️ Не удалось сохранить кошельки лавки:", err)
+	}
+}
+
+// account - кошелёк игрока, создаётся при первом обращении. Вызывать под mutex
+func (ss *ServerShop) account(player string) *ShopAccount {
+	acc, ok := ss.accounts[player]
+	if !ok {
+		acc = &ShopAccount{
+			Balance:   startingBalance,
+			Inventory: make(map[string]int),
+			Gear:      make(map[string]bool),
+		}
+		ss.accounts[player] = acc
+	}
+	return acc
+}
+
+// hashKey - в accounts.json хранится только хеш ключа аккаунта
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleRegister - POST /shop/register, тело: Имя. Выдаёт ключ аккаунта один раз:
+// ok|ключ или error:exists, если ключ этому имени уже выдан
+func (ss *ServerShop) handleRegister(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 1)
+	if !ok {
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	acc := ss.account(parts[0])
+	if acc.KeyHash != "" {
+		fmt.Fprint(w, "error:exists")
+		return
+	}
+	key := newToken()
+	acc.KeyHash = hashKey(key)
+	ss.record(parts[0], "register", "", 0, acc.Balance)
+	fmt.Fprintf(w, "ok|%s", key)
+}
+
+// authorize - совпадает ли ключ из параметра key с ключом аккаунта; иначе 403. Вызывать под mutex
+func (ss *ServerShop) authorize(w http.ResponseWriter, r *http.Request, player string) bool {
+	acc, ok := ss.accounts[player]
+	if !ok || acc.KeyHash == "" ||
+		subtle.ConstantTimeCompare([]byte(acc.KeyHash), []byte(hashKey(r.URL.Query().Get("key")))) != 1 {
+		http.Error(w, "Invalid account key", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// record - журнал сделок в памяти и в файле; после каждой сделки кошельки сохраняются. Вызывать под mutex
+func (ss *ServerShop) record(player, kind, item string, amount, balance int) {
+	tx := Transaction{
+		Time:    time.Now(),
+		Player:  player,
+		Kind:    kind,
+		Item:    item,
+		Amount:  amount,
+		Balance: balance,
+	}
+	ss.transactions = append(ss.transactions, tx)
+
+	line := fmt.Sprintf("%s|%s|%s|%s|%d|%d\n", tx.Time.Format(time.RFC3339), player, kind, item, amount, balance)
+	f, err := os.OpenFile(transactionsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err == nil {
+		f.WriteString(line)
+		f.Close()
+	}
+	ss.save()
+	ss.logCh <- fmt.Sprintf("Лавка: %s %s %s (%d✨, баланс %d)", player, kind, item, amount, balance)
+}
+
+// Credit - начисление воображения сервером (например, за PvP)
+func (ss *ServerShop) Credit(player string, amount int, reason string) {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	acc := ss.account(player)
+	acc.Balance += amount
+	ss.record(player, "reward", reason, amount, acc.Balance)
+}
+
+// Charge - списание воображения сервером (например, взнос за турнир); error:not_enough, если не хватает
+func (ss *ServerShop) Charge(player string, amount int, reason string) error {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	acc := ss.account(player)
+	if acc.Balance < amount {
+		return fmt.Errorf("not_enough")
+	}
+	acc.Balance -= amount
+	ss.record(player, "fee", reason, -amount, acc.Balance)
+	return nil
+}
+
+// Cycle - открытый цикл Новой игры+ игрока (0 - финал ещё не засчитан); кошелёк не создаёт
+func (ss *ServerShop) Cycle(player string) int {
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+
+	if acc, ok := ss.accounts[player]; ok {
+		return acc.Cycle
+	}
+	return 0
+}
+
+// dailyDeals - товары дня одинаковы для всех игроков, пока не сменятся сутки
+func dailyDeals(day time.Time) map[string]bool {
+	h := fnv.New64a()
+	h.Write([]byte(day.Format("2006-01-02")))
+	rng := rand.New(rand.NewSource(int64(h.Sum64())))
+
+	catalog := items.GetAllItems()
+	rng.Shuffle(len(catalog), func(i, j int) { catalog[i], catalog[j] = catalog[j], catalog[i] })
+
+	deals := make(map[string]bool)
+	for i := 0; i < dailyDealsCount && i < len(catalog); i++ {
+		deals[catalog[i].Name] = true
+	}
+	return deals
+}
+
+// catalog - ассортимент лавки для игрока: предметы Новой игры+ открываются
+// только тем, чей финал засчитан сервером
+func catalog(acc *ShopAccount) []*items.Item {
+	result := items.GetAllItems()
+	if acc.Cycle > 0 {
+		result = append(result, items.GetNewGamePlusItems()...)
+	}
+	return result
+}
+
+// findOffer - товар из ассортимента игрока по названию или nil
+func findOffer(acc *ShopAccount, name string) *items.Item {
+	for _, item := range catalog(acc) {
+		if item.Name == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func priceFor(item *items.Item, deals map[string]bool) int {
+	if deals[item.Name] {
+		return item.Price * (100 - dailyDealDiscount) / 100
+	}
+	return item.Price
+}
+
+// handleOffers - GET /shop/offers?player=
+// Первая строка balance:N, дальше по строке на товар: Название|Цена|Редкость|Товар дня(0/1)|Описание
+func (ss *ServerShop) handleOffers(w http.ResponseWriter, r *http.Request) {
+	player := r.URL.Query().Get("player")
+	if player == "" {
+		http.Error(w, "Player required", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	acc := ss.account(player)
+	balance, offers := acc.Balance, catalog(acc)
+	ss.mutex.Unlock()
+
+	deals := dailyDeals(time.Now())
+	fmt.Fprintf(w, "balance:%d\n", balance)
+	for _, item := range offers {
+		deal := 0
+		if deals[item.Name] {
+			deal = 1
+		}
+		fmt.Fprintf(w, "%s|%d|%s|%d|%s\n", item.Name, priceFor(item, deals), item.Rarity, deal, item.Description)
+	}
+}
+
+func (ss *ServerShop) handleBalance(w http.ResponseWriter, r *http.Request) {
+	player := r.URL.Query().Get("player")
+	if player == "" {
+		http.Error(w, "Player required", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	fmt.Fprintf(w, "%d", ss.account(player).Balance)
+}
+
+// handleBuy - POST /shop/buy?key=, тело: Имя|Название предмета
+// Продаётся только то, что есть в ассортименте игрока, иначе error:not_offered.
+// Ответ: ok|баланс, для снаряжения ещё |item:экземпляр в JSON - его свойства выпадают на сервере,
+// а номер экземпляра нужен, чтобы продать или обменять именно этот предмет
+func (ss *ServerShop) handleBuy(w http.ResponseWriter, r *http.Request) {
+	player, itemName, ok := readShopRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	acc := ss.account(player)
+	item := findOffer(acc, itemName)
+	if item == nil {
+		fmt.Fprintf(w, "error:not_offered|%d", acc.Balance)
+		return
+	}
+	price := priceFor(item, dailyDeals(time.Now()))
+	if acc.Balance < price {
+		fmt.Fprintf(w, "error:not_enough|%d", acc.Balance)
+		return
+	}
+	acc.Balance -= price
+	if item.IsConsumable() {
+		acc.Inventory[item.Name]++
+		ss.record(player, "buy", item.Name, price, acc.Balance)
+		fmt.Fprintf(w, "ok|%d", acc.Balance)
+		return
+	}
+
+	instance := items.RollRandom(item)
+	instance.ServerID = ss.newID("item")
+	ss.items[instance.ServerID] = instance
+	acc.Gear[instance.ServerID] = true
+	ss.record(player, "buy", instance.DisplayName(), price, acc.Balance)
+	fmt.Fprintf(w, "ok|%d|%s", acc.Balance, encodeItem(instance))
+}
+
+// encodeItem - экземпляр предмета для ответа клиенту: item:JSON одной строкой
+func encodeItem(item *items.Item) string {
+	raw, _ := json.Marshal(item)
+	return "item:" + string(raw)
+}
+
+// handleSell - POST /shop/sell?key=, тело: Имя|номер экземпляра снаряжения или название расходника
+// Скупаются только товары лавки, которые есть в серверном инвентаре игрока, иначе error:not_owned
+func (ss *ServerShop) handleSell(w http.ResponseWriter, r *http.Request) {
+	player, ref, ok := readShopRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	acc := ss.account(player)
+	if instance, isGear := ss.items[ref]; isGear {
+		if !acc.Gear[ref] {
+			fmt.Fprintf(w, "error:not_owned|%d", acc.Balance)
+			return
+		}
+		price := instance.Price * serverSellPercent / 100
+		delete(acc.Gear, ref)
+		delete(ss.items, ref)
+		acc.Balance += price
+		ss.record(player, "sell", instance.DisplayName(), price, acc.Balance)
+		fmt.Fprintf(w, "ok|%d", acc.Balance)
+		return
+	}
+
+	item := findOffer(acc, ref)
+	if item == nil || !item.IsConsumable() || acc.Inventory[item.Name] <= 0 {
+		fmt.Fprintf(w, "error:not_owned|%d", acc.Balance)
+		return
+	}
+	price := item.Price * serverSellPercent / 100
+	acc.Inventory[item.Name]--
+	if acc.Inventory[item.Name] == 0 {
+		delete(acc.Inventory, item.Name)
+	}
+	acc.Balance += price
+	ss.record(player, "sell", item.Name, price, acc.Balance)
+
+	fmt.Fprintf(w, "ok|%d", acc.Balance)
+}
+
+// handleSync - POST /shop/sync?key=, тело: Имя|Изменение
+// Списывает воображение, потраченное в одиночной игре. Пополнения не принимаются:
+// заработанное начисляет сам сервер - за PvP и через заявки /shop/reward
+func (ss *ServerShop) handleSync(w http.ResponseWriter, r *http.Request) {
+	player, deltaStr, ok := readShopRequest(w, r)
+	if !ok {
+		return
+	}
+
+	delta, err := strconv.Atoi(deltaStr)
+	if err != nil {
+		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	acc := ss.account(player)
+	if delta < 0 {
+		if -delta > acc.Balance {
+			delta = -acc.Balance
+		}
+		acc.Balance += delta
+		ss.record(player, "withdraw", "", delta, acc.Balance)
+	}
+
+	fmt.Fprintf(w, "ok|%d", acc.Balance)
+}
+
+// handleTransactions - GET /shop/transactions?player=&key=, последние сделки игрока
+func (ss *ServerShop) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	player := r.URL.Query().Get("player")
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	for _, tx := range ss.transactions {
+		if tx.Player != player {
+			continue
+		}
+		fmt.Fprintf(w, "%s|%s|%s|%d|%d\n", tx.Time.Format("02.01 15:04"), tx.Kind, tx.Item, tx.Amount, tx.Balance)
+	}
+}
+
+func readShopRequest(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return "", "", false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return "", "", false
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(string(body)), "|", 2)
+	if len(parts) < 2 || parts[0] == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Зритель, не опрашивавший бой дольше этого, больше не считается
+const spectatorTTL = 10 * time.Second
+
+// spectatorCount - сколько зрителей смотрят бой сейчас. Вызывать под match.mutex
+func spectatorCount(match *PvPMatch) int {
+	count := 0
+	for _, seen := range match.Spectators {
+		if time.Since(seen) <= spectatorTTL {
+			count++
+		}
+	}
+	return count
+}
+
+// visibleEvents - сколько первых событий журнала уже можно показать зрителям.
+// События отдаются с задержкой, а ходы - только после расчёта раунда, чтобы зритель
+// не мог подсказать игроку, куда бьёт соперник. Вызывать под match.mutex
+func visibleEvents(match *PvPMatch, delay time.Duration) int {
+	resolved := 0
+	for _, event := range match.Events {
+		if event.Kind == EventRound {
+			resolved = event.Round
+		}
+	}
+	if match.State != MatchActive {
+		// Бой окончен - раунды, которые уже не рассчитать, можно показывать
+		resolved = match.Round
+	}
+
+	cutoff := time.Now().Add(-delay)
+	for i, event := range match.Events {
+		if event.Time.After(cutoff) {
+			return i
+		}
+		if event.Kind == EventMove && event.Round > resolved {
+			return i
+		}
+	}
+	return len(match.Events)
+}
+
+// handleLiveMatches - идущие бои: строки id|игрок1|игрок2|раунд|рейтинг1|рейтинг2|зрителей
+func (s *ChatServer) handleLiveMatches(w http.ResponseWriter, r *http.Request) {
+	s.pvpMutex.RLock()
+	matches := make([]*PvPMatch, 0, len(s.pvpMatches))
+	for _, match := range s.pvpMatches {
+		matches = append(matches, match)
+	}
+	s.pvpMutex.RUnlock()
+
+	for _, match := range matches {
+		match.mutex.RLock()
+		if match.State == MatchActive {
+			fmt.Fprintf(w, "%s|%s|%s|%d|%d|%d|%d\n", match.ID, match.Player1.Name, match.Player2.Name,
+				match.Round, match.Player1.Rating, match.Player2.Rating, spectatorCount(match))
+		}
+		match.mutex.RUnlock()
+	}
+}
+
+// handleWatch - трансляция боя только для чтения: ?id=матч&viewer=зритель&from=курсор.
+// Первая строка: watch:id|игрок1|игрок2|следующий курсор|задержка в секундах|зрителей,
+// дальше новые события в формате записи боя. Трансляция кончается событием end
+func (s *ChatServer) handleWatch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	viewer := query.Get("viewer")
+	from, _ := strconv.Atoi(query.Get("from"))
+
+	s.pvpMutex.RLock()
+	match, exists := s.pvpMatches[query.Get("id")]
+	s.pvpMutex.RUnlock()
+	if !exists {
+		fmt.Fprint(w, "error:not_found")
+		return
+	}
+
+	match.mutex.Lock()
+	defer match.mutex.Unlock()
+
+	if viewer == match.Player1.Name || viewer == match.Player2.Name {
+		fmt.Fprint(w, "error:participant")
+		return
+	}
+	if viewer != "" {
+		match.Spectators[viewer] = time.Now()
+	}
+
+	visible := visibleEvents(match, s.config.SpectatorDelay)
+	if from < 0 || from > visible {
+		from = visible
+	}
+	fmt.Fprintf(w, "watch:%s|%s|%s|%d|%d|%d\n", match.ID, match.Player1.Name, match.Player2.Name,
+		visible, int(s.config.SpectatorDelay.Seconds()), spectatorCount(match))
+	for _, event := range match.Events[from:visible] {
+		fmt.Fprintln(w, formatEvent(event, match.StartedAt))
+	}
+}
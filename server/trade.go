@@ -0,0 +1,401 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Сколько живёт предложение обмена без ответа
+const tradeTTL = 10 * time.Minute
+
+const (
+	TradePending   = "pending"
+	TradeCompleted = "completed"
+	TradeCancelled = "cancelled"
+	TradeDeclined  = "declined"
+	TradeExpired   = "expired"
+)
+
+// TradeOffer - прямой обмен между двумя игроками.
+// Сторона предложения замораживается сразу, сторона ответа - при согласии;
+// обмен проходит только когда подтвердили оба.
+// Предметы в сделках - номера экземпляров снаряжения или названия расходников
+type TradeOffer struct {
+	ID              string
+	From            string
+	To              string
+	FromItems       []string
+	FromImagination int
+	ToItems         []string // до согласия - желаемые названия, после - отданные предметы
+	ToImagination   int
+	FromConfirmed   bool
+	ToConfirmed     bool
+	Status          string
+	CreatedAt       time.Time
+}
+
+// newID - уникальный номер сделки или лота. Вызывать под mutex
+func (ss *ServerShop) newID(prefix string) string {
+	ss.nextID++
+	return fmt.Sprintf("%s_%d", prefix, ss.nextID)
+}
+
+// takeFromAccount - забирает предметы и воображение в залог. Вызывать под mutex
+func (ss *ServerShop) takeFromAccount(player string, refs []string, imagination int) error {
+	acc := ss.account(player)
+	if acc.Balance < imagination {
+		return fmt.Errorf("not_enough")
+	}
+
+	need := make(map[string]int)
+	for _, ref := range refs {
+		need[ref]++
+	}
+	for ref, count := range need {
+		if _, isGear := ss.items[ref]; isGear {
+			if !acc.Gear[ref] || count > 1 {
+				return fmt.Errorf("not_owned:%s", ref)
+			}
+		} else if acc.Inventory[ref] < count {
+			return fmt.Errorf("not_owned:%s", ref)
+		}
+	}
+
+	for ref, count := range need {
+		if _, isGear := ss.items[ref]; isGear {
+			delete(acc.Gear, ref)
+			continue
+		}
+		acc.Inventory[ref] -= count
+		if acc.Inventory[ref] == 0 {
+			delete(acc.Inventory, ref)
+		}
+	}
+	acc.Balance -= imagination
+	return nil
+}
+
+// giveToAccount - зачисляет предметы (с уведомлением клиента через почту) и воображение. Вызывать под mutex
+func (ss *ServerShop) giveToAccount(player string, refs []string, imagination int) {
+	acc := ss.account(player)
+	for _, ref := range refs {
+		if _, isGear := ss.items[ref]; isGear {
+			acc.Gear[ref] = true
+		} else {
+			acc.Inventory[ref]++
+		}
+		acc.Mailbox = append(acc.Mailbox, ref)
+	}
+	acc.Balance += imagination
+}
+
+// itemLabel - название предмета из сделки для списков и журнала: у снаряжения - со свойствами экземпляра
+func (ss *ServerShop) itemLabel(ref string) string {
+	if item, isGear := ss.items[ref]; isGear {
+		return item.DisplayName()
+	}
+	return ref
+}
+
+func (ss *ServerShop) itemLabels(refs []string) string {
+	labels := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		labels = append(labels, ss.itemLabel(ref))
+	}
+	return strings.Join(labels, ",")
+}
+
+// matchWanted - отданные предметы ровно те, что просили: названия совпадают с точностью до количества
+func (ss *ServerShop) matchWanted(wanted, refs []string) bool {
+	if len(wanted) != len(refs) {
+		return false
+	}
+	need := make(map[string]int)
+	for _, name := range wanted {
+		need[name]++
+	}
+	for _, ref := range refs {
+		name := ref
+		if item, isGear := ss.items[ref]; isGear {
+			name = item.Name
+		}
+		if need[name] == 0 {
+			return false
+		}
+		need[name]--
+	}
+	return true
+}
+
+// expireTrades - возвращает залог по просроченным предложениям. Вызывать под mutex
+func (ss *ServerShop) expireTrades(now time.Time) {
+	expired := false
+	for _, trade := range ss.trades {
+		if trade.Status == TradePending && now.Sub(trade.CreatedAt) > tradeTTL {
+			ss.giveToAccount(trade.From, trade.FromItems, trade.FromImagination)
+			trade.Status = TradeExpired
+			ss.logCh <- fmt.Sprintf("Обмен %s истёк", trade.ID)
+			expired = true
+		}
+	}
+	if expired {
+		ss.save()
+	}
+}
+
+// handleTradeOffer - POST /trade/offer
+// Тело: От|Кому|предметы через запятую|воображение|желаемые названия предметов|желаемое воображение
+func (ss *ServerShop) handleTradeOffer(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 6)
+	if !ok {
+		return
+	}
+
+	from, to := parts[0], parts[1]
+	fromImagination, err1 := strconv.Atoi(parts[3])
+	toImagination, err2 := strconv.Atoi(parts[5])
+	if err1 != nil || err2 != nil || fromImagination < 0 || toImagination < 0 {
+		http.Error(w, "Invalid amount", http.StatusBadRequest)
+		return
+	}
+	if to == "" || to == from {
+		http.Error(w, "Invalid partner", http.StatusBadRequest)
+		return
+	}
+
+	trade := &TradeOffer{
+		From:            from,
+		To:              to,
+		FromItems:       splitItems(parts[2]),
+		FromImagination: fromImagination,
+		ToItems:         splitItems(parts[4]),
+		ToImagination:   toImagination,
+		FromConfirmed:   true,
+		Status:          TradePending,
+		CreatedAt:       time.Now(),
+	}
+	if len(trade.FromItems) == 0 && trade.FromImagination == 0 {
+		http.Error(w, "Empty offer", http.StatusBadRequest)
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, from) {
+		return
+	}
+
+	if err := ss.takeFromAccount(from, trade.FromItems, trade.FromImagination); err != nil {
+		fmt.Fprintf(w, "error:%s", err)
+		return
+	}
+	trade.ID = ss.newID("trade")
+	ss.trades[trade.ID] = trade
+	ss.record(from, "trade", "escrow "+trade.ID, -trade.FromImagination, ss.account(from).Balance)
+
+	fmt.Fprintf(w, "ok|%s", trade.ID)
+}
+
+// handleTradeAccept - POST /trade/accept, тело: Имя|ID|отдаваемые предметы через запятую.
+// Предметы должны совпасть с желаемыми названиями, иначе error:wrong_items.
+// Замораживает вторую сторону и проводит обмен
+func (ss *ServerShop) handleTradeAccept(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player, id := parts[0], parts[1]
+	var given []string
+	if len(parts) > 2 {
+		given = splitItems(parts[2])
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireTrades(time.Now())
+
+	trade, exists := ss.trades[id]
+	if !exists || trade.To != player {
+		http.Error(w, "Trade not found", http.StatusNotFound)
+		return
+	}
+	if trade.Status != TradePending {
+		fmt.Fprintf(w, "error:%s", trade.Status)
+		return
+	}
+
+	if !ss.matchWanted(trade.ToItems, given) {
+		fmt.Fprint(w, "error:wrong_items")
+		return
+	}
+	if err := ss.takeFromAccount(player, given, trade.ToImagination); err != nil {
+		fmt.Fprintf(w, "error:%s", err)
+		return
+	}
+	trade.ToItems = given
+	trade.ToConfirmed = true
+
+	if trade.FromConfirmed && trade.ToConfirmed {
+		ss.giveToAccount(trade.To, trade.FromItems, trade.FromImagination)
+		ss.giveToAccount(trade.From, trade.ToItems, trade.ToImagination)
+		trade.Status = TradeCompleted
+		ss.record(trade.From, "trade", "completed "+trade.ID, trade.ToImagination, ss.account(trade.From).Balance)
+		ss.record(trade.To, "trade", "completed "+trade.ID, trade.FromImagination-trade.ToImagination, ss.account(trade.To).Balance)
+	}
+
+	fmt.Fprintf(w, "ok|%d", ss.account(player).Balance)
+}
+
+// handleTradeCancel - POST /trade/cancel, тело: Имя|ID
+// Отправитель отзывает предложение, получатель отклоняет его; залог возвращается отправителю
+func (ss *ServerShop) handleTradeCancel(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 2)
+	if !ok {
+		return
+	}
+	player, id := parts[0], parts[1]
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireTrades(time.Now())
+
+	trade, exists := ss.trades[id]
+	if !exists || (trade.From != player && trade.To != player) {
+		http.Error(w, "Trade not found", http.StatusNotFound)
+		return
+	}
+	if trade.Status != TradePending {
+		fmt.Fprintf(w, "error:%s", trade.Status)
+		return
+	}
+
+	ss.giveToAccount(trade.From, trade.FromItems, trade.FromImagination)
+	if player == trade.From {
+		trade.Status = TradeCancelled
+	} else {
+		trade.Status = TradeDeclined
+	}
+	ss.record(trade.From, "trade", trade.Status+" "+trade.ID, trade.FromImagination, ss.account(trade.From).Balance)
+
+	fmt.Fprint(w, "ok")
+}
+
+// handleTradeList - GET /trade/list?player=
+// Строки: ID|От|Кому|предметы|воображение|желаемые предметы|желаемое воображение|статус
+func (ss *ServerShop) handleTradeList(w http.ResponseWriter, r *http.Request) {
+	player := r.URL.Query().Get("player")
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+	ss.expireTrades(time.Now())
+
+	for _, trade := range ss.trades {
+		if trade.From != player && trade.To != player {
+			continue
+		}
+		if trade.Status != TradePending {
+			continue
+		}
+		fmt.Fprintf(w, "%s|%s|%s|%s|%d|%s|%d|%s\n",
+			trade.ID, trade.From, trade.To,
+			ss.itemLabels(trade.FromItems), trade.FromImagination,
+			strings.Join(trade.ToItems, ","), trade.ToImagination,
+			trade.Status)
+	}
+}
+
+// handleInventory - GET /market/inventory?player=, предметы на сервере: Название|Количество,
+// экземпляры снаряжения - по строке на каждый
+func (ss *ServerShop) handleInventory(w http.ResponseWriter, r *http.Request) {
+	player := r.URL.Query().Get("player")
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, player) {
+		return
+	}
+
+	acc := ss.account(player)
+	for name, count := range acc.Inventory {
+		fmt.Fprintf(w, "%s|%d\n", name, count)
+	}
+	for ref := range acc.Gear {
+		fmt.Fprintf(w, "%s|1\n", ss.itemLabel(ref))
+	}
+}
+
+// handleClaim - POST /market/claim, тело: Имя. Отдаёт клиенту новые предметы из почты:
+// расходники - названием, снаряжение - экземпляром item:JSON
+func (ss *ServerShop) handleClaim(w http.ResponseWriter, r *http.Request) {
+	parts, ok := readMarketRequest(w, r, 1)
+	if !ok {
+		return
+	}
+
+	ss.mutex.Lock()
+	defer ss.mutex.Unlock()
+	if !ss.authorize(w, r, parts[0]) {
+		return
+	}
+	ss.expireTrades(time.Now())
+	ss.expireAuctions(time.Now())
+
+	acc := ss.account(parts[0])
+	if len(acc.Mailbox) == 0 {
+		return
+	}
+	for _, ref := range acc.Mailbox {
+		if item, isGear := ss.items[ref]; isGear {
+			fmt.Fprintln(w, encodeItem(item))
+		} else {
+			fmt.Fprintln(w, ref)
+		}
+	}
+	acc.Mailbox = nil
+	ss.save()
+}
+
+func splitItems(raw string) []string {
+	result := make([]string, 0)
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// readMarketRequest - как readShopRequest, но с произвольным числом полей
+func readMarketRequest(w http.ResponseWriter, r *http.Request, fields int) ([]string, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return nil, false
+	}
+
+	parts := strings.Split(strings.TrimSpace(string(body)), "|")
+	if len(parts) < fields || parts[0] == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return nil, false
+	}
+	return parts, true
+}
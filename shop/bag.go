@@ -0,0 +1,51 @@
+package shop
+
+import (
+	"bufio"
+	"fmt"
+	"game/player"
+	"strings"
+)
+
+const (
+	bagUpgradeSlots = 4   // Ячеек за одно расширение
+	bagUpgradePrice = 100 // Цена первого расширения, каждое следующее дороже на столько же
+	bagMaxSize      = 40
+)
+
+// BagUpgradeCost - цена следующего расширения рюкзака (0 - расширять больше некуда)
+func BagUpgradeCost(p *player.Player) int {
+	if p.BagCapacity() >= bagMaxSize {
+		return 0
+	}
+	done := (p.BagCapacity() - player.DefaultBagSize) / bagUpgradeSlots
+	if done < 0 {
+		done = 0
+	}
+	return bagUpgradePrice * (done + 1)
+}
+
+func BagUpgradeMenu(p *player.Player, reader *bufio.Reader) {
+	cost := BagUpgradeCost(p)
+	if cost == 0 {
+		fmt.Printf("🎒 Рюкзак уже максимального размера: %d ячеек\n", p.BagCapacity())
+		return
+	}
+
+	fmt.Printf("\n🎒 Рюкзак: %d/%d ячеек\n", len(p.Inventory), p.BagCapacity())
+	fmt.Printf("Расширить на %d ячейки за %d✨? (да/нет): ", bagUpgradeSlots, cost)
+	answer, _ := reader.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "да" && answer != "д" && answer != "yes" {
+		return
+	}
+	if !p.SpendImagination(cost) {
+		return
+	}
+
+	p.BagSize = p.BagCapacity() + bagUpgradeSlots
+	if p.BagSize > bagMaxSize {
+		p.BagSize = bagMaxSize
+	}
+	fmt.Printf("✅ Рюкзак расширен: теперь %d ячеек\n", p.BagSize)
+}
@@ -0,0 +1,417 @@
+package shop
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"game/items"
+	"game/player"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RemoteShop - лавка на сервере: цены, товары дня и кошелёк хранит сервер
+type RemoteShop struct {
+	serverURL  string
+	httpClient *http.Client
+	key        string // ключ серверного аккаунта keyOwner, см. AccountKey
+	keyOwner   string
+}
+
+type remoteOffer struct {
+	Item  *items.Item
+	Price int
+	Deal  bool
+}
+
+func NewRemoteShop(serverURL string) *RemoteShop {
+	return &RemoteShop{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		httpClient: &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+	}
+}
+
+// Online - отвечает ли сервер лавки
+func (r *RemoteShop) Online(playerName string) bool {
+	_, err := r.balance(playerName)
+	return err == nil
+}
+
+func (r *RemoteShop) Visit(p *player.Player) {
+	fmt.Println("\n🌐 Лавка подключена к серверу")
+	if err := r.Sync(p); err != nil {
+		fmt.Println("⚠️ Не удалось сверить кошелёк:", err)
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println("\n=== 🏪 ЛАВКА ВООБРАЖЕНИЯ (СЕРВЕР) ===")
+		fmt.Printf("💰 Баланс на сервере: %d\n", p.Imagination)
+		fmt.Println("============================")
+		fmt.Println("1. Купить")
+		fmt.Println("2. Продать")
+		fmt.Println("3. История сделок")
+		fmt.Println("4. Ремонт снаряжения")
+		fmt.Println("5. Расширить рюкзак")
+		fmt.Println("0. Выйти из магазина")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			r.buyMenu(p, reader)
+		case "2":
+			r.sellMenu(p, reader)
+		case "3":
+			r.showTransactions(p)
+		case "4":
+			// Ремонт оплачивается из кошелька клиента, разница уйдёт на сервер при сверке
+			RepairMenu(p, reader)
+			if err := r.Sync(p); err != nil {
+				fmt.Println("⚠️ Не удалось сверить кошелёк:", err)
+			}
+		case "5":
+			BagUpgradeMenu(p, reader)
+			if err := r.Sync(p); err != nil {
+				fmt.Println("⚠️ Не удалось сверить кошелёк:", err)
+			}
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+// Sync - заявляет серверу награды одиночной игры, списывает потраченное с прошлой сверки
+// и принимает баланс сервера. Доход, которого нет в заявках (например, продажа в лавке
+// вне сети), сервер не засчитывает
+func (r *RemoteShop) Sync(p *player.Player) error {
+	if _, err := r.AccountKey(p.Name); err != nil {
+		return err
+	}
+
+	claimed := 0
+	for i, reward := range p.PendingRewards {
+		response, err := r.post(WithKey("/shop/reward", r.key), p.Name+"|"+reward.Claim)
+		if err != nil {
+			// Заявленные награды уже учтены сервером, остальные подождут следующей сверки
+			p.PendingRewards = p.PendingRewards[i:]
+			p.SyncedImagination += claimed
+			return err
+		}
+		if strings.HasPrefix(response, "error:") && reward.Amount > 0 {
+			fmt.Printf("⚠️ Сервер не засчитал награду %d✨ (%s)\n", reward.Amount, strings.TrimPrefix(strings.SplitN(response, "|", 2)[0], "error:"))
+		}
+		claimed += reward.Amount
+	}
+	p.PendingRewards = nil
+
+	// Пополнения сервер не принимает, отправляем только траты
+	delta := min(p.Imagination-p.SyncedImagination-claimed, 0)
+	body := fmt.Sprintf("%s|%d", p.Name, delta)
+	response, err := r.post(WithKey("/shop/sync", r.key), body)
+	if err != nil {
+		return err
+	}
+	return r.applyBalance(p, response)
+}
+
+func (r *RemoteShop) buyMenu(p *player.Player, reader *bufio.Reader) {
+	for {
+		offers, balance, err := r.offers(p.Name)
+		if err != nil {
+			fmt.Println("⚠️ Сервер лавки недоступен:", err)
+			return
+		}
+		p.Imagination = balance
+		p.SyncedImagination = balance
+
+		fmt.Printf("\n💰 Баланс на сервере: %d\n", balance)
+		for i, offer := range offers {
+			color := offer.Item.GetRarityColor()
+			deal := ""
+			if offer.Deal {
+				deal = fmt.Sprintf(" 🔥 товар дня (было %d✨)", offer.Item.Price)
+			}
+			fmt.Printf("%s%d. %s - %d✨%s\033[0m\n", color, i+1, offer.Item.Name, offer.Price, deal)
+			fmt.Printf("   └─ %s\n", offer.Item.Description)
+		}
+
+		fmt.Println("\n0. Назад")
+		fmt.Print("Выберите товар для покупки: ")
+
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+		if choice < 1 || choice > len(offers) {
+			fmt.Println("Неверный номер товара!")
+			continue
+		}
+
+		item := offers[choice-1].Item
+		if !p.HasRoomFor(item) {
+			fmt.Println("❌ Рюкзак полон! Продайте что-нибудь или расширьте рюкзак.")
+			continue
+		}
+		response, err := r.post(WithKey("/shop/buy", r.key), p.Name+"|"+item.Name)
+		if err != nil {
+			fmt.Println("⚠️ Покупка не удалась:", err)
+			continue
+		}
+		if strings.HasPrefix(response, "error:not_enough") {
+			fmt.Println("❌ Недостаточно воображения!")
+			continue
+		}
+		if strings.HasPrefix(response, "error:not_offered") {
+			fmt.Println("❌ Этого товара больше нет в вашем ассортименте")
+			continue
+		}
+		if err := r.applyBalance(p, response); err != nil {
+			fmt.Println("⚠️ Покупка не удалась:", err)
+			continue
+		}
+
+		// Свойства снаряжения выпадают на сервере: он присылает экземпляр с номером для рынка
+		bought := items.RollRandom(item)
+		if parts := strings.SplitN(response, "|", 3); len(parts) == 3 {
+			if instance, err := DecodeItem(parts[2]); err == nil {
+				bought = instance
+			}
+		}
+		p.AddItem(bought)
+		fmt.Printf("✅ Куплено: %s\n", bought.DisplayName())
+	}
+}
+
+func (r *RemoteShop) sellMenu(p *player.Player, reader *bufio.Reader) {
+	for {
+		if len(p.Inventory) == 0 {
+			fmt.Println("Инвентарь пуст - продавать нечего.")
+			return
+		}
+
+		fmt.Println("\n=== 💱 СКУПКА ===")
+		for i, item := range p.Inventory {
+			color := item.GetRarityColor()
+			fmt.Printf("%s%d. %s%s - %d✨\033[0m\n", color, i+1, item.Name, item.QuantityLabel(), SellPrice(item))
+		}
+		fmt.Println("\n0. Назад")
+		fmt.Print("Выберите предмет для продажи: ")
+
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || choice < 0 || choice > len(p.Inventory) {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+
+		item := p.Inventory[choice-1]
+		ref := item.Name
+		if item.ServerID != "" {
+			ref = item.ServerID
+		}
+		response, err := r.post(WithKey("/shop/sell", r.key), p.Name+"|"+ref)
+		if err != nil {
+			fmt.Println("⚠️ Продажа не удалась:", err)
+			continue
+		}
+		if strings.HasPrefix(response, "error:not_owned") {
+			fmt.Println("❌ Сервер не знает об этом предмете: он куплен вне сети и не может быть продан серверной лавке")
+			continue
+		}
+		if err := r.applyBalance(p, response); err != nil {
+			fmt.Println("⚠️ Продажа не удалась:", err)
+			continue
+		}
+
+		p.TakeItem(choice - 1)
+		fmt.Printf("✅ Продано: %s\n", item.Name)
+	}
+}
+
+func (r *RemoteShop) showTransactions(p *player.Player) {
+	path := WithKey("/shop/transactions?player="+url.QueryEscape(p.Name), r.key)
+	resp, err := r.httpClient.Get(r.serverURL + path)
+	if err != nil {
+		fmt.Println("⚠️ Сервер лавки недоступен:", err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		fmt.Println("⚠️ Сервер не показал историю:", strings.TrimSpace(string(body)))
+		return
+	}
+
+	fmt.Println("\n=== 📜 ИСТОРИЯ СДЕЛОК ===")
+	raw := strings.TrimSpace(string(body))
+	if raw == "" {
+		fmt.Println("Сделок пока не было")
+		return
+	}
+	for _, line := range strings.Split(raw, "\n") {
+		parts := strings.Split(line, "|")
+		if len(parts) < 5 {
+			continue
+		}
+		fmt.Printf("%s  %s %s %s✨ (баланс %s)\n", parts[0], parts[1], parts[2], parts[3], parts[4])
+	}
+}
+
+func (r *RemoteShop) offers(playerName string) ([]remoteOffer, int, error) {
+	resp, err := r.httpClient.Get(fmt.Sprintf("%s/shop/offers?player=%s", r.serverURL, url.QueryEscape(playerName)))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "balance:") {
+		return nil, 0, fmt.Errorf("неожиданный ответ сервера")
+	}
+	balance, _ := strconv.Atoi(strings.TrimPrefix(lines[0], "balance:"))
+
+	offers := make([]remoteOffer, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		parts := strings.SplitN(line, "|", 5)
+		if len(parts) < 5 {
+			continue
+		}
+		item := items.FindByName(parts[0])
+		if item == nil {
+			continue
+		}
+		price, _ := strconv.Atoi(parts[1])
+		offers = append(offers, remoteOffer{Item: item, Price: price, Deal: parts[3] == "1"})
+	}
+	return offers, balance, nil
+}
+
+func (r *RemoteShop) balance(playerName string) (int, error) {
+	resp, err := r.httpClient.Get(fmt.Sprintf("%s/shop/balance?player=%s", r.serverURL, url.QueryEscape(playerName)))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("сервер ответил %s", resp.Status)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return strconv.Atoi(strings.TrimSpace(string(body)))
+}
+
+// keyPath - ключ аккаунта лежит в папке сохранений (save.Dir; пакет save сам зависит от лавки),
+// но отдельно от сохранения: новая кампания не должна терять доступ к серверному кошельку
+func keyPath(name string) string {
+	return filepath.Join("saves", name+".key")
+}
+
+// AccountKey - ключ серверного аккаунта игрока. Сервер выдаёт его один раз, при первом обращении,
+// поэтому он хранится в файле; без него сервер не даст распоряжаться кошельком и предметами
+func (r *RemoteShop) AccountKey(name string) (string, error) {
+	if r.keyOwner == name && r.key != "" {
+		return r.key, nil
+	}
+	if raw, err := os.ReadFile(keyPath(name)); err == nil && strings.TrimSpace(string(raw)) != "" {
+		r.key, r.keyOwner = strings.TrimSpace(string(raw)), name
+		return r.key, nil
+	}
+
+	response, err := r.post("/shop/register", name)
+	if err != nil {
+		return "", err
+	}
+	if response == "error:exists" {
+		return "", fmt.Errorf("ключ аккаунта %s уже выдан другой копии игры: перенесите файл %s", name, keyPath(name))
+	}
+	key, found := strings.CutPrefix(response, "ok|")
+	if !found || key == "" {
+		return "", fmt.Errorf("неожиданный ответ сервера: %s", response)
+	}
+	if err := os.MkdirAll(filepath.Dir(keyPath(name)), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(keyPath(name), []byte(key), 0600); err != nil {
+		return "", err
+	}
+	r.key, r.keyOwner = key, name
+	return key, nil
+}
+
+// WithKey - путь запроса с ключом аккаунта в параметре key
+func WithKey(path, key string) string {
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "key=" + url.QueryEscape(key)
+}
+
+func (r *RemoteShop) post(path, body string) (string, error) {
+	resp, err := r.httpClient.Post(r.serverURL+path, "text/plain", strings.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s", strings.TrimSpace(string(raw)))
+	}
+	return string(raw), nil
+}
+
+// DecodeItem - экземпляр предмета из ответа сервера item:JSON
+func DecodeItem(line string) (*items.Item, error) {
+	raw, found := strings.CutPrefix(strings.TrimSpace(line), "item:")
+	if !found {
+		return nil, fmt.Errorf("неожиданный ответ сервера: %s", line)
+	}
+	var item items.Item
+	if err := json.Unmarshal([]byte(raw), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// applyBalance - разбирает ответ вида ok|баланс[|подробности] и принимает баланс как текущий
+func (r *RemoteShop) applyBalance(p *player.Player, response string) error {
+	parts := strings.SplitN(response, "|", 3)
+	if len(parts) < 2 || parts[0] != "ok" {
+		return fmt.Errorf("неожиданный ответ сервера: %s", response)
+	}
+	balance, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return err
+	}
+	p.Imagination = balance
+	p.SyncedImagination = balance
+	return nil
+}
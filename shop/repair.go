@@ -0,0 +1,92 @@
+package shop
+
+import (
+	"bufio"
+	"fmt"
+	"game/items"
+	"game/player"
+	"strconv"
+	"strings"
+)
+
+const (
+	repairPercent   = 50 // Полный ремонт стоит половину цены предмета
+	brokenSurcharge = 50 // Надбавка (в %) за сломанный предмет
+)
+
+// RepairCost - цена восстановления прочности до максимума
+func RepairCost(item *items.Item) int {
+	missing := item.MaxDurability - item.Durability
+	if item.MaxDurability == 0 || missing <= 0 {
+		return 0
+	}
+
+	cost := item.Price * repairPercent / 100 * missing / item.MaxDurability
+	if item.IsBroken() {
+		cost += cost * brokenSurcharge / 100
+	}
+	if cost < 1 {
+		cost = 1
+	}
+	return cost
+}
+
+// repairable - надетые и лежащие в инвентаре предметы с износом
+func repairable(p *player.Player) []*items.Item {
+	result := make([]*items.Item, 0)
+	for _, item := range append(append([]*items.Item{}, p.Equipped...), p.Inventory...) {
+		if RepairCost(item) > 0 {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func RepairMenu(p *player.Player, reader *bufio.Reader) {
+	for {
+		worn := repairable(p)
+		if len(worn) == 0 {
+			fmt.Println("🔧 Всё снаряжение в порядке - чинить нечего.")
+			return
+		}
+
+		total := 0
+		fmt.Println("\n=== 🔧 РЕМОНТ ===")
+		fmt.Printf("💰 Ваше воображение: %d\n", p.Imagination)
+		for i, item := range worn {
+			cost := RepairCost(item)
+			total += cost
+			fmt.Printf("%s%d. %s\033[0m %s - %d✨\n", item.GetRarityColor(), i+1, item.DisplayName(), item.DurabilityLabel(), cost)
+		}
+		fmt.Printf("\n%d. Починить всё - %d✨\n", len(worn)+1, total)
+		fmt.Println("0. Назад")
+		fmt.Print("Что починить: ")
+
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil || choice < 0 || choice > len(worn)+1 {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+
+		switch {
+		case choice == 0:
+			return
+		case choice == len(worn)+1:
+			if !p.SpendImagination(total) {
+				continue
+			}
+			for _, item := range worn {
+				item.Durability = item.MaxDurability
+			}
+			fmt.Println("✅ Всё снаряжение как новое!")
+		default:
+			item := worn[choice-1]
+			if !p.SpendImagination(RepairCost(item)) {
+				continue
+			}
+			item.Durability = item.MaxDurability
+			fmt.Printf("✅ Починено: %s\n", item.DisplayName())
+		}
+	}
+}
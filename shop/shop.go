@@ -1,81 +1,349 @@
-package shop
-
-import (
-	"bufio"
-	"fmt"
-	"game/items"
-	"game/player"
-	"os"
-	"strconv"
-	"strings"
-)
-
-type Shop struct {
-	Items []*items.Item
-}
-
-func NewShop() *Shop {
-	return &Shop{
-		Items: items.GetAllItems(),
-	}
-}
-
-func (s *Shop) Visit(p *player.Player) {
-	for {
-		fmt.Println("\n=== 🏪 ЛАВКА ВООБРАЖЕНИЯ ===")
-		fmt.Printf("💰 Ваше воображение: %d\n", p.Imagination)
-		fmt.Println("============================")
-		
-		// Показываем товары
-		for i, item := range s.Items {
-			color := item.GetRarityColor()
-			fmt.Printf("%s%d. %s - %d✨\033[0m\n", color, i+1, item.Name, item.Price)
-			fmt.Printf("   └─ %s\n", item.Description)
-		}
-		
-		fmt.Println("\n0. Выйти из магазина")
-		fmt.Print("Выберите товар для покупки: ")
-		
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
-		input = strings.TrimSpace(input)
-		
-		choice, err := strconv.Atoi(input)
-		if err != nil {
-			fmt.Println("Неверный ввод!")
-			continue
-		}
-		
-		if choice == 0 {
-			break
-		}
-		
-		if choice < 1 || choice > len(s.Items) {
-			fmt.Println("Неверный номер товара!")
-			continue
-		}
-		
-		selectedItem := s.Items[choice-1]
-		s.BuyItem(p, selectedItem)
-	}
-}
-
-func (s *Shop) BuyItem(p *player.Player, item *items.Item) {
-	if p.Imagination < item.Price {
-		fmt.Println("❌ Недостаточно воображения!")
-		return
-	}
-	
-	if p.SpendImagination(item.Price) {
-		// Создаем копию предмета
-		itemCopy := &items.Item{
-			Name:        item.Name,
-			Description: item.Description,
-			Rarity:      item.Rarity,
-			Effect:      item.Effect,
-			Price:       item.Price,
-		}
-		p.AddItem(itemCopy)
-		fmt.Printf("✅ Куплено: %s\n", item.Name)
-	}
-}
\ No newline at end of file
+package shop
+
+import (
+	"bufio"
+	"fmt"
+	"game/items"
+	"game/player"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	offersCount      = 6  // Сколько товаров на прилавке одновременно
+	sellPercent      = 40 // Какую часть цены лавка платит при скупке
+	fightsPerRotate  = 3  // Через сколько боёв меняется ассортимент
+	maxHistoryRecord = 50
+)
+
+// Редкие предметы реже появляются на прилавке и быстрее заканчиваются
+var rarityWeights = map[items.Rarity]int{
+	items.Common:    60,
+	items.Rare:      30,
+	items.Legendary: 10,
+}
+
+var rarityStock = map[items.Rarity]int{
+	items.Common:    3,
+	items.Rare:      2,
+	items.Legendary: 1,
+}
+
+type Offer struct {
+	Item     *items.Item
+	Stock    int
+	MaxStock int
+}
+
+type Purchase struct {
+	ItemName string
+	Price    int
+	Sold     bool // true - предмет продан лавке
+	Time     time.Time
+}
+
+type Shop struct {
+	Items             []*items.Item // Весь каталог, из которого набираются товары
+	Offers            []*Offer
+	History           []Purchase
+	FightsSinceRotate int
+	restockDisabled   bool
+
+	// Если задано и сервер отвечает, покупки идут через серверную лавку
+	Remote *RemoteShop
+}
+
+func NewShop() *Shop {
+	s := &Shop{
+		Items: items.GetAllItems(),
+	}
+	s.Rotate()
+	return s
+}
+
+// NewFixedShop - разовая лавка с заданными товарами по одной штуке, без пополнения
+func NewFixedShop(stock []*items.Item) *Shop {
+	s := &Shop{Items: stock, restockDisabled: true}
+	for _, item := range stock {
+		s.Offers = append(s.Offers, &Offer{Item: item, Stock: 1, MaxStock: 1})
+	}
+	return s
+}
+
+// UnlockNewGamePlusItems - добавляет в ассортимент предметы Новой игры+ (один раз)
+func (s *Shop) UnlockNewGamePlusItems() {
+	for _, item := range items.GetNewGamePlusItems() {
+		if !s.hasItem(item.Name) {
+			s.Items = append(s.Items, item)
+		}
+	}
+}
+
+func (s *Shop) hasItem(name string) bool {
+	for _, item := range s.Items {
+		if item.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Rotate - новый набор товаров: выбор по весу редкости, без повторов
+func (s *Shop) Rotate() {
+	pool := make([]*items.Item, len(s.Items))
+	copy(pool, s.Items)
+
+	s.Offers = make([]*Offer, 0, offersCount)
+	for len(s.Offers) < offersCount && len(pool) > 0 {
+		total := 0
+		for _, item := range pool {
+			total += rarityWeights[item.Rarity]
+		}
+		if total == 0 {
+			break
+		}
+
+		roll := rand.Intn(total)
+		for i, item := range pool {
+			if roll < rarityWeights[item.Rarity] {
+				stock := rarityStock[item.Rarity]
+				s.Offers = append(s.Offers, &Offer{Item: item, Stock: stock, MaxStock: stock})
+				pool = append(pool[:i], pool[i+1:]...)
+				break
+			}
+			roll -= rarityWeights[item.Rarity]
+		}
+	}
+	s.FightsSinceRotate = 0
+}
+
+// Restock - вызывается после каждого боя: пополняет запасы, а раз в несколько боёв меняет ассортимент
+func (s *Shop) Restock() {
+	if s.restockDisabled {
+		return
+	}
+
+	s.FightsSinceRotate++
+	if s.FightsSinceRotate >= fightsPerRotate {
+		s.Rotate()
+		return
+	}
+	for _, offer := range s.Offers {
+		if offer.Stock < offer.MaxStock {
+			offer.Stock++
+		}
+	}
+}
+
+func (s *Shop) Visit(p *player.Player) {
+	if s.Remote != nil && s.Remote.Online(p.Name) {
+		s.Remote.Visit(p)
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Println("\n=== 🏪 ЛАВКА ВООБРАЖЕНИЯ ===")
+		fmt.Printf("💰 Ваше воображение: %d\n", p.Imagination)
+		fmt.Println("============================")
+		fmt.Println("1. Купить")
+		fmt.Println("2. Продать")
+		fmt.Println("3. История покупок")
+		fmt.Println("4. Ремонт снаряжения")
+		fmt.Println("5. Расширить рюкзак")
+		fmt.Println("0. Выйти из магазина")
+		fmt.Print("Выберите действие: ")
+
+		input, _ := reader.ReadString('\n')
+		switch strings.TrimSpace(input) {
+		case "1":
+			s.buyMenu(p, reader)
+		case "2":
+			s.sellMenu(p, reader)
+		case "3":
+			s.ShowHistory()
+		case "4":
+			RepairMenu(p, reader)
+		case "5":
+			BagUpgradeMenu(p, reader)
+		case "0":
+			return
+		default:
+			fmt.Println("Неверный ввод!")
+		}
+	}
+}
+
+func (s *Shop) buyMenu(p *player.Player, reader *bufio.Reader) {
+	for {
+		fmt.Printf("\n💰 Ваше воображение: %d\n", p.Imagination)
+		if !s.restockDisabled {
+			fmt.Printf("🔄 Новый завоз через %d боя(ёв)\n", fightsPerRotate-s.FightsSinceRotate)
+		}
+
+		// Показываем товары
+		for i, offer := range s.Offers {
+			item := offer.Item
+			color := item.GetRarityColor()
+			stock := fmt.Sprintf("в наличии: %d/%d", offer.Stock, offer.MaxStock)
+			if offer.Stock == 0 {
+				stock = "нет в наличии"
+			}
+			fmt.Printf("%s%d. %s - %d✨ (%s)\033[0m\n", color, i+1, item.Name, item.Price, stock)
+			fmt.Printf("   └─ %s\n", item.Description)
+		}
+
+		fmt.Println("\n0. Назад")
+		fmt.Print("Выберите товар для покупки: ")
+
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		choice, err := strconv.Atoi(input)
+		if err != nil {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+
+		if choice == 0 {
+			return
+		}
+
+		if choice < 1 || choice > len(s.Offers) {
+			fmt.Println("Неверный номер товара!")
+			continue
+		}
+
+		s.BuyItem(p, s.Offers[choice-1])
+	}
+}
+
+func (s *Shop) BuyItem(p *player.Player, offer *Offer) {
+	item := offer.Item
+	if offer.Stock <= 0 {
+		fmt.Println("❌ Товар закончился! Загляните после следующего боя.")
+		return
+	}
+
+	if p.Imagination < item.Price {
+		fmt.Println("❌ Недостаточно воображения!")
+		return
+	}
+
+	if !p.HasRoomFor(item) {
+		fmt.Println("❌ Рюкзак полон! Продайте что-нибудь или расширьте рюкзак.")
+		return
+	}
+
+	if p.SpendImagination(item.Price) {
+		// Каждый купленный экземпляр получает свои случайные свойства
+		itemCopy := items.RollRandom(item)
+		p.AddItem(itemCopy)
+		offer.Stock--
+		s.record(item.Name, item.Price, false)
+		fmt.Printf("✅ Куплено: %s\n", item.Name)
+	}
+}
+
+func (s *Shop) sellMenu(p *player.Player, reader *bufio.Reader) {
+	for {
+		if len(p.Inventory) == 0 {
+			fmt.Println("Инвентарь пуст - продавать нечего.")
+			return
+		}
+
+		fmt.Println("\n=== 💱 СКУПКА ===")
+		for i, item := range p.Inventory {
+			color := item.GetRarityColor()
+			fmt.Printf("%s%d. %s%s - %d✨\033[0m\n", color, i+1, item.Name, item.QuantityLabel(), SellPrice(item))
+		}
+		fmt.Println("\n0. Назад")
+		fmt.Print("Выберите предмет для продажи: ")
+
+		input, _ := reader.ReadString('\n')
+		choice, err := strconv.Atoi(strings.TrimSpace(input))
+		if err != nil {
+			fmt.Println("Неверный ввод!")
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+		s.SellItem(p, choice-1)
+	}
+}
+
+// SellPrice - сколько лавка заплатит за предмет
+func SellPrice(item *items.Item) int {
+	return item.Price * sellPercent / 100
+}
+
+func (s *Shop) SellItem(p *player.Player, index int) bool {
+	if index < 0 || index >= len(p.Inventory) {
+		fmt.Println("Неверный номер предмета!")
+		return false
+	}
+
+	// Из стопки продаётся одна штука
+	item := p.TakeItem(index)
+	price := SellPrice(item)
+	p.AddImagination(price)
+	s.record(item.Name, price, true)
+	fmt.Printf("✅ Продано: %s\n", item.Name)
+	return true
+}
+
+func (s *Shop) record(name string, price int, sold bool) {
+	s.History = append(s.History, Purchase{
+		ItemName: name,
+		Price:    price,
+		Sold:     sold,
+		Time:     time.Now(),
+	})
+	if len(s.History) > maxHistoryRecord {
+		s.History = s.History[len(s.History)-maxHistoryRecord:]
+	}
+}
+
+func (s *Shop) ShowHistory() {
+	fmt.Println("\n=== 📜 ИСТОРИЯ СДЕЛОК ===")
+	if len(s.History) == 0 {
+		fmt.Println("Сделок пока не было")
+		return
+	}
+	for _, record := range s.History {
+		action := "Куплено"
+		sign := "-"
+		if record.Sold {
+			action = "Продано"
+			sign = "+"
+		}
+		fmt.Printf("%s  %s: %s (%s%d✨)\n", record.Time.Format("02.01 15:04"), action, record.ItemName, sign, record.Price)
+	}
+}
+
+// State - сохраняемое состояние лавки (каталог собирается заново из кода)
+type State struct {
+	Offers            []*Offer
+	History           []Purchase
+	FightsSinceRotate int
+}
+
+func (s *Shop) State() State {
+	return State{
+		Offers:            s.Offers,
+		History:           s.History,
+		FightsSinceRotate: s.FightsSinceRotate,
+	}
+}
+
+func (s *Shop) Restore(state State) {
+	if len(state.Offers) > 0 {
+		s.Offers = state.Offers
+	}
+	s.History = state.History
+	s.FightsSinceRotate = state.FightsSinceRotate
+}
@@ -1,98 +1,98 @@
-package story
-
-import (
-	"fmt"
-	"time"
-)
-
-func PrintSlow(text string, delay time.Duration) {
-	for _, char := range text {
-		fmt.Print(string(char))
-		time.Sleep(delay)
-	}
-	fmt.Println()
-}
-
-func Introduction() {
-	PrintSlow("\n=== БИТВА ЗА ВООБРАЖАРИУМ ===", 40*time.Millisecond)
-	PrintSlow("=====================", 40*time.Millisecond)
-
-	PrintSlow("\nВоображариум — мир, созданный силой детского воображения.", 40*time.Millisecond)
-	PrintSlow("Здесь оживают мечты, страхи и самые смелые фантазии.", 40*time.Millisecond)
-
-	PrintSlow("\nНо мир на грани коллапса!", 50*time.Millisecond)
-	PrintSlow("4 великие гильдии сражаются за право стать Хранителем Воображариума.", 40*time.Millisecond)
-	PrintSlow("Только сильнейший получит силу управлять этим миром.", 40*time.Millisecond)
-
-	PrintSlow("\nВы — капитан гильдии «Хранители Снов».", 40*time.Millisecond)
-	PrintSlow("Ваша цель: победить 3 вражеские гильдии и бросить вызов Древнему Хаосу.", 40*time.Millisecond)
-
-	PrintSlow("\n«Воображение — твое главное оружие. Используй его мудро.»", 45*time.Millisecond)
-	PrintSlow("— напутствие Старшего Хранителя.", 40*time.Millisecond)
-
-	fmt.Println("\nНажмите Enter, чтобы начать турнир...")
-}
-
-func GuildIntro(guildName string) {
-	switch guildName {
-	case "Стальные Легенды":
-		PrintSlow("\n⚔️ ГИЛЬДИЯ «СТАЛЬНЫЕ ЛЕГЕНДЫ»", 30*time.Millisecond)
-		PrintSlow("Мастера физической силы и железной воли.", 30*time.Millisecond)
-		PrintSlow("«Сила решает всё!» — их девиз.", 30*time.Millisecond)
-
-	case "Теневые Мечтатели":
-		PrintSlow("\n🌑 ГИЛЬДИЯ «ТЕНЕВЫЕ МЕЧТАТЕЛИ»", 30*time.Millisecond)
-		PrintSlow("Хитрые и непредсказуемые, как сам сон.", 30*time.Millisecond)
-		PrintSlow("«Противник не знает, что мы задумали...»", 30*time.Millisecond)
-
-	case "Искры Творчества":
-		PrintSlow("\n✨ ГИЛЬДИЯ «ИСКРЫ ТВОРЧЕСТВА»", 30*time.Millisecond)
-		PrintSlow("Маги и изобретатели, черпающие силу из идей.", 30*time.Millisecond)
-		PrintSlow("«Творчество — бесконечный источник силы.»", 30*time.Millisecond)
-	}
-}
-
-func BossIntro() {
-	PrintSlow("\n\n👾 ДРЕВНИЙ ХАОС ПРОСЫПАЕТСЯ 👾", 50*time.Millisecond)
-	PrintSlow("========================", 40*time.Millisecond)
-
-	PrintSlow("\nВы победили все гильдии, но битва не окончена...", 40*time.Millisecond)
-	PrintSlow("Ваши сражения разбудили Древнего Хаоса — первобытную силу,", 40*time.Millisecond)
-	PrintSlow("что стоит за всеми конфликтами Воображариума.", 40*time.Millisecond)
-
-	PrintSlow("\n«ХРАНИТЕЛЬ... ТЫ ДУМАЛ, ЧТО ПОБЕДА ОЗНАЧАЕТ КОНЕЦ?»", 60*time.Millisecond)
-	PrintSlow("«Я — НАЧАЛО ВСЕХ ИСТОРИЙ И ИХ НЕИЗБЕЖНЫЙ ФИНАЛ.»", 60*time.Millisecond)
-	PrintSlow("«ДОКАЖИ, ЧТО ТВОЁ ВООБРАЖЕНИЕ СИЛЬНЕЕ ХАОСА.»", 60*time.Millisecond)
-}
-
-func Victory(imagination int) {
-	PrintSlow("\n🏆 ВЫ СТАЛИ ХРАНИТЕЛЕМ ВООБРАЖАРИУМА! 🏆", 50*time.Millisecond)
-	PrintSlow("=================================", 40*time.Millisecond)
-
-	PrintSlow("\nТысячи воображаемых существ приветствуют нового Хранителя.", 40*time.Millisecond)
-	PrintSlow("Древний Хаос усмирён и стал частью равновесия.", 40*time.Millisecond)
-	PrintSlow("4 гильдии признают ваше превосходство.", 40*time.Millisecond)
-
-	PrintSlow("\nВаше воображение достигло уровня: ", 40*time.Millisecond)
-	fmt.Printf("🌟 %d очков 🌟\n", imagination)
-
-	PrintSlow("\n«Воображариум будет процветать под вашей защитой.»", 45*time.Millisecond)
-	PrintSlow("— голоса всех Хранителей прошлого.", 40*time.Millisecond)
-
-	PrintSlow("\n=== ПОБЕДА ===", 60*time.Millisecond)
-	PrintSlow("Спасибо за игру!", 40*time.Millisecond)
-}
-
-func Defeat() {
-	PrintSlow("\n💔 ПОРАЖЕНИЕ... 💔", 50*time.Millisecond)
-	PrintSlow("================", 40*time.Millisecond)
-
-	PrintSlow("\nВоображариум погружается в забвение.", 40*time.Millisecond)
-	PrintSlow("Древний Хаос празднует победу.", 40*time.Millisecond)
-	PrintSlow("Но легенды о храбрецах не умирают...", 40*time.Millisecond)
-
-	PrintSlow("\n«Возможно, в другой жизни ты станешь Хранителем.»", 45*time.Millisecond)
-	PrintSlow("— шепчет угасающий свет.", 40*time.Millisecond)
-
-	PrintSlow("\n=== ИГРА ОКОНЧЕНА ===", 60*time.Millisecond)
-}
+package story
+
+import (
+	"fmt"
+	"time"
+)
+
+func PrintSlow(text string, delay time.Duration) {
+	for _, char := range text {
+		fmt.Print(string(char))
+		time.Sleep(delay)
+	}
+	fmt.Println()
+}
+
+func Introduction() {
+	PrintSlow("\n=== БИТВА ЗА ВООБРАЖАРИУМ ===", 40*time.Millisecond)
+	PrintSlow("=====================", 40*time.Millisecond)
+
+	PrintSlow("\nВоображариум — мир, созданный силой детского воображения.", 40*time.Millisecond)
+	PrintSlow("Здесь оживают мечты, страхи и самые смелые фантазии.", 40*time.Millisecond)
+
+	PrintSlow("\nНо мир на грани коллапса!", 50*time.Millisecond)
+	PrintSlow("4 великие гильдии сражаются за право стать Хранителем Воображариума.", 40*time.Millisecond)
+	PrintSlow("Только сильнейший получит силу управлять этим миром.", 40*time.Millisecond)
+
+	PrintSlow("\nВы — капитан гильдии «Хранители Снов».", 40*time.Millisecond)
+	PrintSlow("Ваша цель: победить 3 вражеские гильдии и бросить вызов Древнему Хаосу.", 40*time.Millisecond)
+
+	PrintSlow("\n«Воображение — твое главное оружие. Используй его мудро.»", 45*time.Millisecond)
+	PrintSlow("— напутствие Старшего Хранителя.", 40*time.Millisecond)
+
+	fmt.Println("\nНажмите Enter, чтобы начать турнир...")
+}
+
+func GuildIntro(guildName string) {
+	switch guildName {
+	case "Стальные Легенды":
+		PrintSlow("\n⚔️ ГИЛЬДИЯ «СТАЛЬНЫЕ ЛЕГЕНДЫ»", 30*time.Millisecond)
+		PrintSlow("Мастера физической силы и железной воли.", 30*time.Millisecond)
+		PrintSlow("«Сила решает всё!» — их девиз.", 30*time.Millisecond)
+
+	case "Теневые Мечтатели":
+		PrintSlow("\n🌑 ГИЛЬДИЯ «ТЕНЕВЫЕ МЕЧТАТЕЛИ»", 30*time.Millisecond)
+		PrintSlow("Хитрые и непредсказуемые, как сам сон.", 30*time.Millisecond)
+		PrintSlow("«Противник не знает, что мы задумали...»", 30*time.Millisecond)
+
+	case "Искры Творчества":
+		PrintSlow("\n✨ ГИЛЬДИЯ «ИСКРЫ ТВОРЧЕСТВА»", 30*time.Millisecond)
+		PrintSlow("Маги и изобретатели, черпающие силу из идей.", 30*time.Millisecond)
+		PrintSlow("«Творчество — бесконечный источник силы.»", 30*time.Millisecond)
+	}
+}
+
+func BossIntro() {
+	PrintSlow("\n\n👾 ДРЕВНИЙ ХАОС ПРОСЫПАЕТСЯ 👾", 50*time.Millisecond)
+	PrintSlow("========================", 40*time.Millisecond)
+
+	PrintSlow("\nВы победили все гильдии, но битва не окончена...", 40*time.Millisecond)
+	PrintSlow("Ваши сражения разбудили Древнего Хаоса — первобытную силу,", 40*time.Millisecond)
+	PrintSlow("что стоит за всеми конфликтами Воображариума.", 40*time.Millisecond)
+
+	PrintSlow("\n«ХРАНИТЕЛЬ... ТЫ ДУМАЛ, ЧТО ПОБЕДА ОЗНАЧАЕТ КОНЕЦ?»", 60*time.Millisecond)
+	PrintSlow("«Я — НАЧАЛО ВСЕХ ИСТОРИЙ И ИХ НЕИЗБЕЖНЫЙ ФИНАЛ.»", 60*time.Millisecond)
+	PrintSlow("«ДОКАЖИ, ЧТО ТВОЁ ВООБРАЖЕНИЕ СИЛЬНЕЕ ХАОСА.»", 60*time.Millisecond)
+}
+
+func Victory(imagination int) {
+	PrintSlow("\n🏆 ВЫ СТАЛИ ХРАНИТЕЛЕМ ВООБРАЖАРИУМА! 🏆", 50*time.Millisecond)
+	PrintSlow("=================================", 40*time.Millisecond)
+
+	PrintSlow("\nТысячи воображаемых существ приветствуют нового Хранителя.", 40*time.Millisecond)
+	PrintSlow("Древний Хаос усмирён и стал частью равновесия.", 40*time.Millisecond)
+	PrintSlow("4 гильдии признают ваше превосходство.", 40*time.Millisecond)
+
+	PrintSlow("\nВаше воображение достигло уровня: ", 40*time.Millisecond)
+	fmt.Printf("🌟 %d очков 🌟\n", imagination)
+
+	PrintSlow("\n«Воображариум будет процветать под вашей защитой.»", 45*time.Millisecond)
+	PrintSlow("— голоса всех Хранителей прошлого.", 40*time.Millisecond)
+
+	PrintSlow("\n=== ПОБЕДА ===", 60*time.Millisecond)
+	PrintSlow("Спасибо за игру!", 40*time.Millisecond)
+}
+
+func Defeat() {
+	PrintSlow("\n💔 ПОРАЖЕНИЕ... 💔", 50*time.Millisecond)
+	PrintSlow("================", 40*time.Millisecond)
+
+	PrintSlow("\nВоображариум погружается в забвение.", 40*time.Millisecond)
+	PrintSlow("Древний Хаос празднует победу.", 40*time.Millisecond)
+	PrintSlow("Но легенды о храбрецах не умирают...", 40*time.Millisecond)
+
+	PrintSlow("\n«Возможно, в другой жизни ты станешь Хранителем.»", 45*time.Millisecond)
+	PrintSlow("— шепчет угасающий свет.", 40*time.Millisecond)
+
+	PrintSlow("\n=== ИГРА ОКОНЧЕНА ===", 60*time.Millisecond)
+}
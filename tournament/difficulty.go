@@ -0,0 +1,111 @@
+package tournament
+
+import "game/boss"
+
+type Difficulty string
+
+const (
+	Story     Difficulty = "story"
+	Normal    Difficulty = "normal"
+	Hard      Difficulty = "hard"
+	Nightmare Difficulty = "nightmare"
+)
+
+// Стартовые показатели Хранителя, от которых считается адаптивная сложность
+const (
+	adaptiveBaseStrength = 20
+	adaptiveBaseMaxHP    = 120
+	adaptiveWeight       = 0.6
+)
+
+// Каждый цикл Новой игры+ усиливает боссов и награды
+const (
+	cycleBossGrowth   = 0.35
+	cycleRewardGrowth = 0.5
+)
+
+type DifficultySettings struct {
+	Title            string
+	HPMultiplier     float64
+	StrengthMult     float64
+	Aggression       float32
+	RewardMultiplier float64
+}
+
+func AllDifficulties() []Difficulty {
+	return []Difficulty{Story, Normal, Hard, Nightmare}
+}
+
+func (d Difficulty) Settings() DifficultySettings {
+	switch d {
+	case Story:
+		return DifficultySettings{Title: "📖 Сказка", HPMultiplier: 0.7, StrengthMult: 0.7, Aggression: 0.15, RewardMultiplier: 0.75}
+	case Hard:
+		return DifficultySettings{Title: "🔥 Испытание", HPMultiplier: 1.3, StrengthMult: 1.25, Aggression: 0.4, RewardMultiplier: 1.5}
+	case Nightmare:
+		return DifficultySettings{Title: "💀 Кошмар", HPMultiplier: 1.7, StrengthMult: 1.5, Aggression: 0.55, RewardMultiplier: 2}
+	default:
+		return DifficultySettings{Title: "⚔️ Обычная", HPMultiplier: 1, StrengthMult: 1, Aggression: 0.3, RewardMultiplier: 1}
+	}
+}
+
+// prepareBoss - масштабирует босса под сложность, а в адаптивном режиме и под снаряжение игрока
+func (t *Tournament) prepareBoss(b *boss.Boss) {
+	settings := t.Rules.Difficulty.Settings()
+	hpMult := settings.HPMultiplier
+	strengthMult := settings.StrengthMult
+
+	cycleMult := 1 + cycleBossGrowth*float64(t.Cycle)
+	hpMult *= cycleMult
+	strengthMult *= cycleMult
+
+	if t.Rules.Adaptive {
+		// Здоровье босса растёт вместе с силой игрока, сила босса - с его здоровьем
+		hpMult *= adaptiveFactor(t.Player.GetStrength(), adaptiveBaseStrength)
+		strengthMult *= adaptiveFactor(t.Player.GetMaxHP(), adaptiveBaseMaxHP)
+	}
+
+	b.Scale(hpMult, strengthMult, settings.Aggression)
+}
+
+func adaptiveFactor(value, base int) float64 {
+	factor := 1 + (float64(value)/float64(base)-1)*adaptiveWeight
+	if factor < 0.5 {
+		factor = 0.5
+	}
+	return factor
+}
+
+// Reward - награда с учётом множителя сложности и цикла Новой игры+
+func Reward(base int, d Difficulty, cycle int) int {
+	cycleMult := 1 + cycleRewardGrowth*float64(cycle)
+	return int(float64(base) * d.Settings().RewardMultiplier * cycleMult)
+}
+
+// GuildReward - награда за победу над гильдией с номером guild (с нуля)
+func GuildReward(guild int, d Difficulty, cycle int) int {
+	return Reward(50+guild*25, d, cycle)
+}
+
+// FinalReward - награда за победу над Древним Хаосом
+func FinalReward(d Difficulty, cycle int) int {
+	return Reward(200, d, cycle)
+}
+
+// Valid - известна ли сложность (название приходит и от клиента)
+func (d Difficulty) Valid() bool {
+	for _, known := range AllDifficulties() {
+		if d == known {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Tournament) difficultyTitle() string {
+	title := t.Rules.Difficulty.Settings().Title
+	if t.Rules.Adaptive {
+		title += " + адаптивная"
+	}
+	return title
+}
@@ -0,0 +1,171 @@
+package tournament
+
+import (
+	"fmt"
+	"game/items"
+	"math/rand"
+)
+
+// Сколько бросков подряд без легендарного предмета, прежде чем он выпадет гарантированно
+const legendaryPity = 10
+
+// LootTable - что может выпасть с босса: веса редкостей и гарантированная награда за первую победу
+type LootTable struct {
+	Rolls         int
+	RarityWeights map[items.Rarity]int
+	FirstClear    []string
+}
+
+// LootState - счётчики добычи, переживающие перезапуск турнира и Новую игру+
+type LootState struct {
+	Pity        int             // Бросков с последнего легендарного предмета
+	FirstClears map[string]bool // Боссы, за которых уже выдана награда первой победы (с учётом цикла)
+}
+
+type lootDrop struct {
+	Item       *items.Item
+	FirstClear bool
+	Pity       bool
+}
+
+const finalBossLootKey = "Древний Хаос"
+
+// Ключ - название гильдии (или finalBossLootKey)
+var lootTables = map[string]LootTable{
+	"⚔️ Стальные Легенды": {
+		Rolls:         1,
+		RarityWeights: map[items.Rarity]int{items.Common: 70, items.Rare: 27, items.Legendary: 3},
+		FirstClear:    []string{"🍯 Банка меда светлячков"},
+	},
+	"🌑 Теневые Мечтатели": {
+		Rolls:         1,
+		RarityWeights: map[items.Rarity]int{items.Common: 60, items.Rare: 33, items.Legendary: 7},
+		FirstClear:    []string{"🧤 Перчатки храбрости"},
+	},
+	"✨ Искры Творчества": {
+		Rolls:         2,
+		RarityWeights: map[items.Rarity]int{items.Common: 50, items.Rare: 38, items.Legendary: 12},
+		FirstClear:    []string{"🛡 Щит сказочного стража"},
+	},
+	finalBossLootKey: {
+		Rolls:         3,
+		RarityWeights: map[items.Rarity]int{items.Common: 30, items.Rare: 45, items.Legendary: 25},
+		FirstClear:    []string{"🔥 Сердце дракончика"},
+	},
+}
+
+// rollLoot - добыча за победу над боссом. Предметы сразу попадают в инвентарь
+func (t *Tournament) rollLoot(key string) []lootDrop {
+	table, ok := lootTables[key]
+	if !ok {
+		return nil
+	}
+	if t.Loot.FirstClears == nil {
+		t.Loot.FirstClears = make(map[string]bool)
+	}
+
+	drops := make([]lootDrop, 0)
+	clearKey := fmt.Sprintf("%d:%s", t.Cycle, key)
+	if !t.Loot.FirstClears[clearKey] {
+		t.Loot.FirstClears[clearKey] = true
+		for _, name := range table.FirstClear {
+			if item := items.FindByName(name); item != nil {
+				drops = append(drops, lootDrop{Item: item, FirstClear: true})
+			}
+		}
+	}
+
+	pool := items.GetAllItems()
+	if t.Cycle > 0 {
+		pool = append(pool, items.GetNewGamePlusItems()...)
+	}
+
+	for i := 0; i < table.Rolls; i++ {
+		rarity := rollRarity(table.RarityWeights)
+		forced := false
+		if rarity != items.Legendary && t.Loot.Pity+1 >= legendaryPity {
+			rarity = items.Legendary
+			forced = true
+		}
+
+		item := randomOfRarity(pool, rarity)
+		if item == nil {
+			continue
+		}
+		if item.Rarity == items.Legendary {
+			t.Loot.Pity = 0
+		} else {
+			t.Loot.Pity++
+		}
+		drops = append(drops, lootDrop{Item: item, Pity: forced})
+	}
+
+	for i := range drops {
+		drops[i].Item = items.RollRandom(drops[i].Item)
+		if !t.Player.PutItem(drops[i].Item) {
+			// Добыча босса не пропадает: ложится сверх вместимости рюкзака
+			t.Player.Inventory = append(t.Player.Inventory, drops[i].Item)
+		}
+	}
+	return drops
+}
+
+func rollRarity(weights map[items.Rarity]int) items.Rarity {
+	order := []items.Rarity{items.Common, items.Rare, items.Legendary}
+	total := 0
+	for _, rarity := range order {
+		total += weights[rarity]
+	}
+	if total == 0 {
+		return items.Common
+	}
+
+	roll := rand.Intn(total)
+	for _, rarity := range order {
+		if roll < weights[rarity] {
+			return rarity
+		}
+		roll -= weights[rarity]
+	}
+	return items.Common
+}
+
+func randomOfRarity(pool []*items.Item, rarity items.Rarity) *items.Item {
+	candidates := make([]*items.Item, 0)
+	for _, item := range pool {
+		if item.Rarity == rarity {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// showLootSummary - экран добычи после победы
+func (t *Tournament) showLootSummary(imagination int, drops []lootDrop) {
+	fmt.Println("\n=== 🎁 ДОБЫЧА ===")
+	fmt.Printf("✨ Воображение: +%d\n", imagination)
+	if len(drops) == 0 {
+		fmt.Println("Предметов не выпало")
+	}
+	for _, drop := range drops {
+		tag := "🎲"
+		switch {
+		case drop.FirstClear:
+			tag = "🏅 Первая победа:"
+		case drop.Pity:
+			tag = "🍀 Гарантированная легенда:"
+		}
+		fmt.Printf("%s %s%s\033[0m - %s\n", tag, drop.Item.GetRarityColor(), drop.Item.DisplayName(), drop.Item.Description)
+		if bonus := drop.Item.BonusDescription(); bonus != "" {
+			fmt.Printf("   ✦ %s\n", bonus)
+		}
+	}
+	fmt.Printf("🔮 До гарантированного легендарного предмета: %d бросков\n", legendaryPity-t.Loot.Pity)
+	if free := t.Player.FreeSlots(); free < 0 {
+		fmt.Printf("⚠️ Рюкзак переполнен на %d ячеек: новые предметы не поместятся, пока не освободите место\n", -free)
+	}
+	fmt.Println("==================")
+}
@@ -1,147 +1,359 @@
-package tournament
-
-import (
-	"fmt"
-	"game/boss"
-	"game/fight"
-	"game/player"
-	"game/story"
-)
-
-type Guild struct {
-	Name     string
-	Boss     *boss.Boss
-	Defeated bool
-}
-
-type Tournament struct {
-	Player       *player.Player
-	Guilds       []Guild
-	CurrentGuild int
-	FinalBoss    *boss.Boss
-	FinalDefeated bool
-}
-
-func NewTournament(p *player.Player) *Tournament {
-	return &Tournament{
-		Player: p,
-		Guilds: []Guild{
-			{
-				Name:     "⚔️ Стальные Легенды",
-				Boss:     boss.NewGuildBoss("Командир Стальных Легенд", 150, 25),
-				Defeated: false,
-			},
-			{
-				Name:     "🌑 Теневые Мечтатели",
-				Boss:     boss.NewGuildBoss("Верховный Теневой", 180, 28),
-				Defeated: false,
-			},
-			{
-				Name:     "✨ Искры Творчества",
-				Boss:     boss.NewGuildBoss("Магистр Творчества", 220, 32),
-				Defeated: false,
-			},
-		},
-		CurrentGuild: 0,
-		FinalBoss:    boss.NewFinalBoss(),
-		FinalDefeated: false,
-	}
-}
-
-func (t *Tournament) IsComplete() bool {
-	// Турнир завершен, если все гильдии побеждены
-	for _, guild := range t.Guilds {
-		if !guild.Defeated {
-			return false
-		}
-	}
-	return true
-}
-
-func (t *Tournament) IsFinalDefeated() bool {
-	return t.FinalDefeated
-}
-
-func (t *Tournament) StartNextGuild() bool {
-	if t.CurrentGuild >= len(t.Guilds) {
-		fmt.Println("Все гильдии уже побеждены!")
-		return false
-	}
-	
-	guild := &t.Guilds[t.CurrentGuild]
-	
-	fmt.Printf("\n%s\n", "========================================")
-	fmt.Printf("ГИЛЬДИЯ: %s\n", guild.Name)
-	fmt.Println("========================================")
-	
-	// Бой с гильдией
-	fight := fight.NewFight(t.Player, guild.Boss)
-	victory := fight.Start()
-	
-	if victory {
-		guild.Defeated = true
-		t.Player.Wins++
-		
-		// Награда
-		reward := 50 + t.CurrentGuild*25
-		t.Player.AddImagination(reward)
-		fmt.Printf("\n✨ Награда: %d воображения!\n", reward)
-		
-		t.CurrentGuild++
-		
-		// Проверяем, все ли гильдии побеждены
-		if t.IsComplete() {
-			fmt.Println("\n🎉 ВЫ ПОБЕДИЛИ ВСЕ ГИЛЬДИИ! 🎉")
-			fmt.Println("Теперь вас ждет финальный бой с Древним Хаосом!")
-		}
-		
-		return true
-	}
-	
-	return false
-}
-
-func (t *Tournament) StartFinalBoss() bool {
-	fmt.Printf("\n%s\n", "========================================")
-	fmt.Printf("ФИНАЛЬНЫЙ БОЙ: %s\n", t.FinalBoss.Name)
-	fmt.Println("========================================")
-	
-	story.BossIntro()
-	
-	fight := fight.NewFight(t.Player, t.FinalBoss)
-	victory := fight.Start()
-	
-	if victory {
-		t.FinalDefeated = true
-		t.Player.Wins++
-		
-		// Финальная награда
-		t.Player.AddImagination(200)
-		story.Victory(t.Player.Imagination)
-		return true
-	}
-	
-	return false
-}
-
-func (t *Tournament) ShowProgress() {
-	fmt.Println("\n=== ПРОГРЕСС ТУРНИРА ===")
-	for i, guild := range t.Guilds {
-		status := "❌ Не побеждена"
-		if guild.Defeated {
-			status = "✅ Побеждена"
-		}
-		fmt.Printf("%d. %s - %s\n", i+1, guild.Name, status)
-	}
-	
-	if t.IsComplete() && !t.FinalDefeated {
-		fmt.Println("\n👾 ФИНАЛЬНЫЙ БОСС: Древний Хаос - ⚔️ ДОСТУПЕН")
-	} else if t.FinalDefeated {
-		fmt.Println("\n👾 ФИНАЛЬНЫЙ БОСС: Древний Хаос - ✅ ПОБЕЖДЕН")
-	}
-	fmt.Println("==========================")
-}
-
-func IntroPlay() {
-	story.Introduction()
-}
\ No newline at end of file
+package tournament
+
+import (
+	"fmt"
+	"game/boss"
+	"game/fight"
+	"game/player"
+	"game/story"
+)
+
+type Guild struct {
+	Name     string
+	Boss     *boss.Boss
+	Defeated bool
+	Attempts int
+}
+
+// Rules - настройки кампании: сложность и последствия поражения
+type Rules struct {
+	Lives                  int        // Количество жизней на кампанию
+	Hardcore               bool       // Одна жизнь, поражение стирает Хранителя
+	ImaginationLossPercent int        // Сколько процентов воображения теряется при поражении
+	LoseConsumable         bool       // Теряется ли случайный расходный предмет
+	Difficulty             Difficulty // Множители силы боссов и наград
+	Adaptive               bool       // Боссы подстраиваются под снаряжение игрока
+}
+
+func DefaultRules() Rules {
+	return Rules{
+		Lives:                  3,
+		Hardcore:               false,
+		ImaginationLossPercent: 20,
+		LoseConsumable:         true,
+		Difficulty:             Normal,
+	}
+}
+
+func HardcoreRules() Rules {
+	return Rules{
+		Lives:                  1,
+		Hardcore:               true,
+		ImaginationLossPercent: 0,
+		LoseConsumable:         false,
+		Difficulty:             Normal,
+	}
+}
+
+type Tournament struct {
+	Player       *player.Player
+	Guilds       []Guild
+	CurrentGuild int
+	FinalBoss    *boss.Boss
+	FinalDefeated bool
+	FinalAttempts int
+	Rules        Rules
+	Lives        int
+	LastDefeat   string
+	GameOver     bool
+	Cycle        int // Номер цикла Новой игры+ (0 - первое прохождение)
+	Loot         LootState
+}
+
+// GuildCount - сколько гильдий нужно победить до финального боя
+const GuildCount = 3
+
+func NewTournament(p *player.Player, rules Rules) *Tournament {
+	if rules.Hardcore {
+		rules.Lives = 1
+	}
+	t := &Tournament{
+		Player: p,
+		Rules:  rules,
+		Lives:  rules.Lives,
+		Guilds: []Guild{
+			{
+				Name:     "⚔️ Стальные Легенды",
+				Boss:     boss.NewGuildBoss("Командир Стальных Легенд", 150, 25),
+				Defeated: false,
+			},
+			{
+				Name:     "🌑 Теневые Мечтатели",
+				Boss:     boss.NewGuildBoss("Верховный Теневой", 180, 28),
+				Defeated: false,
+			},
+			{
+				Name:     "✨ Искры Творчества",
+				Boss:     boss.NewGuildBoss("Магистр Творчества", 220, 32),
+				Defeated: false,
+			},
+		},
+		CurrentGuild: 0,
+		FinalBoss:    boss.NewFinalBoss(),
+		FinalDefeated: false,
+	}
+
+	for i := range t.Guilds {
+		t.prepareBoss(t.Guilds[i].Boss)
+	}
+	t.prepareBoss(t.FinalBoss)
+	return t
+}
+
+func (t *Tournament) IsComplete() bool {
+	// Турнир завершен, если все гильдии побеждены
+	for _, guild := range t.Guilds {
+		if !guild.Defeated {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tournament) IsFinalDefeated() bool {
+	return t.FinalDefeated
+}
+
+func (t *Tournament) StartNextGuild() bool {
+	if t.CurrentGuild >= len(t.Guilds) {
+		fmt.Println("Все гильдии уже побеждены!")
+		return false
+	}
+	
+	if t.GameOver {
+		fmt.Println("💀 Кампания окончена.")
+		return false
+	}
+
+	guild := &t.Guilds[t.CurrentGuild]
+	guild.Attempts++
+	
+	fmt.Printf("\n%s\n", "========================================")
+	fmt.Printf("ГИЛЬДИЯ: %s\n", guild.Name)
+	fmt.Println("========================================")
+	
+	// Бой с гильдией
+	t.prepareBoss(guild.Boss)
+	fight := fight.NewFight(t.Player, guild.Boss)
+	victory := fight.Start()
+	
+	if victory {
+		guild.Defeated = true
+		t.LastDefeat = ""
+		t.Player.Wins++
+		
+		// Награда
+		// Сложность и цикл сервер запоминает при старте прохождения, заявки гильдий их не несут
+		if t.CurrentGuild == 0 {
+			t.Player.Mark(fmt.Sprintf("start|%s|%d", t.Rules.Difficulty, t.Cycle))
+		}
+		reward := GuildReward(t.CurrentGuild, t.Rules.Difficulty, t.Cycle)
+		t.Player.Earn(reward, fmt.Sprintf("guild|%d", t.CurrentGuild))
+		t.showLootSummary(reward, t.rollLoot(guild.Name))
+		
+		t.CurrentGuild++
+		
+		// Проверяем, все ли гильдии побеждены
+		if t.IsComplete() {
+			fmt.Println("\n🎉 ВЫ ПОБЕДИЛИ ВСЕ ГИЛЬДИИ! 🎉")
+			fmt.Println("Теперь вас ждет финальный бой с Древним Хаосом!")
+		}
+		
+		return true
+	}
+	
+	guild.Boss.Reset()
+	t.handleDefeat(guild.Name)
+	return false
+}
+
+func (t *Tournament) StartFinalBoss() bool {
+	if t.GameOver {
+		fmt.Println("💀 Кампания окончена.")
+		return false
+	}
+	t.FinalAttempts++
+
+	fmt.Printf("\n%s\n", "========================================")
+	fmt.Printf("ФИНАЛЬНЫЙ БОЙ: %s\n", t.FinalBoss.Name)
+	fmt.Println("========================================")
+	
+	story.BossIntro()
+	
+	t.prepareBoss(t.FinalBoss)
+	fight := fight.NewFight(t.Player, t.FinalBoss)
+	victory := fight.Start()
+	
+	if victory {
+		t.FinalDefeated = true
+		t.LastDefeat = ""
+		t.Player.Wins++
+		
+		// Финальная награда
+		reward := FinalReward(t.Rules.Difficulty, t.Cycle)
+		t.Player.Earn(reward, "final")
+		t.showLootSummary(reward, t.rollLoot(finalBossLootKey))
+		story.Victory(t.Player.Imagination)
+		return true
+	}
+	
+	t.FinalBoss.Reset()
+	t.handleDefeat("Древний Хаос")
+	return false
+}
+
+// handleDefeat - штрафы за поражение и списание жизни
+func (t *Tournament) handleDefeat(opponent string) {
+	t.LastDefeat = opponent
+	t.Lives--
+
+	fmt.Println("\n=== ПОСЛЕДСТВИЯ ПОРАЖЕНИЯ ===")
+	if t.Rules.ImaginationLossPercent > 0 {
+		t.Player.LoseImagination(t.Rules.ImaginationLossPercent)
+	}
+	if t.Rules.LoseConsumable {
+		t.Player.LoseRandomConsumable()
+	}
+
+	if t.Lives <= 0 {
+		t.GameOver = true
+		story.Defeat()
+		return
+	}
+	fmt.Printf("❤️ Осталось жизней: %d/%d\n", t.Lives, t.Rules.Lives)
+}
+
+// IsGameOver - закончились жизни (или хардкор-поражение)
+func (t *Tournament) IsGameOver() bool {
+	return t.GameOver
+}
+
+// Restart - новая кампания с теми же правилами (после исчерпания жизней)
+func (t *Tournament) Restart() {
+	loot := t.Loot
+	*t = *newCycle(t.Player, t.Rules, t.Cycle)
+	t.Loot = loot
+}
+
+// StartNewGamePlus - следующий цикл: снаряжение остаётся, боссы сильнее и знают новые приёмы
+func (t *Tournament) StartNewGamePlus() {
+	loot := t.Loot
+	*t = *newCycle(t.Player, t.Rules, t.Cycle+1)
+	t.Loot = loot
+	fmt.Printf("\n🌀 НОВАЯ ИГРА+ (цикл %d) 🌀\n", t.Cycle)
+	fmt.Println("Гильдии собрались снова - и они помнят ваши приёмы.")
+	fmt.Println("В Лавке Воображения появились легендарные предметы нового цикла!")
+}
+
+func newCycle(p *player.Player, rules Rules, cycle int) *Tournament {
+	t := NewTournament(p, rules)
+	t.Cycle = cycle
+	for i := range t.Guilds {
+		t.Guilds[i].Boss.LearnNewGamePlusMoves(cycle)
+		t.prepareBoss(t.Guilds[i].Boss)
+	}
+	t.FinalBoss.LearnNewGamePlusMoves(cycle)
+	t.prepareBoss(t.FinalBoss)
+	return t
+}
+
+// Abandon - игрок сдаётся: турнир начинается заново, жизни восстанавливаются
+func (t *Tournament) Abandon() {
+	t.Restart()
+	fmt.Println("🏳 Вы покинули турнир. Гильдии ждут нового вызова.")
+}
+
+func (t *Tournament) ShowProgress() {
+	fmt.Println("\n=== ПРОГРЕСС ТУРНИРА ===")
+	fmt.Printf("🎚 Сложность: %s\n", t.difficultyTitle())
+	if t.Cycle > 0 {
+		fmt.Printf("🌀 Новая игра+: цикл %d\n", t.Cycle)
+	}
+	if t.Rules.Hardcore {
+		fmt.Println("☠️ Режим: хардкор (поражение стирает Хранителя)")
+	} else {
+		fmt.Printf("❤️ Жизни: %d/%d\n", t.Lives, t.Rules.Lives)
+	}
+	for i, guild := range t.Guilds {
+		status := "❌ Не побеждена"
+		if guild.Defeated {
+			status = "✅ Побеждена"
+		}
+		if guild.Attempts > 0 {
+			status += fmt.Sprintf(" (попыток: %d)", guild.Attempts)
+		}
+		fmt.Printf("%d. %s - %s\n", i+1, guild.Name, status)
+	}
+	
+	if t.IsComplete() && !t.FinalDefeated {
+		fmt.Println("\n👾 ФИНАЛЬНЫЙ БОСС: Древний Хаос - ⚔️ ДОСТУПЕН")
+	} else if t.FinalDefeated {
+		fmt.Println("\n👾 ФИНАЛЬНЫЙ БОСС: Древний Хаос - ✅ ПОБЕЖДЕН")
+	}
+	if t.FinalAttempts > 0 {
+		fmt.Printf("   Попыток против Древнего Хаоса: %d\n", t.FinalAttempts)
+	}
+
+	if t.GameOver {
+		fmt.Println("\n💀 Кампания окончена: жизни исчерпаны")
+	} else if t.LastDefeat != "" {
+		fmt.Printf("\n🔁 Последнее поражение: %s\n", t.LastDefeat)
+		fmt.Println("   Можно повторить бой или сдаться и начать турнир заново")
+	}
+	fmt.Println("==========================")
+}
+
+func IntroPlay() {
+	story.Introduction()
+}
+// Progress - сохраняемое состояние кампании (боссы восстанавливаются из правил)
+type Progress struct {
+	Rules         Rules
+	CurrentGuild  int
+	Defeated      []bool
+	Attempts      []int
+	FinalDefeated bool
+	FinalAttempts int
+	Lives         int
+	LastDefeat    string
+	Cycle         int
+	Loot          LootState
+}
+
+func (t *Tournament) Progress() Progress {
+	progress := Progress{
+		Rules:         t.Rules,
+		CurrentGuild:  t.CurrentGuild,
+		FinalDefeated: t.FinalDefeated,
+		FinalAttempts: t.FinalAttempts,
+		Lives:         t.Lives,
+		LastDefeat:    t.LastDefeat,
+		Cycle:         t.Cycle,
+		Loot:          t.Loot,
+	}
+	for _, guild := range t.Guilds {
+		progress.Defeated = append(progress.Defeated, guild.Defeated)
+		progress.Attempts = append(progress.Attempts, guild.Attempts)
+	}
+	return progress
+}
+
+// Restore - собирает турнир заново и применяет сохранённый прогресс
+func Restore(p *player.Player, progress Progress) *Tournament {
+	t := newCycle(p, progress.Rules, progress.Cycle)
+	t.CurrentGuild = progress.CurrentGuild
+	t.FinalDefeated = progress.FinalDefeated
+	t.FinalAttempts = progress.FinalAttempts
+	t.Lives = progress.Lives
+	t.LastDefeat = progress.LastDefeat
+	t.Loot = progress.Loot
+	for i := range t.Guilds {
+		if i < len(progress.Defeated) {
+			t.Guilds[i].Defeated = progress.Defeated[i]
+		}
+		if i < len(progress.Attempts) {
+			t.Guilds[i].Attempts = progress.Attempts[i]
+		}
+	}
+	return t
+}